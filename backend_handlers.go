@@ -2,39 +2,103 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"strconv"
 )
 
-// GetBackends returns a list of HAProxy backends.
-func GetBackends(w http.ResponseWriter, enc Encoder, svc DataSvc) {
+// GetBackends returns a list of HAProxy backends; with ?empty=true, only the backends that have no
+// members are listed. Disabled members are left out of the list unless ?includeDisabled=true.
+func GetBackends(r *http.Request, enc Encoder, svc DataSvc) (int, string, *Error) {
+	opts, e := util{}.parseListOptions(r)
+	if e != nil {
+		return 0, "", NewError(ErrBadData, e)
+	}
+	empty := false
+	if v := r.URL.Query().Get("empty"); v != "" {
+		if empty, e = strconv.ParseBool(v); e != nil {
+			return 0, "", NewErrorf(ErrBadData, "the empty value '%s' is invalid - must be true or false", v)
+		}
+	}
+	includeDisabled := false
+	if v := r.URL.Query().Get("includeDisabled"); v != "" {
+		if includeDisabled, e = strconv.ParseBool(v); e != nil {
+			return 0, "", NewErrorf(ErrBadData, "the includeDisabled value '%s' is invalid - must be true or false", v)
+		}
+	}
+
 	b, err := svc.GetAllBackends()
 	if err != nil {
-		panic(err)
+		return 0, "", err
+	}
+	if !opts.ModifiedSince.IsZero() {
+		b = b.ModifiedSince(opts.ModifiedSince)
+	}
+	if empty {
+		b = b.Empty()
+	}
+	if !includeDisabled {
+		b = b.WithoutDisabledMembers()
+	}
+	return http.StatusOK, util{}.list(enc, b.ToInterfaces(), opts), nil
+}
+
+// GetBackend returns the requested HAProxy backend; with ?raw=true, its stored form is returned
+// instead.
+func GetBackend(r *http.Request, enc Encoder, svc DataSvc, params Params) (int, string, *Error) {
+	asMap, e := parseMemberFormat(r)
+	if e != nil {
+		return 0, "", NewError(ErrBadData, e)
+	}
+	raw, e := util{}.parseRaw(r)
+	if e != nil {
+		return 0, "", NewError(ErrBadData, e)
+	}
+	if raw {
+		return util{}.raw(svc, "backend", params["name"])
+	}
+
+	data, err := svc.GetBackend(params["name"])
+	if err != nil {
+		return 0, "", err
 	}
-	util{}.writeResponse(w, http.StatusOK, enc.EncodeMulti(b.ToInterfaces()...))
+	if data == nil {
+		return 0, "", NewErrorf(ErrNotFound, "the backend with name %s does not exist", params["name"])
+	}
+	var v interface{} = data
+	if asMap {
+		v = &memberMapBackend{data, data.Members.ByName()}
+	}
+	return http.StatusOK, enc.Encode(util{}.project(enc, v, util{}.parseFields(r))), nil
 }
 
-// GetBackend returns the requested HAProxy backend.
-func GetBackend(w http.ResponseWriter, enc Encoder, svc DataSvc, params Params) {
+// HeadBackend reports whether the requested HAProxy backend exists without returning its body.
+func HeadBackend(svc DataSvc, params Params) (int, string, *Error) {
 	data, err := svc.GetBackend(params["name"])
 	if err != nil {
-		panic(err)
+		return 0, "", err
 	}
 	if data == nil {
-		util{}.notFound(w, enc, fmt.Sprintf("the backend with name %s does not exist", params["name"]))
-		return
+		return http.StatusNotFound, "", nil
 	}
-	util{}.writeResponse(w, http.StatusOK, enc.Encode(data))
+	return http.StatusOK, "", nil
+}
+
+// PostBackendsValidate checks a proposed HAProxy backend against the validation rules without saving
+// it; the response lists every field error, with a 400 status if there are any.
+func PostBackendsValidate(r *http.Request, enc Encoder) (int, string, *Error) {
+	b := &Backend{}
+	if err := loadBackendFromRequest(r, enc, b); err != nil {
+		return 0, "", err
+	}
+	status, body := validationResponse(enc, ValidateBackend(b))
+	return status, body, nil
 }
 
 // PutBackend creates or updates an HAProxy backend.
-func PutBackend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc, params Params) {
+func PutBackend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc, params Params) (int, string, *Error) {
 	b := &Backend{}
-	e := loadBackendFromRequest(r, enc, b)
-	if e != nil {
-		util{}.badRequest(w, enc, "the backend data is invalid")
-		return
+	if err := loadBackendFromRequest(r, enc, b); err != nil {
+		return 0, "", err
 	}
 
 	// always use the name identified in the resource
@@ -42,98 +106,372 @@ func PutBackend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc
 
 	existing, err := svc.GetBackend(b.Name)
 	if err != nil {
-		panic(err)
+		return 0, "", err
 	}
 	status := http.StatusOK
 	if existing == nil {
 		status = http.StatusCreated
 	}
 
-	err = svc.SaveBackend(b)
+	if err := svc.SaveBackend(b); err != nil {
+		return 0, "", err
+	}
+	warnSingleMember(w, svc, b)
+	return status, enc.Encode(b), nil
+}
+
+// CreateBackend creates a new HAProxy backend named by the request body; unlike PutBackend, an
+// existing backend is never replaced.
+func CreateBackend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc) (int, string, *Error) {
+	b := &Backend{}
+	if err := loadBackendFromRequest(r, enc, b); err != nil {
+		return 0, "", err
+	}
+
+	existing, err := svc.GetBackend(b.Name)
 	if err != nil {
-		switch err.Type {
-		case ErrBadData:
-			util{}.badRequest(w, enc, err.Error())
-			return
-		default:
-			panic(err)
-		}
+		return 0, "", err
+	}
+	if existing != nil {
+		return 0, "", NewErrorf(ErrConflict, "the backend with name %s already exists", existing.Name)
 	}
 
-	util{}.writeResponse(w, status, enc.Encode(b))
+	if err := svc.SaveBackend(b); err != nil {
+		return 0, "", err
+	}
+	warnSingleMember(w, svc, b)
+	return http.StatusCreated, enc.Encode(b), nil
 }
 
 // PostBackend performs a partial update of an existing HAProxy backend.
-func PostBackend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc, params Params) {
+func PostBackend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc, params Params) (int, string, *Error) {
 	name := params["name"]
 	b, err := svc.GetBackend(name)
 	if err != nil {
-		panic(err)
+		return 0, "", err
 	}
 	if b == nil {
-		util{}.notFound(w, enc, fmt.Sprintf("the backend with name %s does not exist", name))
-		return
+		return 0, "", NewErrorf(ErrNotFound, "the backend with name %s does not exist", name)
 	}
 
-	e := loadBackendFromRequest(r, enc, b)
-	if e != nil {
-		util{}.badRequest(w, enc, "the backend data is invalid")
-		return
+	if err := loadBackendFromRequest(r, enc, b); err != nil {
+		return 0, "", err
+	}
+
+	if err := svc.SaveBackend(b); err != nil {
+		return 0, "", err
 	}
+	warnSingleMember(w, svc, b)
+	return http.StatusOK, enc.Encode(b), nil
+}
 
-	err = svc.SaveBackend(b)
+// PatchBackend applies a JSON Patch (RFC 6902) document to an existing HAProxy backend, e.g. to
+// change the port of a single member without sending the whole backend.
+func PatchBackend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc, params Params) (int, string, *Error) {
+	name := params["name"]
+	b, err := svc.GetBackend(name)
 	if err != nil {
-		switch err.Type {
-		case ErrBadData:
-			util{}.badRequest(w, enc, err.Error())
-			return
-		default:
-			panic(err)
+		return 0, "", err
+	}
+	if b == nil {
+		return 0, "", NewErrorf(ErrNotFound, "the backend with name %s does not exist", name)
+	}
+
+	body, derr := util{}.readBody(r)
+	if derr != nil {
+		return 0, "", derr
+	}
+	patched, e := applyPatch([]byte(enc.Encode(b)), body)
+	if e != nil {
+		return 0, "", NewError(ErrBadData, e)
+	}
+	p := &Backend{}
+	if e := enc.Decode(patched, p); e != nil {
+		return 0, "", NewErrorf(ErrBadData, "the patched backend data is not valid: %v", e)
+	}
+
+	// always use the name identified in the resource
+	p.Name = b.Name
+
+	if err := svc.SaveBackend(p); err != nil {
+		return 0, "", err
+	}
+	warnSingleMember(w, svc, p)
+	return http.StatusOK, enc.Encode(p), nil
+}
+
+// cloneRequest is the serializable body of a request to clone a backend.
+type cloneRequest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// CloneBackend saves a copy of an existing HAProxy backend, including its members, under a new name
+// and optionally with a new version.
+func CloneBackend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc, params Params) (int, string, *Error) {
+	body, derr := util{}.readBody(r)
+	if derr != nil {
+		return 0, "", derr
+	}
+	req := cloneRequest{}
+	if err := enc.Decode(body, &req); err != nil {
+		return 0, "", NewErrorf(ErrBadData, "the clone data is invalid")
+	}
+	if req.Name == "" {
+		return 0, "", NewErrorf(ErrBadData, "the name of the clone is required")
+	}
+
+	name := params["name"]
+	b, derr := svc.CloneBackend(name, req.Name, req.Version)
+	if derr != nil {
+		if derr.Type == ErrNotFound {
+			return 0, "", NewErrorf(ErrNotFound, "the backend with name %s does not exist", name)
 		}
+		return 0, "", derr
 	}
+	warnSingleMember(w, svc, b)
+	return http.StatusCreated, enc.Encode(b), nil
+}
 
-	util{}.writeResponse(w, http.StatusOK, enc.Encode(b))
+// sets a Warning header on the response if the given backend has a single member, when single-member
+// backends are configured to be warned about
+func warnSingleMember(w http.ResponseWriter, svc DataSvc, b *Backend) {
+	if msg := svc.SingleMemberWarning(b); msg != "" {
+		w.Header().Add("Warning", fmt.Sprintf("199 - %q", msg))
+	}
 }
 
-// DeleteBackend removes an HAProxy backend.
-func DeleteBackend(w http.ResponseWriter, enc Encoder, svc DataSvc, params Params) {
+// DeleteBackend removes an HAProxy backend; with ?cascade=true, the default backend of any
+// frontends that reference it is cleared as well.
+func DeleteBackend(r *http.Request, svc DataSvc, params Params) (int, string, *Error) {
 	key := params["name"]
-	err := svc.DeleteBackend(key)
+	var err *Error
+	switch r.URL.Query().Get("cascade") {
+	case "", "false":
+		err = svc.DeleteBackend(key)
+	case "true":
+		err = svc.DeleteBackendCascade(key)
+	default:
+		return 0, "", NewErrorf(ErrBadData, "the cascade value is invalid - must be 'true' or 'false'")
+	}
 	if err != nil {
-		switch err.Type {
-		case ErrNotFound:
-			util{}.notFound(w, enc, fmt.Sprintf("the backend with name %s does not exist", key))
-			return
-		default:
-			panic(err)
+		if err.Type == ErrNotFound {
+			return 0, "", NewErrorf(ErrNotFound, "the backend with name %s does not exist", key)
 		}
+		return 0, "", err
 	}
-	util{}.writeResponse(w, http.StatusNoContent, "")
+	return http.StatusNoContent, "", nil
 }
 
-// GetBackendMembers returns a list of all members in a backend.
-func GetBackendMembers(w http.ResponseWriter, enc Encoder, svc DataSvc, params Params) {
+// GetBackendMembers returns a list of all members in a backend, optionally sorted by name.
+func GetBackendMembers(r *http.Request, enc Encoder, svc DataSvc, params Params) (int, string, *Error) {
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy != "" && sortBy != "name" {
+		return 0, "", NewErrorf(ErrBadData, "the sort value '%s' is invalid - must be 'name'", sortBy)
+	}
+	asMap, e := parseMemberFormat(r)
+	if e != nil {
+		return 0, "", NewError(ErrBadData, e)
+	}
+
 	b, err := svc.GetBackend(params["name"])
 	if err != nil {
-		panic(err)
+		return 0, "", err
 	}
 	if b == nil {
-		util{}.notFound(w, enc, fmt.Sprintf("the backend with name %s does not exist", params["name"]))
-		return
+		return 0, "", NewErrorf(ErrNotFound, "the backend with name %s does not exist", params["name"])
+	}
+	if asMap {
+		return http.StatusOK, enc.Encode(b.Members.ByName()), nil
+	}
+	members := b.Members
+	if sortBy == "name" {
+		members = append(BackendMembers{}, b.Members...)
+		members.SortByName()
+	}
+	return http.StatusOK, enc.EncodeMulti(members.ToInterfaces()...), nil
+}
+
+// GetBackendConfig returns the section of the HAProxy config that the config template renders for
+// the requested backend.
+func GetBackendConfig(w http.ResponseWriter, svc DataSvc, h HAProxy, params Params) (int, string, *Error) {
+	b, err := svc.GetBackend(params["name"])
+	if err != nil {
+		return 0, "", err
+	}
+	if b == nil {
+		return 0, "", NewErrorf(ErrNotFound, "the backend with name %s does not exist", params["name"])
+	}
+
+	rendered, e := h.RenderConfig(Frontends{}, Backends{b}.ToHAProxyBackends())
+	if e != nil {
+		return 0, "", NewErrorf(ErrUnknown, "error rendering the haproxy config template")
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	return http.StatusOK, backendBlock(rendered, b.Name), nil
+}
+
+// SwapBackendMembers replaces the members of an HAProxy backend, disabling and draining the
+// existing members before they are removed.
+func SwapBackendMembers(r *http.Request, enc Encoder, svc DataSvc, params Params) (int, string, *Error) {
+	body, derr := util{}.readBody(r)
+	if derr != nil {
+		return 0, "", derr
+	}
+	members := BackendMembers{}
+	if err := enc.Decode(body, &members); err != nil {
+		if derr, ok := err.(*Error); ok && derr.Type == ErrBadData {
+			return 0, "", NewErrorf(ErrBadData, "the backend members data is invalid: %v", derr)
+		}
+		return 0, "", NewErrorf(ErrBadData, "the backend members data is invalid")
+	}
+
+	name := params["name"]
+	b, derr := svc.SwapBackendMembers(name, members)
+	if derr != nil {
+		if derr.Type == ErrNotFound {
+			return 0, "", NewErrorf(ErrNotFound, "the backend with name %s does not exist", name)
+		}
+		return 0, "", derr
+	}
+	return http.StatusOK, enc.Encode(b), nil
+}
+
+// GetBackendMemberMeta returns the metadata of a member of an HAProxy backend. The metadata keys are
+// user data, so they are returned as stored rather than in the configured JSON field style.
+func GetBackendMemberMeta(svc DataSvc, params Params) (int, string, *Error) {
+	name, member := params["name"], params["memberName"]
+	b, err := svc.GetBackend(name)
+	if err != nil {
+		return 0, "", err
+	}
+	if b != nil {
+		for _, m := range b.Members {
+			if m.Name != member {
+				continue
+			}
+			meta := m.Meta
+			if meta == nil {
+				meta = map[string]string{}
+			}
+			return http.StatusOK, JSONEncoder{}.Encode(meta), nil
+		}
+	}
+	return 0, "", NewErrorf(ErrNotFound, "the member %s of backend %s does not exist", member, name)
+}
+
+// PutBackendMemberMeta replaces the metadata of a member of an HAProxy backend without changing its
+// other fields or reloading HAProxy.
+func PutBackendMemberMeta(r *http.Request, svc DataSvc, params Params) (int, string, *Error) {
+	body, derr := util{}.readBody(r)
+	if derr != nil {
+		return 0, "", derr
+	}
+	meta := map[string]string{}
+	if err := (JSONEncoder{}).Decode(body, &meta); err != nil {
+		return 0, "", NewErrorf(ErrBadData, "the member metadata is invalid")
+	}
+
+	name, member := params["name"], params["memberName"]
+	if derr := svc.SaveBackendMemberMeta(name, member, meta); derr != nil {
+		if derr.Type == ErrNotFound {
+			return 0, "", NewErrorf(ErrNotFound, "the member %s of backend %s does not exist", member, name)
+		}
+		return 0, "", derr
+	}
+	return http.StatusOK, JSONEncoder{}.Encode(meta), nil
+}
+
+// memberVersionRequest is the serializable body of a bulk update of member versions.
+type memberVersionRequest struct {
+	Version        string `json:"version"`
+	BackendVersion bool   `json:"backendVersion"`
+}
+
+// PostBackendMembersVersion sets the version of every member of an HAProxy backend at once, and
+// optionally of the backend itself, without reloading HAProxy.
+func PostBackendMembersVersion(r *http.Request, enc Encoder, svc DataSvc, params Params) (int, string, *Error) {
+	body, derr := util{}.readBody(r)
+	if derr != nil {
+		return 0, "", derr
+	}
+	req := memberVersionRequest{}
+	if err := enc.Decode(body, &req); err != nil {
+		return 0, "", NewErrorf(ErrBadData, "the version data is invalid")
+	}
+	if req.Version == "" {
+		return 0, "", NewErrorf(ErrBadData, "the version is required")
+	}
+
+	name := params["name"]
+	b, derr := svc.SetBackendMemberVersions(name, req.Version, req.BackendVersion)
+	if derr != nil {
+		if derr.Type == ErrNotFound {
+			return 0, "", NewErrorf(ErrNotFound, "the backend %s does not exist", name)
+		}
+		return 0, "", derr
+	}
+	return http.StatusOK, enc.Encode(b), nil
+}
+
+// heartbeatResponse is the serializable result of a batch of member heartbeats.
+type heartbeatResponse struct {
+	Updated  int               `json:"updated"`
+	NotFound []MemberHeartbeat `json:"notFound"`
+}
+
+// PostMembersHeartbeat records the liveness of many backend members at once, without reloading
+// HAProxy.
+func PostMembersHeartbeat(r *http.Request, enc Encoder, svc DataSvc) (int, string, *Error) {
+	body, derr := util{}.readBody(r)
+	if derr != nil {
+		return 0, "", derr
+	}
+	beats := []MemberHeartbeat{}
+	if err := enc.Decode(body, &beats); err != nil {
+		return 0, "", NewErrorf(ErrBadData, "the heartbeat data is invalid")
+	}
+
+	notFound, derr := svc.HeartbeatMembers(beats)
+	if derr != nil {
+		return 0, "", derr
+	}
+	res := &heartbeatResponse{Updated: len(beats) - len(notFound), NotFound: notFound}
+	return http.StatusOK, enc.Encode(res), nil
+}
+
+// memberMapBackend is the serializable form of a backend whose members are keyed by name.
+type memberMapBackend struct {
+	*Backend
+	Members map[string]BackendMember `json:"members"`
+}
+
+// parses the memberFormat query parameter, returning true if members should be keyed by name
+func parseMemberFormat(r *http.Request) (bool, error) {
+	switch format := r.URL.Query().Get("memberFormat"); format {
+	case "", "array":
+		return false, nil
+	case "map":
+		return true, nil
+	default:
+		return false, fmt.Errorf("the memberFormat value '%s' is invalid - must be 'array' or 'map'", format)
 	}
-	util{}.writeResponse(w, http.StatusOK, enc.EncodeMulti(b.Members.ToInterfaces()...))
 }
 
 // parse request body into a Backend instance
-func loadBackendFromRequest(r *http.Request, enc Encoder, b *Backend) *ErrorResponse {
+func loadBackendFromRequest(r *http.Request, enc Encoder, b *Backend) *Error {
 	//TODO: Don't use ReadAll()... reading a terabyte of data in one go would be bad
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		panic(err)
+	body, derr := util{}.readBody(r)
+	if derr != nil {
+		return derr
 	}
-	err = enc.Decode(body, b)
+	err := enc.Decode(body, b)
 	if err != nil {
-		return NewErrorResponse(http.StatusBadRequest, fmt.Sprintf("the backend data is not valid"))
+		if derr, ok := err.(*Error); ok && derr.Type == ErrBadData {
+			return NewErrorf(ErrBadData, "the backend data is not valid: %v", derr)
+		}
+		return NewErrorf(ErrBadData, "the backend data is not valid")
 	}
 	return nil
 }
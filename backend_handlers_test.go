@@ -1,11 +1,15 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"text/template"
+	"time"
 )
 
 type backendHandlersTestCase struct {
@@ -21,6 +25,7 @@ func (c backendHandlersTestCase) execute() {
 		Params:    make(map[string]string),
 		ResWriter: httptest.NewRecorder(),
 	}
+	mocks.Request, _ = http.NewRequest("GET", "/backends", nil)
 
 	// perform setup
 	if c.Setup != nil {
@@ -61,9 +66,10 @@ func Test_GetBackends(t *testing.T) {
 
 	testAction := func(m *backendHandlersMocks) {
 		// retrieve and validate data
-		GetBackends(m.ResWriter, m.Enc, m.Svc)
+		_, body, derr := GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "GetBackends() returned an unexpected error: %v", derr)
 		expBody := m.Enc.EncodeMulti(b1, b2)
-		assert.Equal(t, m.ResWriter.Body.String(), expBody, "GetBackends() returned an unexpected body")
+		assert.Equal(t, body, expBody, "GetBackends() returned an unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -73,41 +79,38 @@ func Test_GetBackends(t *testing.T) {
 	}.execute()
 }
 
-func Test_GetBackends_SvcError(t *testing.T) {
-	setup := func(m *backendHandlersMocks) {
-		m.Svc.GetAllError = NewErrorf(ErrUnknown, "")
-	}
-
+func Test_GetBackends_Empty(t *testing.T) {
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { GetBackends(m.ResWriter, m.Enc, m.Svc) }
-		assert.Panic(t, b, "GetBackends() failed to panic when expected")
+		// retrieve and validate data
+		status, body, derr := GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "GetBackends() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusOK, "GetBackends() returned unexpected status code")
+		assert.Equal(t, body, "[]", "GetBackends() returned an unexpected body")
 	}
 
 	backendHandlersTestCase{
-		Setup:    setup,
+		Setup:    nil,
 		Action:   testAction,
 		Teardown: nil,
 	}.execute()
 }
 
-// ----------------------------------------------
-// GetBackend TESTS
-// ----------------------------------------------
-
-func Test_GetBackend(t *testing.T) {
-	b := bData.OneBackend()
+func Test_GetBackends_Fields(t *testing.T) {
+	b1 := bData.OneBackend()
+	b2 := bData.OtherBackend()
 
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveBackend(b)
-		m.Params["name"] = b.Name
+		m.Svc.SaveBackend(b1)
+		m.Svc.SaveBackend(b2)
+		m.Request, _ = http.NewRequest("GET", "/backends?fields=mode,name", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
 		// retrieve and validate data
-		GetBackend(m.ResWriter, m.Enc, m.Svc, m.Params)
-		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "GetBackend() returned unexpected status code")
-		assert.Equal(t, m.ResWriter.Body.String(), m.Enc.Encode(b), "GetBackend() returned unexpected body")
+		_, body, derr := GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "GetBackends() returned an unexpected error: %v", derr)
+		expBody := fmt.Sprintf(`[{"mode":"%s","name":"%s"},{"mode":"%s","name":"%s"}]`, b1.Mode, b1.Name, b2.Mode, b2.Name)
+		assert.Equal(t, body, expBody, "GetBackends() returned an unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -117,18 +120,30 @@ func Test_GetBackend(t *testing.T) {
 	}.execute()
 }
 
-func Test_GetBackend_DoesNotExist(t *testing.T) {
+// Tests that the GetBackends() handler lists only the backends modified after the modifiedSince
+// time, and rejects an invalid time.
+func Test_GetBackends_ModifiedSince(t *testing.T) {
+	since := time.Date(2015, 6, 12, 14, 0, 0, 0, time.UTC)
+	b1 := bData.OneBackend()
+	b1.ModifiedAt = since.Add(-time.Minute)
+	b2 := bData.OtherBackend()
+	b2.ModifiedAt = since.Add(time.Minute)
+
 	setup := func(m *backendHandlersMocks) {
-		m.Params["name"] = "12345"
+		m.Svc.SaveBackend(b1)
+		m.Svc.SaveBackend(b2)
+		m.Request, _ = http.NewRequest("GET", "/backends?fields=name&modifiedSince=2015-06-12T14:00:00Z", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// retrieve and validate data
-		GetBackend(m.ResWriter, m.Enc, m.Svc, m.Params)
-		expCode := http.StatusNotFound
-		expBody := fmt.Sprintf(`"code":%d`, expCode)
-		assert.Equal(t, m.ResWriter.Code, expCode, "GetBackend() returned unexpected status code")
-		assert.StringContains(t, m.ResWriter.Body.String(), expBody, "GetBackend() returned unexpected body")
+		_, body, derr := GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "GetBackends() returned an unexpected error: %v", derr)
+		assert.Equal(t, body, fmt.Sprintf(`[{"name":"%s"}]`, b2.Name), "GetBackends() returned an unexpected body")
+
+		m.Request, _ = http.NewRequest("GET", "/backends?modifiedSince=yesterday", nil)
+		_, _, derr = GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNotNil(t, derr, "GetBackends() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "GetBackends() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -138,16 +153,32 @@ func Test_GetBackend_DoesNotExist(t *testing.T) {
 	}.execute()
 }
 
-func Test_GetBackend_SvcError(t *testing.T) {
+func Test_GetBackends_EmptyFilter(t *testing.T) {
+	b1 := bData.OneBackend()
+	b2 := bData.OtherBackend()
+	b2.Members = BackendMembers{}
+	b3 := bData.OneBackend()
+	b3.Name = "nil-members"
+	b3.Members = nil
+
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.GetError = NewErrorf(ErrUnknown, "")
-		m.Params["name"] = "12345"
+		m.Svc.SaveBackend(b1)
+		m.Svc.SaveBackend(b2)
+		m.Svc.SaveBackend(b3)
+		m.Request, _ = http.NewRequest("GET", "/backends?fields=name&empty=true", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { GetBackend(m.ResWriter, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "GetBackends() failed to panic when expected")
+		_, body, derr := GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "GetBackends() returned an unexpected error: %v", derr)
+		assert.StringContains(t, body, fmt.Sprintf(`{"name":"%s"}`, b2.Name), "GetBackends() failed to return a backend with no members")
+		assert.StringContains(t, body, fmt.Sprintf(`{"name":"%s"}`, b3.Name), "GetBackends() failed to return a backend with nil members")
+		assert.NotStringContains(t, body, fmt.Sprintf(`{"name":"%s"}`, b1.Name), "GetBackends() returned a backend with members")
+
+		m.Request, _ = http.NewRequest("GET", "/backends?empty=maybe", nil)
+		_, _, derr = GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNotNil(t, derr, "GetBackends() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "GetBackends() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -157,26 +188,37 @@ func Test_GetBackend_SvcError(t *testing.T) {
 	}.execute()
 }
 
-// ----------------------------------------------
-// PutBackend TESTS
-// ----------------------------------------------
-
-func Test_PutBackend_Create(t *testing.T) {
-	b := bData.OneBackend()
+func Test_GetBackends_IncludeDisabled(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+	b.Members[0].Disabled = true
+	disabled := b.Members[0].Name
+	enabled := b.Members[1].Name
 
 	setup := func(m *backendHandlersMocks) {
-		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
+		m.Svc.SaveBackend(b)
+		m.Request, _ = http.NewRequest("GET", "/backends", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test
-		PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
-
-		// assert return values
-		expBody := m.Enc.Encode(b)
-		assert.Equal(t, m.ResWriter.Code, http.StatusCreated, "PutBackend() returned unexpected status code")
-		assert.Equal(t, m.ResWriter.Body.String(), expBody, "PutBackend() returned unexpected body")
+		_, body, derr := GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "GetBackends() returned an unexpected error: %v", derr)
+		assert.NotStringContains(t, body, disabled, "GetBackends() returned a disabled member by default")
+		assert.StringContains(t, body, enabled, "GetBackends() failed to return an enabled member")
+
+		m.Request, _ = http.NewRequest("GET", "/backends?includeDisabled=true", nil)
+		_, body, derr = GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "GetBackends() returned an unexpected error: %v", derr)
+		assert.StringContains(t, body, disabled, "GetBackends() failed to return a disabled member when requested")
+		assert.StringContains(t, body, enabled, "GetBackends() failed to return an enabled member")
+
+		// the stored backend is unchanged
+		stored, _ := m.Svc.GetBackend(b.Name)
+		assert.Equal(t, len(stored.Members), 2, "GetBackends() removed a disabled member from the stored backend")
+
+		m.Request, _ = http.NewRequest("GET", "/backends?includeDisabled=maybe", nil)
+		_, _, derr = GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNotNil(t, derr, "GetBackends() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "GetBackends() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -186,24 +228,16 @@ func Test_PutBackend_Create(t *testing.T) {
 	}.execute()
 }
 
-func Test_PutBackend_Update(t *testing.T) {
-	b1 := bData.OneBackend()
-	b2 := *b1
-	b2.Mode = "new mode"
-
+func Test_GetBackends_SvcError(t *testing.T) {
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveBackend(b1)
-		m.Params["name"] = b2.Name
-		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b2)))
+		m.Svc.GetAllError = NewErrorf(ErrUnknown, "")
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test
-		PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
-
-		// assert return values
-		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "PutBackend() returned unexpected status code")
-		assert.Equal(t, m.ResWriter.Body.String(), m.Enc.Encode(b2), "PutBackend() returned unexpected body")
+		// execute function to test, check for an error
+		_, _, derr := GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNotNil(t, derr, "GetBackends() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrUnknown, "GetBackends() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -213,21 +247,23 @@ func Test_PutBackend_Update(t *testing.T) {
 	}.execute()
 }
 
-func Test_PutBackend_WithInvalidJSON(t *testing.T) {
+func Test_GetBackends_Envelope(t *testing.T) {
+	b1 := bData.OneBackend()
+	b2 := bData.OtherBackend()
+
 	setup := func(m *backendHandlersMocks) {
-		m.Params["name"] = "12345"
-		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(`{"test:true}`))
+		m.Svc.SaveBackend(b1)
+		m.Svc.SaveBackend(b2)
+		m.Request, _ = http.NewRequest("GET", "/backends?envelope=true&limit=1&offset=1", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test
-		PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
-
-		// assert return values
-		expCode := http.StatusBadRequest
-		expBody := fmt.Sprintf(`"code":%d`, expCode)
-		assert.Equal(t, m.ResWriter.Code, expCode, "PutBackend() returned unexpected status code")
-		assert.StringContains(t, m.ResWriter.Body.String(), expBody, "PutBackend() returned unexpected body")
+		// retrieve and validate data
+		status, body, derr := GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "GetBackends() returned an unexpected error: %v", derr)
+		expBody := m.Enc.Encode(&ListEnvelope{Data: []interface{}{b2}, Total: 2, Limit: 1, Offset: 1})
+		assert.Equal(t, status, http.StatusOK, "GetBackends() returned unexpected status code")
+		assert.Equal(t, body, expBody, "GetBackends() returned an unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -237,24 +273,17 @@ func Test_PutBackend_WithInvalidJSON(t *testing.T) {
 	}.execute()
 }
 
-func Test_PutBackend_SvcBadDataError(t *testing.T) {
-	b := bData.OneBackend()
-
+func Test_GetBackends_EnvelopeEmpty(t *testing.T) {
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveError = NewErrorf(ErrBadData, "")
-		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
+		m.Request, _ = http.NewRequest("GET", "/backends?envelope=true", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test
-		PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
-
-		// assert return values
-		expCode := http.StatusBadRequest
-		expBody := fmt.Sprintf(`"code":%d`, expCode)
-		assert.Equal(t, m.ResWriter.Code, expCode, "PutBackend() returned unexpected status code")
-		assert.StringContains(t, m.ResWriter.Body.String(), expBody, "PutBackend() returned unexpected body")
+		// retrieve and validate data
+		_, body, derr := GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "GetBackends() returned an unexpected error: %v", derr)
+		expBody := `{"data":[],"total":0,"limit":0,"offset":0}`
+		assert.Equal(t, body, expBody, "GetBackends() returned an unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -264,19 +293,15 @@ func Test_PutBackend_SvcBadDataError(t *testing.T) {
 	}.execute()
 }
 
-func Test_PutBackend_SvcDBError(t *testing.T) {
-	b := bData.OneBackend()
-
+func Test_GetBackends_InvalidListParams(t *testing.T) {
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveError = NewErrorf(ErrDB, "")
-		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
+		m.Request, _ = http.NewRequest("GET", "/backends?limit=-1", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "PutBackend() failed to panic when expected")
+		_, _, derr := GetBackends(m.Request, m.Enc, m.Svc)
+		assert.EnsureNotNil(t, derr, "GetBackends() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "GetBackends() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -286,19 +311,24 @@ func Test_PutBackend_SvcDBError(t *testing.T) {
 	}.execute()
 }
 
-func Test_PutBackend_SvcSyncError(t *testing.T) {
+// ----------------------------------------------
+// GetBackend TESTS
+// ----------------------------------------------
+
+func Test_GetBackend(t *testing.T) {
 	b := bData.OneBackend()
 
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveError = NewErrorf(ErrSync, "")
+		m.Svc.SaveBackend(b)
 		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "PutBackend() failed to panic when expected")
+		// retrieve and validate data
+		status, body, derr := GetBackend(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "GetBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusOK, "GetBackend() returned unexpected status code")
+		assert.Equal(t, body, m.Enc.Encode(b), "GetBackend() returned unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -308,19 +338,22 @@ func Test_PutBackend_SvcSyncError(t *testing.T) {
 	}.execute()
 }
 
-func Test_PutBackend_SvcOutOfSyncError(t *testing.T) {
+func Test_GetBackend_Raw(t *testing.T) {
 	b := bData.OneBackend()
 
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveError = NewErrorf(ErrOutOfSync, "")
+		m.Svc.SaveBackend(b)
 		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
+		m.Request, _ = http.NewRequest("GET", "/backends/"+b.Name+"?raw=true", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "PutBackend() failed to panic when expected")
+		status, body, derr := GetBackend(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "GetBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusOK, "GetBackend() returned unexpected status code")
+		assert.StringContains(t, body, `"id":"backend/`+b.Name+`"`, "GetBackend() did not return the ID")
+		assert.StringContains(t, body, `"proxyType":"backend"`, "GetBackend() did not return the ProxyType")
+		assert.StringContains(t, body, `"value":{"name":"`+b.Name+`"`, "GetBackend() did not return the stored value")
 	}
 
 	backendHandlersTestCase{
@@ -330,28 +363,16 @@ func Test_PutBackend_SvcOutOfSyncError(t *testing.T) {
 	}.execute()
 }
 
-// ----------------------------------------------
-// PostBackend TESTS
-// ----------------------------------------------
-
-func Test_PostBackend(t *testing.T) {
-	b := bData.OneBackend()
-	b2 := *b
-	b2.Mode = "new mode"
-
+func Test_GetBackend_RawInvalid(t *testing.T) {
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveBackend(b)
-		m.Params["name"] = b2.Name
-		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(`{"mode":"new mode"}`))
+		m.Params["name"] = "12345"
+		m.Request, _ = http.NewRequest("GET", "/backends/12345?raw=yes", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test
-		PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
-
-		// assert return values
-		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "PostBackend() returned unexpected status code")
-		assert.Equal(t, m.ResWriter.Body.String(), m.Enc.Encode(b2), "PostBackend() returned unexpected body")
+		_, _, derr := GetBackend(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "GetBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "GetBackend() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -361,24 +382,32 @@ func Test_PostBackend(t *testing.T) {
 	}.execute()
 }
 
-func Test_PostBackend_WithInvalidJSON(t *testing.T) {
-	b := bData.OneBackend()
+func Test_GetBackend_MemberFormatMap(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
 
 	setup := func(m *backendHandlersMocks) {
 		m.Svc.SaveBackend(b)
 		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(`{"test:true}`))
+		m.Request, _ = http.NewRequest("GET", "/backends/"+b.Name+"?memberFormat=map", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test
-		PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
-
-		// assert return values
-		expCode := http.StatusBadRequest
-		expBody := fmt.Sprintf(`"code":%d`, expCode)
-		assert.Equal(t, m.ResWriter.Code, expCode, "PostBackend() returned unexpected status code")
-		assert.StringContains(t, m.ResWriter.Body.String(), expBody, "PostBackend() returned unexpected body")
+		// retrieve and validate data
+		status, body, derr := GetBackend(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "GetBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusOK, "GetBackend() returned unexpected status code")
+
+		actual := struct {
+			Name    string                   `json:"name"`
+			Members map[string]BackendMember `json:"members"`
+		}{}
+		err := json.Unmarshal([]byte(body), &actual)
+		assert.EnsureNil(t, err, "GetBackend() returned members that are not keyed by name: %v", err)
+		assert.Equal(t, actual.Name, b.Name, "GetBackend() returned unexpected backend")
+		assert.EnsureEqual(t, len(actual.Members), len(b.Members), "GetBackend() returned unexpected number of members")
+		for _, member := range b.Members {
+			assert.Equal(t, actual.Members[member.Name].Host, member.Host, "GetBackend() returned unexpected member %s", member.Name)
+		}
 	}
 
 	backendHandlersTestCase{
@@ -388,23 +417,21 @@ func Test_PostBackend_WithInvalidJSON(t *testing.T) {
 	}.execute()
 }
 
-func Test_PostBackend_SvcNotFoundError(t *testing.T) {
-	b := bData.OneBackend()
+func Test_GetBackend_MemberFormatArray(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
 
 	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
 		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+		m.Request, _ = http.NewRequest("GET", "/backends/"+b.Name+"?memberFormat=array", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test
-		PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
-
-		// assert return values
-		expCode := http.StatusNotFound
-		expBody := fmt.Sprintf(`"code":%d`, expCode)
-		assert.Equal(t, m.ResWriter.Code, expCode, "PostBackend() returned unexpected status code")
-		assert.StringContains(t, m.ResWriter.Body.String(), expBody, "PostBackend() returned unexpected body")
+		// retrieve and validate data
+		status, body, derr := GetBackend(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "GetBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusOK, "GetBackend() returned unexpected status code")
+		assert.Equal(t, body, m.Enc.Encode(b), "GetBackend() returned unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -414,25 +441,17 @@ func Test_PostBackend_SvcNotFoundError(t *testing.T) {
 	}.execute()
 }
 
-func Test_PostBackend_SvcBadDataError(t *testing.T) {
-	b := bData.OneBackend()
-
+func Test_GetBackend_InvalidMemberFormat(t *testing.T) {
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveBackend(b)
-		m.Svc.SaveError = NewErrorf(ErrBadData, "")
-		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+		m.Params["name"] = "12345"
+		m.Request, _ = http.NewRequest("GET", "/backends/12345?memberFormat=list", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test
-		PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
-
-		// assert return values
-		expCode := http.StatusBadRequest
-		expBody := fmt.Sprintf(`"code":%d`, expCode)
-		assert.Equal(t, m.ResWriter.Code, expCode, "PostBackend() returned unexpected status code")
-		assert.StringContains(t, m.ResWriter.Body.String(), expBody, "PostBackend() returned unexpected body")
+		// retrieve and validate data
+		_, _, derr := GetBackend(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "GetBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "GetBackend() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -442,20 +461,22 @@ func Test_PostBackend_SvcBadDataError(t *testing.T) {
 	}.execute()
 }
 
-func Test_PostBackend_SvcDBError(t *testing.T) {
+func Test_GetBackend_Fields(t *testing.T) {
 	b := bData.OneBackend()
 
 	setup := func(m *backendHandlersMocks) {
 		m.Svc.SaveBackend(b)
-		m.Svc.SaveError = NewErrorf(ErrDB, "")
 		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+		m.Request, _ = http.NewRequest("GET", "/backends/"+b.Name+"?fields=name,%20balance,unknown", nil)
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "PostBackend() failed to panic when expected")
+		// retrieve and validate data
+		status, body, derr := GetBackend(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "GetBackend() returned an unexpected error: %v", derr)
+		expBody := fmt.Sprintf(`{"name":"%s","balance":"%s"}`, b.Name, b.Balance)
+		assert.Equal(t, status, http.StatusOK, "GetBackend() returned unexpected status code")
+		assert.Equal(t, body, expBody, "GetBackend() returned unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -465,20 +486,16 @@ func Test_PostBackend_SvcDBError(t *testing.T) {
 	}.execute()
 }
 
-func Test_PostBackend_SvcSyncError(t *testing.T) {
-	b := bData.OneBackend()
-
+func Test_GetBackend_DoesNotExist(t *testing.T) {
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveBackend(b)
-		m.Svc.SaveError = NewErrorf(ErrSync, "")
-		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+		m.Params["name"] = "12345"
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "PostBackend() failed to panic when expected")
+		// retrieve and validate data
+		_, _, derr := GetBackend(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "GetBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrNotFound, "GetBackend() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -488,20 +505,17 @@ func Test_PostBackend_SvcSyncError(t *testing.T) {
 	}.execute()
 }
 
-func Test_PostBackend_SvcOutOfSyncError(t *testing.T) {
-	b := bData.OneBackend()
-
+func Test_GetBackend_SvcError(t *testing.T) {
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveBackend(b)
-		m.Svc.SaveError = NewErrorf(ErrOutOfSync, "")
-		m.Params["name"] = b.Name
-		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+		m.Svc.GetError = NewErrorf(ErrUnknown, "")
+		m.Params["name"] = "12345"
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "PostBackend() failed to panic when expected")
+		// execute function to test, check for an error
+		_, _, derr := GetBackend(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "GetBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrUnknown, "GetBackend() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -512,25 +526,22 @@ func Test_PostBackend_SvcOutOfSyncError(t *testing.T) {
 }
 
 // ----------------------------------------------
-// DeleteBackend TESTS
+// HeadBackend TESTS
 // ----------------------------------------------
 
-func Test_DeleteBackend(t *testing.T) {
-	b := bData.OneBackend()
+func Test_HeadBackend(t *testing.T) {
+	x := bData.OneBackend()
 
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveBackend(b)
-		m.Params["name"] = b.Name
+		m.Svc.SaveBackend(x)
+		m.Params["name"] = x.Name
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test
-		DeleteBackend(m.ResWriter, m.Enc, m.Svc, m.Params)
-
-		// assert return values
-		expCode := http.StatusNoContent
-		assert.Equal(t, m.ResWriter.Code, expCode, "DeleteBackend() returned unexpected status code")
-		assert.Empty(t, m.ResWriter.Body.String(), "DeleteBackend() returned unexpected body")
+		status, body, derr := HeadBackend(m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "HeadBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusOK, "HeadBackend() returned unexpected status code")
+		assert.Equal(t, body, "", "HeadBackend() returned an unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -540,21 +551,16 @@ func Test_DeleteBackend(t *testing.T) {
 	}.execute()
 }
 
-func Test_DeleteBackend_SvcNotFoundError(t *testing.T) {
+func Test_HeadBackend_DoesNotExist(t *testing.T) {
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.DeleteError = NewErrorf(ErrNotFound, "")
 		m.Params["name"] = "12345"
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test
-		DeleteBackend(m.ResWriter, m.Enc, m.Svc, m.Params)
-
-		// assert return values
-		expCode := http.StatusNotFound
-		expBody := fmt.Sprintf(`"code":%d`, expCode)
-		assert.Equal(t, m.ResWriter.Code, expCode, "DeleteBackend() returned unexpected status code")
-		assert.StringContains(t, m.ResWriter.Body.String(), expBody, "DeleteBackend() returned unexpected body")
+		status, body, derr := HeadBackend(m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "HeadBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusNotFound, "HeadBackend() returned unexpected status code")
+		assert.Equal(t, body, "", "HeadBackend() returned an unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -564,16 +570,17 @@ func Test_DeleteBackend_SvcNotFoundError(t *testing.T) {
 	}.execute()
 }
 
-func Test_DeleteBackend_SvcDBError(t *testing.T) {
+func Test_HeadBackend_SvcError(t *testing.T) {
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.DeleteError = NewErrorf(ErrDB, "")
+		m.Svc.GetError = NewErrorf(ErrUnknown, "")
 		m.Params["name"] = "12345"
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { DeleteBackend(m.ResWriter, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "DeleteBackend() failed to panic when expected")
+		// execute function to test, check for an error
+		_, _, derr := HeadBackend(m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "HeadBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrUnknown, "HeadBackend() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -583,16 +590,27 @@ func Test_DeleteBackend_SvcDBError(t *testing.T) {
 	}.execute()
 }
 
-func Test_DeleteBackend_SvcSyncError(t *testing.T) {
+// ----------------------------------------------
+// PutBackend TESTS
+// ----------------------------------------------
+
+func Test_PutBackend_Create(t *testing.T) {
+	b := bData.OneBackend()
+
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.DeleteError = NewErrorf(ErrSync, "")
-		m.Params["name"] = "12345"
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { DeleteBackend(m.ResWriter, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "DeleteBackend() failed to panic when expected")
+		// execute function to test
+		status, body, derr := PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "PutBackend() returned an unexpected error: %v", derr)
+
+		// assert return values
+		expBody := m.Enc.Encode(b)
+		assert.Equal(t, status, http.StatusCreated, "PutBackend() returned unexpected status code")
+		assert.Equal(t, body, expBody, "PutBackend() returned unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -602,16 +620,25 @@ func Test_DeleteBackend_SvcSyncError(t *testing.T) {
 	}.execute()
 }
 
-func Test_DeleteBackend_SvcOutOfSyncError(t *testing.T) {
+func Test_PutBackend_Update(t *testing.T) {
+	b1 := bData.OneBackend()
+	b2 := *b1
+	b2.Mode = "new mode"
+
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.DeleteError = NewErrorf(ErrOutOfSync, "")
-		m.Params["name"] = "12345"
+		m.Svc.SaveBackend(b1)
+		m.Params["name"] = b2.Name
+		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b2)))
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { DeleteBackend(m.ResWriter, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "DeleteBackend() failed to panic when expected")
+		// execute function to test
+		status, body, derr := PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "PutBackend() returned an unexpected error: %v", derr)
+
+		// assert return values
+		assert.Equal(t, status, http.StatusOK, "PutBackend() returned unexpected status code")
+		assert.Equal(t, body, m.Enc.Encode(b2), "PutBackend() returned unexpected body")
 	}
 
 	backendHandlersTestCase{
@@ -621,27 +648,19 @@ func Test_DeleteBackend_SvcOutOfSyncError(t *testing.T) {
 	}.execute()
 }
 
-// ----------------------------------------------
-// GetBackendMembers TESTS
-// ----------------------------------------------
-
-func Test_GetBackendMembers(t *testing.T) {
-	b := bData.OneBackendMultiMembers()
-
+func Test_PutBackend_WithInvalidJSON(t *testing.T) {
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.SaveBackend(b)
-		m.Params["name"] = b.Name
+		m.Params["name"] = "12345"
+		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(`{"test:true}`))
 	}
 
 	testAction := func(m *backendHandlersMocks) {
 		// execute function to test
-		GetBackendMembers(m.ResWriter, m.Enc, m.Svc, m.Params)
+		_, _, derr := PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
 
 		// assert return values
-		expCode := http.StatusOK
-		expBody := m.Enc.Encode(b.Members)
-		assert.Equal(t, expCode, m.ResWriter.Code, "GetBackendMembers() returned unexpected status code")
-		assert.Equal(t, expBody, m.ResWriter.Body.String(), "GetBackendMembers() returned unexpected body")
+		assert.EnsureNotNil(t, derr, "PutBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "PutBackend() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -651,20 +670,22 @@ func Test_GetBackendMembers(t *testing.T) {
 	}.execute()
 }
 
-func Test_GetBackendMembers_DoesNotExist(t *testing.T) {
+func Test_PutBackend_SvcBadDataError(t *testing.T) {
+	b := bData.OneBackend()
+
 	setup := func(m *backendHandlersMocks) {
-		m.Params["name"] = "12345"
+		m.Svc.SaveError = NewErrorf(ErrBadData, "")
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
 	}
 
 	testAction := func(m *backendHandlersMocks) {
 		// execute function to test
-		GetBackendMembers(m.ResWriter, m.Enc, m.Svc, m.Params)
+		_, _, derr := PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
 
 		// assert return values
-		expCode := http.StatusNotFound
-		expBody := fmt.Sprintf(`"code":%d`, expCode)
-		assert.Equal(t, m.ResWriter.Code, expCode, "GetBackendMembers() returned unexpected status code")
-		assert.StringContains(t, m.ResWriter.Body.String(), expBody, "GetBackendMembers() returned unexpected body")
+		assert.EnsureNotNil(t, derr, "PutBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "PutBackend() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -674,16 +695,20 @@ func Test_GetBackendMembers_DoesNotExist(t *testing.T) {
 	}.execute()
 }
 
-func Test_GetBackendMembers_SvcError(t *testing.T) {
+func Test_PutBackend_SvcDBError(t *testing.T) {
+	b := bData.OneBackend()
+
 	setup := func(m *backendHandlersMocks) {
-		m.Svc.GetError = NewErrorf(ErrUnknown, "")
-		m.Params["name"] = "12345"
+		m.Svc.SaveError = NewErrorf(ErrDB, "")
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
 	}
 
 	testAction := func(m *backendHandlersMocks) {
-		// execute function to test, check for panic
-		b := func() { GetBackendMembers(m.ResWriter, m.Enc, m.Svc, m.Params) }
-		assert.Panic(t, b, "GetBackendMembers() failed to panic when expected")
+		// execute function to test, check for an error
+		_, _, derr := PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "PutBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrDB, "PutBackend() returned an unexpected error type")
 	}
 
 	backendHandlersTestCase{
@@ -693,9 +718,1137 @@ func Test_GetBackendMembers_SvcError(t *testing.T) {
 	}.execute()
 }
 
-// ----------------------------------------------
-// loadBackendFromRequest TESTS
-// ----------------------------------------------
+func Test_PutBackend_SvcSyncError(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveError = NewErrorf(ErrSync, "")
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test, check for an error
+		_, _, derr := PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "PutBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrSync, "PutBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PutBackend_SvcOutOfSyncError(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveError = NewErrorf(ErrOutOfSync, "")
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test, check for an error
+		_, _, derr := PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "PutBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrOutOfSync, "PutBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PutBackend_PreserveUnknownFields(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Enc = JSONEncoder{PreserveUnknownFields: true}
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(`{"balance":"roundrobin","owner":"team-a"}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "PutBackend() returned an unexpected error: %v", derr)
+		assert.EnsureEqual(t, status, http.StatusCreated, "PutBackend() returned unexpected status code")
+
+		// assert the unknown field survives a save and get
+		_, body, _ = GetBackend(httptest.NewRequest("GET", "/backends/"+m.Params["name"], nil), m.Enc, m.Svc, m.Params)
+		assert.StringContains(t, body, `"meta":{"owner":"team-a"}`, "GetBackend() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+// Tests that PutBackend() sets a Warning header when a backend with a single member is saved, and
+// not when the backend has several members.
+func Test_PutBackend_SingleMemberWarning(t *testing.T) {
+	for _, c := range []struct {
+		Backend *Backend
+		Exp     string
+	}{
+		{bData.OneBackend(), `199 - "single member"`},
+		{bData.OneBackendMultiMembers(), ""},
+	} {
+		b := c.Backend
+		setup := func(m *backendHandlersMocks) {
+			m.Svc.MemberWarning = "single member"
+			m.Params["name"] = b.Name
+			m.Request, _ = http.NewRequest("PUT", "/backends", strings.NewReader(m.Enc.Encode(b)))
+		}
+
+		testAction := func(m *backendHandlersMocks) {
+			// execute function to test
+			status, _, derr := PutBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+			assert.EnsureNil(t, derr, "PutBackend() returned an unexpected error: %v", derr)
+
+			// assert return values
+			assert.Equal(t, status, http.StatusCreated, "PutBackend() returned unexpected status code")
+			assert.Equal(t, m.ResWriter.Header().Get("Warning"), c.Exp, "PutBackend() set an unexpected Warning header for %s", b.Name)
+		}
+
+		backendHandlersTestCase{
+			Setup:    setup,
+			Action:   testAction,
+			Teardown: nil,
+		}.execute()
+	}
+}
+
+// ----------------------------------------------
+// PostBackend TESTS
+// ----------------------------------------------
+
+func Test_CreateBackend(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := CreateBackend(m.ResWriter, m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "CreateBackend() returned an unexpected error: %v", derr)
+
+		// assert return values
+		assert.Equal(t, status, http.StatusCreated, "CreateBackend() returned unexpected status code")
+		assert.Equal(t, body, m.Enc.Encode(b), "CreateBackend() returned unexpected body")
+		saved, _ := m.Svc.GetBackend(b.Name)
+		assert.NotNil(t, saved, "CreateBackend() failed to save the backend")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_CreateBackend_Exists(t *testing.T) {
+	b := bData.OneBackend()
+	b2 := *b
+	b2.Mode = "new mode"
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b2)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := CreateBackend(m.ResWriter, m.Request, m.Enc, m.Svc)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "CreateBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrConflict, "CreateBackend() returned an unexpected error type")
+		saved, _ := m.Svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, saved, "CreateBackend() removed the existing backend")
+		assert.Equal(t, saved.Mode, b.Mode, "CreateBackend() replaced the existing backend")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_CreateBackend_WithInvalidJSON(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(`{"test:true}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := CreateBackend(m.ResWriter, m.Request, m.Enc, m.Svc)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "CreateBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "CreateBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackend(t *testing.T) {
+	b := bData.OneBackend()
+	b2 := *b
+	b2.Mode = "new mode"
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b2.Name
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(`{"mode":"new mode"}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "PostBackend() returned an unexpected error: %v", derr)
+
+		// assert return values
+		assert.Equal(t, status, http.StatusOK, "PostBackend() returned unexpected status code")
+		assert.Equal(t, body, m.Enc.Encode(b2), "PostBackend() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackend_WithInvalidJSON(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(`{"test:true}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "PostBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "PostBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackend_EmptyBody(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(""))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "PostBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "PostBackend() returned an unexpected error type")
+		assert.StringContains(t, derr.Error(), "the request body is empty", "PostBackend() returned an unexpected error message")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackend_EmptyObject(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(`{}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, _, derr := PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "PostBackend() returned an unexpected error: %v", derr)
+
+		// assert return values
+		assert.Equal(t, status, http.StatusOK, "PostBackend() returned unexpected status code")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackend_SvcNotFoundError(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "PostBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrNotFound, "PostBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackend_SvcBadDataError(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Svc.SaveError = NewErrorf(ErrBadData, "")
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "PostBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "PostBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackend_SvcDBError(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Svc.SaveError = NewErrorf(ErrDB, "")
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test, check for an error
+		_, _, derr := PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "PostBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrDB, "PostBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackend_SvcSyncError(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Svc.SaveError = NewErrorf(ErrSync, "")
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test, check for an error
+		_, _, derr := PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "PostBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrSync, "PostBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackend_SvcOutOfSyncError(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Svc.SaveError = NewErrorf(ErrOutOfSync, "")
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends", strings.NewReader(m.Enc.Encode(b)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test, check for an error
+		_, _, derr := PostBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "PostBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrOutOfSync, "PostBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+// ----------------------------------------------
+// PatchBackend TESTS
+// ----------------------------------------------
+
+// executes PatchBackend with the given patch document against a stored copy of the given backend,
+// then passes the results to check
+func patchBackendTest(b *Backend, patch string, check func(m *backendHandlersMocks, status int, body string, derr *Error)) {
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("PATCH", "/backends/"+b.Name, strings.NewReader(patch))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		status, body, derr := PatchBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		check(m, status, body, derr)
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PatchBackend_Replace(t *testing.T) {
+	b := bData.OneBackend()
+	patch := `[{"op": "replace", "path": "/balance", "value": "leastconn"}, {"op": "replace", "path": "/members/0/port", "value": 9090}]`
+
+	patchBackendTest(b, patch, func(m *backendHandlersMocks, status int, body string, derr *Error) {
+		assert.EnsureNil(t, derr, "PatchBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusOK, "PatchBackend() returned unexpected status code")
+
+		saved, _ := m.Svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, saved, "PatchBackend() failed to save the backend")
+		assert.Equal(t, saved.Balance, "leastconn", "PatchBackend() failed to replace the balance")
+		assert.Equal(t, saved.Members[0].Port, 9090, "PatchBackend() failed to replace the member port")
+		assert.Equal(t, saved.Host, b.Host, "PatchBackend() changed a field that wasn't patched")
+		assert.Equal(t, body, m.Enc.Encode(saved), "PatchBackend() returned unexpected body")
+	})
+}
+
+func Test_PatchBackend_Add(t *testing.T) {
+	b := bData.OneBackend()
+	patch := `[{"op": "add", "path": "/members/-", "value": {"name": "backend/test001/10.180.1.2", "version": "1.2.5", "host": "10.180.1.2", "port": 8080}}]`
+
+	patchBackendTest(b, patch, func(m *backendHandlersMocks, status int, body string, derr *Error) {
+		assert.EnsureNil(t, derr, "PatchBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusOK, "PatchBackend() returned unexpected status code")
+
+		saved, _ := m.Svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, saved, "PatchBackend() failed to save the backend")
+		assert.EnsureEqual(t, len(saved.Members), 2, "PatchBackend() failed to add the member")
+		assert.Equal(t, saved.Members[0].Host, "10.180.1.1", "PatchBackend() changed the existing member")
+		assert.Equal(t, saved.Members[1].Host, "10.180.1.2", "PatchBackend() added an unexpected member")
+	})
+}
+
+func Test_PatchBackend_Remove(t *testing.T) {
+	b := bData.OneBackend()
+	patch := `[{"op": "remove", "path": "/members/0"}]`
+
+	patchBackendTest(b, patch, func(m *backendHandlersMocks, status int, body string, derr *Error) {
+		assert.EnsureNil(t, derr, "PatchBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusOK, "PatchBackend() returned unexpected status code")
+
+		saved, _ := m.Svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, saved, "PatchBackend() failed to save the backend")
+		assert.Equal(t, len(saved.Members), 0, "PatchBackend() failed to remove the member")
+	})
+}
+
+func Test_PatchBackend_InvalidPath(t *testing.T) {
+	b := bData.OneBackend()
+	patch := `[{"op": "replace", "path": "/members/5/port", "value": 9090}]`
+
+	patchBackendTest(b, patch, func(m *backendHandlersMocks, status int, body string, derr *Error) {
+		assert.EnsureNotNil(t, derr, "PatchBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "PatchBackend() returned an unexpected error type")
+
+		saved, _ := m.Svc.GetBackend(b.Name)
+		assert.Equal(t, saved.Members[0].Port, 8080, "PatchBackend() saved the backend despite the error")
+	})
+}
+
+func Test_PatchBackend_NotFound(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = "12345"
+		m.Request, _ = http.NewRequest("PATCH", "/backends/12345", strings.NewReader(`[]`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		_, _, derr := PatchBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "PatchBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrNotFound, "PatchBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+// ----------------------------------------------
+// CloneBackend TESTS
+// ----------------------------------------------
+
+func Test_CloneBackend(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends/"+b.Name+"/clone", strings.NewReader(`{"name":"clone-1.3.0","version":"1.3.0"}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := CloneBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "CloneBackend() returned an unexpected error: %v", derr)
+
+		// assert return values
+		assert.Equal(t, status, http.StatusCreated, "CloneBackend() returned unexpected status code")
+		r := &Backend{}
+		m.Enc.Decode([]byte(body), r)
+		assert.Equal(t, r.Name, "clone-1.3.0", "CloneBackend() returned an unexpected name")
+		assert.Equal(t, r.Version, "1.3.0", "CloneBackend() returned an unexpected version")
+		assert.Equal(t, len(r.Members), len(b.Members), "CloneBackend() failed to copy the members")
+
+		saved, _ := m.Svc.GetBackend("clone-1.3.0")
+		assert.NotNil(t, saved, "CloneBackend() failed to save the clone")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_CloneBackend_Conflict(t *testing.T) {
+	b1 := bData.OneBackend()
+	b2 := bData.OtherBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b1)
+		m.Svc.SaveBackend(b2)
+		m.Params["name"] = b1.Name
+		m.Request, _ = http.NewRequest("POST", "/backends/"+b1.Name+"/clone", strings.NewReader(fmt.Sprintf(`{"name":"%s"}`, b2.Name)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		_, _, derr := CloneBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "CloneBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrConflict, "CloneBackend() returned an unexpected error type")
+		assert.Equal(t, statusOf(derr), http.StatusConflict, "CloneBackend() returned an error with an unexpected status")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_CloneBackend_SourceMissing(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = "missing"
+		m.Request, _ = http.NewRequest("POST", "/backends/missing/clone", strings.NewReader(`{"name":"clone"}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		_, _, derr := CloneBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "CloneBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrNotFound, "CloneBackend() returned an unexpected error type")
+		assert.StringContains(t, derr.Error(), "missing", "CloneBackend() returned an unexpected error message")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_CloneBackend_WithoutName(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends/"+b.Name+"/clone", strings.NewReader(`{"version":"1.3.0"}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		_, _, derr := CloneBackend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "CloneBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "CloneBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+// ----------------------------------------------
+// PostBackendsValidate TESTS
+// ----------------------------------------------
+
+func Test_PostBackendsValidate(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Request, _ = http.NewRequest("POST", "/backends/validate", strings.NewReader(m.Enc.Encode(b)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := PostBackendsValidate(m.Request, m.Enc)
+		assert.EnsureNil(t, derr, "PostBackendsValidate() returned an unexpected error: %v", derr)
+
+		// assert return values
+		assert.Equal(t, status, http.StatusOK, "PostBackendsValidate() returned unexpected status code")
+		assert.Equal(t, body, `{"errors":[]}`, "PostBackendsValidate() returned unexpected body")
+		saved, _ := m.Svc.GetBackend(b.Name)
+		assert.Nil(t, saved, "PostBackendsValidate() saved the backend")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackendsValidate_MultipleErrors(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Request, _ = http.NewRequest("POST", "/backends/validate", strings.NewReader(`{"reloadStrategy":"bogus","defaultPort":-1}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := PostBackendsValidate(m.Request, m.Enc)
+		assert.EnsureNil(t, derr, "PostBackendsValidate() returned an unexpected error: %v", derr)
+
+		// assert return values
+		assert.Equal(t, status, http.StatusBadRequest, "PostBackendsValidate() returned unexpected status code")
+		res := struct {
+			Errors []FieldError `json:"errors"`
+		}{}
+		m.Enc.Decode([]byte(body), &res)
+		fields := []string{}
+		for _, e := range res.Errors {
+			fields = append(fields, e.Field)
+		}
+		assert.Equal(t, fields, []string{"name", "reloadStrategy", "defaultPort"}, "PostBackendsValidate() returned unexpected field errors")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackendsValidate_WithInvalidJSON(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Request, _ = http.NewRequest("POST", "/backends/validate", strings.NewReader(`{"test:true}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := PostBackendsValidate(m.Request, m.Enc)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "PostBackendsValidate() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "PostBackendsValidate() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+// ----------------------------------------------
+// DeleteBackend TESTS
+// ----------------------------------------------
+
+func Test_DeleteBackend(t *testing.T) {
+	b := bData.OneBackend()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := DeleteBackend(m.Request, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "DeleteBackend() returned an unexpected error: %v", derr)
+
+		// assert return values
+		expCode := http.StatusNoContent
+		assert.Equal(t, status, expCode, "DeleteBackend() returned unexpected status code")
+		assert.Empty(t, body, "DeleteBackend() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_DeleteBackend_Cascade(t *testing.T) {
+	b := bData.OneBackend()
+	f := FrontendTestData{}.OneFrontend()
+	f.DefaultBackend = b.Name
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Svc.SaveFrontend(f)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("DELETE", "/backends/"+b.Name+"?cascade=true", nil)
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, _, derr := DeleteBackend(m.Request, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "DeleteBackend() returned an unexpected error: %v", derr)
+
+		// assert return values
+		assert.Equal(t, status, http.StatusNoContent, "DeleteBackend() returned unexpected status code")
+		assert.Equal(t, m.Svc.Frontends[0].DefaultBackend, "", "DeleteBackend() did not cascade to the referencing frontend")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_DeleteBackend_InvalidCascade(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = "12345"
+		m.Request, _ = http.NewRequest("DELETE", "/backends/12345?cascade=maybe", nil)
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := DeleteBackend(m.Request, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "DeleteBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "DeleteBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_DeleteBackend_SvcNotFoundError(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.DeleteError = NewErrorf(ErrNotFound, "")
+		m.Params["name"] = "12345"
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := DeleteBackend(m.Request, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "DeleteBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrNotFound, "DeleteBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_DeleteBackend_SvcDBError(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.DeleteError = NewErrorf(ErrDB, "")
+		m.Params["name"] = "12345"
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test, check for an error
+		_, _, derr := DeleteBackend(m.Request, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "DeleteBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrDB, "DeleteBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_DeleteBackend_SvcSyncError(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.DeleteError = NewErrorf(ErrSync, "")
+		m.Params["name"] = "12345"
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test, check for an error
+		_, _, derr := DeleteBackend(m.Request, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "DeleteBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrSync, "DeleteBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_DeleteBackend_SvcOutOfSyncError(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.DeleteError = NewErrorf(ErrOutOfSync, "")
+		m.Params["name"] = "12345"
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test, check for an error
+		_, _, derr := DeleteBackend(m.Request, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "DeleteBackend() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrOutOfSync, "DeleteBackend() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+// ----------------------------------------------
+// GetBackendConfig TESTS
+// ----------------------------------------------
+
+func Test_GetBackendConfig(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+	h := testHelpers.NewHAProxyMock()
+	h.template = template.Must(template.New("test").Parse(defaultTemplate))
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Svc.SaveBackend(bData.OtherBackend())
+		m.Svc.SaveFrontend(fData.OneFrontend())
+		m.Params["name"] = b.Name
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := GetBackendConfig(m.ResWriter, m.Svc, h, m.Params)
+		assert.EnsureNil(t, derr, "GetBackendConfig() returned an unexpected error: %v", derr)
+
+		// assert return values
+		expBody := `  backend test002-1.2.5
+    mode http
+    balance roundrobin
+    server backend/test002/10.180.2.1 10.180.2.1:8080 check inter 2000
+    server backend/test002/10.180.2.2 10.180.2.2:8080 check inter 2000
+`
+		assert.Equal(t, status, http.StatusOK, "GetBackendConfig() returned unexpected status code")
+		assert.Equal(t, m.ResWriter.Header().Get("Content-Type"), "text/plain", "GetBackendConfig() response has unexpected content type")
+		assert.Equal(t, body, expBody, "GetBackendConfig() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetBackendConfig_DoesNotExist(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = "12345"
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := GetBackendConfig(m.ResWriter, m.Svc, testHelpers.NewHAProxyMock(), m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "GetBackendConfig() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrNotFound, "GetBackendConfig() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetBackendConfig_SvcError(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.GetError = NewErrorf(ErrUnknown, "")
+		m.Params["name"] = "12345"
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test, check for an error
+		_, _, derr := GetBackendConfig(m.ResWriter, m.Svc, testHelpers.NewHAProxyMock(), m.Params)
+		assert.EnsureNotNil(t, derr, "GetBackendConfig() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrUnknown, "GetBackendConfig() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+// ----------------------------------------------
+// GetBackendMembers TESTS
+// ----------------------------------------------
+
+func Test_GetBackendMembers(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := GetBackendMembers(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "GetBackendMembers() returned an unexpected error: %v", derr)
+
+		// assert return values
+		expCode := http.StatusOK
+		expBody := m.Enc.Encode(b.Members)
+		assert.Equal(t, expCode, status, "GetBackendMembers() returned unexpected status code")
+		assert.Equal(t, expBody, body, "GetBackendMembers() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetBackendMembers_Empty(t *testing.T) {
+	b := bData.OneBackend()
+	b.Members = nil
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := GetBackendMembers(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "GetBackendMembers() returned an unexpected error: %v", derr)
+
+		// assert return values
+		assert.Equal(t, status, http.StatusOK, "GetBackendMembers() returned unexpected status code")
+		assert.Equal(t, body, "[]", "GetBackendMembers() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetBackendMembers_DoesNotExist(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = "12345"
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := GetBackendMembers(m.Request, m.Enc, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "GetBackendMembers() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrNotFound, "GetBackendMembers() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetBackendMembers_SvcError(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.GetError = NewErrorf(ErrUnknown, "")
+		m.Params["name"] = "12345"
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test, check for an error
+		_, _, derr := GetBackendMembers(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNotNil(t, derr, "GetBackendMembers() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrUnknown, "GetBackendMembers() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetBackendMembers_SortByName(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+	b.Members[0], b.Members[1] = b.Members[1], b.Members[0]
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("GET", "/backends/"+b.Name+"/members?sort=name", nil)
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := GetBackendMembers(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "GetBackendMembers() returned an unexpected error: %v", derr)
+
+		// assert return values
+		expBody := m.Enc.Encode(BackendMembers{b.Members[1], b.Members[0]})
+		assert.Equal(t, status, http.StatusOK, "GetBackendMembers() returned unexpected status code")
+		assert.Equal(t, body, expBody, "GetBackendMembers() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetBackendMembers_MemberFormatMap(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("GET", "/backends/"+b.Name+"/members?memberFormat=map", nil)
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := GetBackendMembers(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "GetBackendMembers() returned an unexpected error: %v", derr)
+
+		// assert return values
+		expBody := m.Enc.Encode(map[string]BackendMember{
+			b.Members[0].Name: b.Members[0],
+			b.Members[1].Name: b.Members[1],
+		})
+		assert.Equal(t, status, http.StatusOK, "GetBackendMembers() returned unexpected status code")
+		assert.Equal(t, body, expBody, "GetBackendMembers() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetBackendMembers_InvalidSort(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("GET", "/backends/"+b.Name+"/members?sort=port", nil)
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := GetBackendMembers(m.Request, m.Enc, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "GetBackendMembers() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "GetBackendMembers() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+// ----------------------------------------------
+// loadBackendFromRequest TESTS
+// ----------------------------------------------
 
 func Test_loadBackendFromRequest(t *testing.T) {
 	b1 := bData.OneBackend()
@@ -721,6 +1874,400 @@ func Test_loadBackendFromRequest_BadData(t *testing.T) {
 
 	// assert return values
 	assert.EnsureNotNil(t, err, "loadBackendFromRequest() failed to return an expected error")
-	assert.Equal(t, err.Code, http.StatusBadRequest, "loadBackendFromRequest() returned unexpected status code in error")
-	assert.NotEmpty(t, err.Message, "loadBackendFromRequest() returned empty error message")
+	assert.Equal(t, err.Type, ErrBadData, "loadBackendFromRequest() returned unexpected error type")
+	assert.NotEmpty(t, err.Error(), "loadBackendFromRequest() returned empty error message")
+}
+
+func Test_loadBackendFromRequest_FractionalPort(t *testing.T) {
+	enc := JSONEncoder{}
+	body := `{"name":"test","members":[{"name":"m1","host":"10.0.0.1","port":8080.5}]}`
+	r, _ := http.NewRequest("POST", "/backends", strings.NewReader(body))
+
+	// execute function to test
+	b := &Backend{}
+	err := loadBackendFromRequest(r, enc, b)
+
+	// assert return values
+	assert.EnsureNotNil(t, err, "loadBackendFromRequest() failed to return an expected error")
+	assert.Equal(t, err.Type, ErrBadData, "loadBackendFromRequest() returned unexpected error type")
+	assert.StringContains(t, err.Error(), "the port '8080.5' of member 'm1' is invalid", "loadBackendFromRequest() returned unexpected error message")
+}
+
+// failingReader is a request body whose reads always fail.
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func Test_loadBackendFromRequest_ReadError(t *testing.T) {
+	enc := JSONEncoder{}
+	r, _ := http.NewRequest("POST", "/backends", failingReader{})
+
+	// execute function to test
+	b := &Backend{}
+	err := loadBackendFromRequest(r, enc, b)
+
+	// assert return values
+	assert.EnsureNotNil(t, err, "loadBackendFromRequest() failed to return an expected error")
+	assert.Equal(t, statusOf(err), http.StatusInternalServerError, "loadBackendFromRequest() returned unexpected status for error")
+	assert.StringContains(t, err.Error(), "connection reset by peer", "loadBackendFromRequest() returned unexpected error message")
+}
+
+// ----------------------------------------------
+// SwapBackendMembers TESTS
+// ----------------------------------------------
+
+func Test_SwapBackendMembers(t *testing.T) {
+	b := bData.OneBackend()
+	members := bData.OtherBackend().Members
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Request, _ = http.NewRequest("POST", "/backends/"+b.Name+"/members/swap", strings.NewReader(m.Enc.Encode(members)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := SwapBackendMembers(m.Request, m.Enc, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "SwapBackendMembers() returned an unexpected error: %v", derr)
+
+		// assert return values
+		expected := *b
+		expected.Members = members
+		assert.Equal(t, status, http.StatusOK, "SwapBackendMembers() returned unexpected status code")
+		assert.Equal(t, body, m.Enc.Encode(expected), "SwapBackendMembers() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_SwapBackendMembers_DoesNotExist(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = "12345"
+		m.Request, _ = http.NewRequest("POST", "/backends/12345/members/swap", strings.NewReader("[]"))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := SwapBackendMembers(m.Request, m.Enc, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "SwapBackendMembers() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrNotFound, "SwapBackendMembers() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_SwapBackendMembers_InvalidBody(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = "12345"
+		m.Request, _ = http.NewRequest("POST", "/backends/12345/members/swap", strings.NewReader("{"))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := SwapBackendMembers(m.Request, m.Enc, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "SwapBackendMembers() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "SwapBackendMembers() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+// ----------------------------------------------
+// PostBackendMembersVersion TESTS
+// ----------------------------------------------
+
+func Test_PostBackendMembersVersion(t *testing.T) {
+	for _, backendVersion := range []bool{false, true} {
+		b := bData.OneBackendMultiMembers()
+		origVersion := b.Version
+
+		setup := func(m *backendHandlersMocks) {
+			m.Svc.SaveBackend(b)
+			m.Params["name"] = b.Name
+			body := fmt.Sprintf(`{"version":"1.3.0","backendVersion":%t}`, backendVersion)
+			m.Request, _ = http.NewRequest("POST", "/backends/"+b.Name+"/members/version", strings.NewReader(body))
+		}
+
+		testAction := func(m *backendHandlersMocks) {
+			// execute function to test
+			status, body, derr := PostBackendMembersVersion(m.Request, m.Enc, m.Svc, m.Params)
+			assert.EnsureNil(t, derr, "PostBackendMembersVersion() returned an unexpected error: %v", derr)
+
+			// assert return values
+			assert.EnsureEqual(t, status, http.StatusOK, "PostBackendMembersVersion() returned unexpected status code")
+			r := &Backend{}
+			m.Enc.Decode([]byte(body), r)
+			for _, mem := range r.Members {
+				assert.Equal(t, mem.Version, "1.3.0", "PostBackendMembersVersion() failed to set the version of member %s", mem.Name)
+			}
+			expVersion := origVersion
+			if backendVersion {
+				expVersion = "1.3.0"
+			}
+			assert.Equal(t, r.Version, expVersion, "PostBackendMembersVersion() set an unexpected backend version")
+		}
+
+		backendHandlersTestCase{
+			Setup:    setup,
+			Action:   testAction,
+			Teardown: nil,
+		}.execute()
+	}
+}
+
+func Test_PostBackendMembersVersion_DoesNotExist(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = "12345"
+		m.Request, _ = http.NewRequest("POST", "/backends/12345/members/version", strings.NewReader(`{"version":"1.3.0"}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := PostBackendMembersVersion(m.Request, m.Enc, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "PostBackendMembersVersion() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrNotFound, "PostBackendMembersVersion() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostBackendMembersVersion_InvalidBody(t *testing.T) {
+	for _, body := range []string{"{", `{"version":""}`} {
+		setup := func(m *backendHandlersMocks) {
+			m.Params["name"] = "12345"
+			m.Request, _ = http.NewRequest("POST", "/backends/12345/members/version", strings.NewReader(body))
+		}
+
+		testAction := func(m *backendHandlersMocks) {
+			// execute function to test
+			_, _, derr := PostBackendMembersVersion(m.Request, m.Enc, m.Svc, m.Params)
+
+			// assert return values
+			assert.EnsureNotNil(t, derr, "PostBackendMembersVersion() failed to return an error when expected")
+			assert.Equal(t, derr.Type, ErrBadData, "PostBackendMembersVersion() returned an unexpected error type")
+		}
+
+		backendHandlersTestCase{
+			Setup:    setup,
+			Action:   testAction,
+			Teardown: nil,
+		}.execute()
+	}
+}
+
+func Test_PostMembersHeartbeat(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+	beats := []MemberHeartbeat{
+		{Backend: b.Name, Member: b.Members[0].Name},
+		{Backend: b.Name, Member: b.Members[1].Name},
+		{Backend: b.Name, Member: "missing-member"},
+		{Backend: "missing-backend", Member: b.Members[0].Name},
+	}
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Request, _ = http.NewRequest("POST", "/members/heartbeat", strings.NewReader(m.Enc.Encode(beats)))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := PostMembersHeartbeat(m.Request, m.Enc, m.Svc)
+		assert.EnsureNil(t, derr, "PostMembersHeartbeat() returned an unexpected error: %v", derr)
+
+		// assert return values
+		expBody := m.Enc.Encode(&heartbeatResponse{Updated: 2, NotFound: beats[2:]})
+		assert.Equal(t, status, http.StatusOK, "PostMembersHeartbeat() returned unexpected status code")
+		assert.Equal(t, body, expBody, "PostMembersHeartbeat() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostMembersHeartbeat_InvalidData(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Request, _ = http.NewRequest("POST", "/members/heartbeat", strings.NewReader(`{"backend":"x"}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := PostMembersHeartbeat(m.Request, m.Enc, m.Svc)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "PostMembersHeartbeat() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "PostMembersHeartbeat() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetBackendMemberMeta(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+	b.Members[1].Meta = map[string]string{"az": "us-east-1a", "instanceId": "i-1234"}
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Params["memberName"] = b.Members[1].Name
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := GetBackendMemberMeta(m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "GetBackendMemberMeta() returned an unexpected error: %v", derr)
+
+		// assert return values
+		expBody := `{"az":"us-east-1a","instanceId":"i-1234"}`
+		assert.Equal(t, status, http.StatusOK, "GetBackendMemberMeta() returned unexpected status code")
+		assert.Equal(t, body, expBody, "GetBackendMemberMeta() returned unexpected body")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetBackendMemberMeta_DoesNotExist(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+
+	testCases := []Params{
+		{"name": b.Name, "memberName": "missing-member"},
+		{"name": "missing-backend", "memberName": b.Members[0].Name},
+	}
+
+	for _, params := range testCases {
+		setup := func(m *backendHandlersMocks) {
+			m.Svc.SaveBackend(b)
+			m.Params = params
+		}
+
+		testAction := func(m *backendHandlersMocks) {
+			// execute function to test
+			_, _, derr := GetBackendMemberMeta(m.Svc, m.Params)
+
+			// assert return values
+			assert.EnsureNotNil(t, derr, "GetBackendMemberMeta() failed to return an error when expected")
+			assert.Equal(t, derr.Type, ErrNotFound, "GetBackendMemberMeta() returned an unexpected error type for %v", params)
+		}
+
+		backendHandlersTestCase{
+			Setup:    setup,
+			Action:   testAction,
+			Teardown: nil,
+		}.execute()
+	}
+}
+
+func Test_PutBackendMemberMeta(t *testing.T) {
+	b := bData.OneBackendMultiMembers()
+	b.Members[0].Meta = map[string]string{"az": "us-east-1a", "rack": "r1"}
+	meta := map[string]string{"az": "us-east-1b"}
+
+	setup := func(m *backendHandlersMocks) {
+		m.Svc.SaveBackend(b)
+		m.Params["name"] = b.Name
+		m.Params["memberName"] = b.Members[0].Name
+		m.Request, _ = http.NewRequest("PUT", "/backends/"+b.Name+"/members/"+b.Members[0].Name+"/meta", strings.NewReader(`{"az":"us-east-1b"}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		status, body, derr := PutBackendMemberMeta(m.Request, m.Svc, m.Params)
+		assert.EnsureNil(t, derr, "PutBackendMemberMeta() returned an unexpected error: %v", derr)
+
+		// assert return values
+		assert.Equal(t, status, http.StatusOK, "PutBackendMemberMeta() returned unexpected status code")
+		assert.Equal(t, body, `{"az":"us-east-1b"}`, "PutBackendMemberMeta() returned unexpected body")
+
+		// assert the metadata was replaced, not merged
+		r, _ := m.Svc.GetBackend(b.Name)
+		assert.Equal(t, r.Members[0].Meta, meta, "PutBackendMemberMeta() failed to replace the member metadata")
+		assert.Nil(t, r.Members[1].Meta, "PutBackendMemberMeta() changed the metadata of another member")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PutBackendMemberMeta_DoesNotExist(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = "12345"
+		m.Params["memberName"] = "node1"
+		m.Request, _ = http.NewRequest("PUT", "/backends/12345/members/node1/meta", strings.NewReader(`{"az":"us-east-1b"}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := PutBackendMemberMeta(m.Request, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "PutBackendMemberMeta() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrNotFound, "PutBackendMemberMeta() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PutBackendMemberMeta_InvalidData(t *testing.T) {
+	setup := func(m *backendHandlersMocks) {
+		m.Params["name"] = "12345"
+		m.Params["memberName"] = "node1"
+		m.Request, _ = http.NewRequest("PUT", "/backends/12345/members/node1/meta", strings.NewReader(`{"az":1}`))
+	}
+
+	testAction := func(m *backendHandlersMocks) {
+		// execute function to test
+		_, _, derr := PutBackendMemberMeta(m.Request, m.Svc, m.Params)
+
+		// assert return values
+		assert.EnsureNotNil(t, derr, "PutBackendMemberMeta() failed to return an error when expected")
+		assert.Equal(t, derr.Type, ErrBadData, "PutBackendMemberMeta() returned an unexpected error type")
+	}
+
+	backendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
 }
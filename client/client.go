@@ -0,0 +1,269 @@
+// Package client is a Go client for the Conduit REST API.
+//
+// The types of this package mirror the JSON representations served by Conduit with the default
+// camelCase json-field-style, and errors returned by the API are mapped back to the ErrorType that
+// caused them.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrorType represents the type of an error returned by the API.
+type ErrorType int
+
+// The error types, in the same order as those of the server.
+const (
+	// ErrConflict indicates that a record to create already exists.
+	ErrConflict ErrorType = iota
+	// ErrNotFound indicates that a record does not exist.
+	ErrNotFound
+	// ErrBadData indicates that the data to save is incomplete or invalid.
+	ErrBadData
+	// ErrSync indicates that a problem occurred when syncing the haproxy config file.
+	ErrSync
+	// ErrOutOfSync indicates that the haproxy config file is out of sync with the database.
+	ErrOutOfSync
+	// ErrDB indicates that a problem occurred reading or writing to the database.
+	ErrDB
+	// ErrDrift indicates that the haproxy config file has been changed outside of Conduit.
+	ErrDrift
+	// ErrUnknown indicates that an unknown error has occurred.
+	ErrUnknown
+)
+
+// String returns the string representation of an ErrorType.
+func (t ErrorType) String() string {
+	switch t {
+	case ErrConflict:
+		return "ErrConflict"
+	case ErrNotFound:
+		return "ErrNotFound"
+	case ErrBadData:
+		return "ErrBadData"
+	case ErrSync:
+		return "ErrSync"
+	case ErrOutOfSync:
+		return "ErrOutOfSync"
+	case ErrDB:
+		return "ErrDB"
+	case ErrDrift:
+		return "ErrDrift"
+	case ErrUnknown:
+		return "ErrUnknown"
+	}
+	return ""
+}
+
+// Error is an error response of the API.
+type Error struct {
+	Type       ErrorType
+	StatusCode int
+	Message    string
+}
+
+// Error returns the message of the error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("[%d] %s", e.StatusCode, e.Message)
+}
+
+// returns the type of error that causes the API to respond with the given status code
+func errorTypeOf(code int) ErrorType {
+	switch code {
+	case http.StatusBadRequest:
+		return ErrBadData
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	}
+	return ErrUnknown
+}
+
+// Frontend is an HAProxy frontend.
+type Frontend struct {
+	Name           string            `json:"name"`
+	Bind           string            `json:"bind"`
+	DefaultBackend string            `json:"defaultBackend"`
+	Mode           string            `json:"mode"`
+	KeepAlive      string            `json:"keepalive"`
+	Option         string            `json:"option"`
+	MaxConn        int               `json:"maxconn"`
+	Rules          []string          `json:"rules"`
+	RoutingRules   []Rule            `json:"routingRules"`
+	ExpiresAt      time.Time         `json:"expiresAt"`
+	ModifiedAt     time.Time         `json:"modifiedAt"`
+	Meta           map[string]string `json:"meta"`
+}
+
+// Rule routes the requests of a frontend that match an HAProxy ACL condition to a backend.
+type Rule struct {
+	Condition string `json:"condition"`
+	Backend   string `json:"backend"`
+}
+
+// Backend is an HAProxy backend.
+type Backend struct {
+	Name           string            `json:"name"`
+	Version        string            `json:"version"`
+	Balance        string            `json:"balance"`
+	Host           string            `json:"host"`
+	Mode           string            `json:"mode"`
+	Resolvers      string            `json:"resolvers"`
+	HealthCheck    string            `json:"healthCheck"`
+	ReloadStrategy string            `json:"reloadStrategy"`
+	DefaultPort    int               `json:"defaultPort"`
+	ExpiresAt      time.Time         `json:"expiresAt"`
+	ModifiedAt     time.Time         `json:"modifiedAt"`
+	Members        []BackendMember   `json:"members"`
+	Meta           map[string]string `json:"meta"`
+}
+
+// BackendMember is an individual member node of a backend.
+type BackendMember struct {
+	Name      string            `json:"name"`
+	Version   string            `json:"version"`
+	Host      string            `json:"host"`
+	Port      int               `json:"port"`
+	LastKnown time.Time         `json:"lastKnown"`
+	Disabled  bool              `json:"disabled"`
+	Meta      map[string]string `json:"meta"`
+}
+
+// Client is a client of the Conduit REST API.
+type Client struct {
+	// URL is the base URL of the API, e.g. "http://localhost:8080".
+	URL string
+	// HTTPClient is the client used to send requests; if nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// New returns a new Client of the API at the given base URL.
+func New(baseURL string) *Client {
+	return &Client{URL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// GetFrontends returns all of the frontends.
+func (c *Client) GetFrontends() ([]*Frontend, error) {
+	f := []*Frontend{}
+	if err := c.do("GET", "/frontends", nil, &f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetFrontend returns the frontend with the given name; an *Error of type ErrNotFound is returned
+// if it doesn't exist.
+func (c *Client) GetFrontend(name string) (*Frontend, error) {
+	f := &Frontend{}
+	if err := c.do("GET", "/frontends/"+url.PathEscape(name), nil, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SaveFrontend creates or replaces the given frontend and returns it as saved.
+func (c *Client) SaveFrontend(f *Frontend) (*Frontend, error) {
+	saved := &Frontend{}
+	if err := c.do("PUT", "/frontends/"+url.PathEscape(f.Name), f, saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// DeleteFrontend deletes the frontend with the given name.
+func (c *Client) DeleteFrontend(name string) error {
+	return c.do("DELETE", "/frontends/"+url.PathEscape(name), nil, nil)
+}
+
+// GetBackends returns all of the backends.
+func (c *Client) GetBackends() ([]*Backend, error) {
+	b := []*Backend{}
+	if err := c.do("GET", "/backends", nil, &b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetBackend returns the backend with the given name; an *Error of type ErrNotFound is returned if
+// it doesn't exist.
+func (c *Client) GetBackend(name string) (*Backend, error) {
+	b := &Backend{}
+	if err := c.do("GET", "/backends/"+url.PathEscape(name), nil, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// SaveBackend creates or replaces the given backend and returns it as saved.
+func (c *Client) SaveBackend(b *Backend) (*Backend, error) {
+	saved := &Backend{}
+	if err := c.do("PUT", "/backends/"+url.PathEscape(b.Name), b, saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// DeleteBackend deletes the backend with the given name.
+func (c *Client) DeleteBackend(name string) error {
+	return c.do("DELETE", "/backends/"+url.PathEscape(name), nil, nil)
+}
+
+// sends a request with the given body encoded as JSON, and decodes the response into out; a
+// response with an error status is returned as an *Error
+func (c *Client) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.URL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 400 {
+		e := &Error{Type: errorTypeOf(res.StatusCode), StatusCode: res.StatusCode}
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &msg) == nil && msg.Message != "" {
+			e.Message = msg.Message
+		} else {
+			e.Message = http.StatusText(res.StatusCode)
+		}
+		return e
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PearsonEducation/Thalassa-Conduit/client"
+)
+
+// starts a server of the full API backed by a mock datastore and returns a client of it
+func newTestClient() (*client.Client, func()) {
+	config := &Config{}
+	queue := NewReloadQueue(testHelpers.NewHAProxyMock(), 0)
+	svc := NewDataSvc(testHelpers.NewDatastoreMock(), queue, config)
+	r := initRouter(nil, config, testHelpers.NewDBManagerMock(), svc, queue)
+	ts := httptest.NewServer(initNegroni(config, r))
+	return client.New(ts.URL), func() {
+		ts.Close()
+		queue.Close()
+	}
+}
+
+// asserts that the given error is a client error of the given type and status code
+func assertClientError(t *testing.T, err error, errType client.ErrorType, code int, name string) {
+	cerr, ok := err.(*client.Error)
+	if !assert.True(t, ok, "%s returned an unexpected error: %v", name, err) {
+		return
+	}
+	assert.Equal(t, cerr.Type, errType, "%s returned an unexpected error type: '%v'", name, cerr.Type)
+	assert.Equal(t, cerr.StatusCode, code, "%s returned an unexpected status code", name)
+}
+
+// Tests that the client saves, gets, and deletes backends.
+func Test_Client_Backends(t *testing.T) {
+	c, stop := newTestClient()
+	defer stop()
+
+	b := &client.Backend{
+		Name:    "test-app",
+		Balance: "roundrobin",
+		Members: []client.BackendMember{{Name: "m1", Host: "10.0.0.1", Port: 8080}},
+	}
+	saved, err := c.SaveBackend(b)
+	assert.EnsureNil(t, err, "Client.SaveBackend() returned an unexpected error: %v", err)
+	assert.Equal(t, saved.Name, b.Name, "Client.SaveBackend() returned an unexpected backend")
+
+	r, err := c.GetBackend(b.Name)
+	assert.EnsureNil(t, err, "Client.GetBackend() returned an unexpected error: %v", err)
+	assert.Equal(t, r.Balance, b.Balance, "Client.GetBackend() returned an unexpected backend")
+	assert.EnsureEqual(t, len(r.Members), 1, "Client.GetBackend() returned unexpected members")
+	assert.Equal(t, r.Members[0].Port, 8080, "Client.GetBackend() returned an unexpected member")
+
+	all, err := c.GetBackends()
+	assert.EnsureNil(t, err, "Client.GetBackends() returned an unexpected error: %v", err)
+	assert.Equal(t, len(all), 1, "Client.GetBackends() returned an unexpected number of backends")
+
+	err = c.DeleteBackend(b.Name)
+	assert.EnsureNil(t, err, "Client.DeleteBackend() returned an unexpected error: %v", err)
+	_, err = c.GetBackend(b.Name)
+	assertClientError(t, err, client.ErrNotFound, http.StatusNotFound, "Client.GetBackend()")
+}
+
+// Tests that the client saves, gets, and deletes frontends.
+func Test_Client_Frontends(t *testing.T) {
+	c, stop := newTestClient()
+	defer stop()
+
+	f := &client.Frontend{Name: "test-fe", Bind: "*:80", Rules: []string{}}
+	saved, err := c.SaveFrontend(f)
+	assert.EnsureNil(t, err, "Client.SaveFrontend() returned an unexpected error: %v", err)
+	assert.Equal(t, saved.Name, f.Name, "Client.SaveFrontend() returned an unexpected frontend")
+
+	r, err := c.GetFrontend(f.Name)
+	assert.EnsureNil(t, err, "Client.GetFrontend() returned an unexpected error: %v", err)
+	assert.Equal(t, r.Bind, f.Bind, "Client.GetFrontend() returned an unexpected frontend")
+
+	all, err := c.GetFrontends()
+	assert.EnsureNil(t, err, "Client.GetFrontends() returned an unexpected error: %v", err)
+	assert.Equal(t, len(all), 1, "Client.GetFrontends() returned an unexpected number of frontends")
+
+	err = c.DeleteFrontend(f.Name)
+	assert.EnsureNil(t, err, "Client.DeleteFrontend() returned an unexpected error: %v", err)
+	err = c.DeleteFrontend(f.Name)
+	assertClientError(t, err, client.ErrNotFound, http.StatusNotFound, "Client.DeleteFrontend()")
+}
+
+// Tests that the client maps a rejected save to an ErrBadData error with the server's message.
+func Test_Client_BadData(t *testing.T) {
+	c, stop := newTestClient()
+	defer stop()
+
+	b := &client.Backend{
+		Name: "test-app",
+		Members: []client.BackendMember{
+			{Name: "m1", Host: "10.0.0.1", Port: 8080},
+			{Name: "m2", Host: "10.0.0.1", Port: 8080},
+		},
+	}
+	_, err := c.SaveBackend(b)
+	assertClientError(t, err, client.ErrBadData, http.StatusBadRequest, "Client.SaveBackend()")
+	assert.StringContains(t, err.Error(), "same address", "Client.SaveBackend() returned an unexpected error message")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -9,6 +10,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path"
+	"strings"
 	"syscall"
 	"text/template"
 	"time"
@@ -37,10 +40,92 @@ Options:
    -hareload=cmd       shell command to reload HAProxy config
    -f=path             path to a config file, overwrites CLI flags
    -db-path=path       path to location of database files
+   -reload-strategy=s  how to reload HAProxy: command (default) or socket
+   -hasocket=path      path to the HAProxy master/admin socket
+   -hastats-socket=path
+                       path to the HAProxy stats socket, if not hasocket
+   -key-namespace=ns   namespace for database keys, for sharing a database
+   -disabled-endpoints=patterns
+                       comma-separated endpoint path patterns to disable
+   -preserve-unknown-fields
+                       store unrecognized JSON fields in the meta map
+   -get-cache-max-age=seconds
+                       max-age of the Cache-Control header on GET responses
+   -sync-on-startup    write the HAProxy config from the database on startup
+   -reload-queue-size=n
+                       maximum number of pending HAProxy reloads
+   -validate-reload-command
+                       verify on startup that the hareload command exists
+   -drain-wait=duration
+                       time old members drain when swapping backend members
+   -json-field-style=s JSON key style: camelCase (default) or snake_case
+   -default-mode=mode  mode of frontends and backends saved without one
+   -config-backup-count=n
+                       number of HAProxy config file backups to keep
+   -strict-sync        roll back changes when the HAProxy reload fails
+   -shutdown-timeout=duration
+                       time to wait for HAProxy syncs in progress when stopping
+   -block-writes-on-drift
+                       refuse changes while the HAProxy config has drifted
+   -normalize-names=false
+                       reject names containing spaces instead of rewriting them
+   -route-prefix=path  base path under which all endpoints are served
+   -allowed-options=options
+                       comma-separated frontend option values that may be set
+   -config-header      start the HAProxy config file with a generated-by comment
+   -expiry-sweep-interval=duration
+                       how often to delete expired frontends and backends
+   -post-reload-command=cmd
+                       command to execute after each successful HAProxy reload
+   -unique-names-across-types
+                       reject a frontend and backend sharing a name
+   -max-name-length=n  maximum length of frontend and backend names
+   -validate-db-path   verify on startup that the db-path directory is writable
+   -init-empty-config  create the HAProxy config file on startup if it's missing
+   -dedupe-reloads     skip reloads that wouldn't change the HAProxy config
+   -trust-proxy-headers
+                       log the client IP from the X-Forwarded-For header
+   -reconcile-on-out-of-sync
+                       rewrite the HAProxy config when a rollback fails
+   -verify-config      verify the HAProxy config file after each reload
+   -hareload-argv=cmd,arg,...
+                       command to reload HAProxy, executed without a shell
+   -backend-soft-limit=n
+                       number of backends above which saves are warned about
+   -warn-single-member-backends
+                       warn when a backend is saved with a single member
 
 `
 )
 
+// version is the Conduit version reported by the index endpoint; it is set at build time with
+// -ldflags "-X main.version=<version>".
+var version = "dev"
+
+// the top-level endpoints listed by the index endpoint
+var indexEndpoints = []string{
+	"/status",
+	"/healthz",
+	"/readyz",
+	"/frontends",
+	"/backends",
+	"/all",
+	"/members/heartbeat",
+	"/haproxy/config",
+	"/haproxy/config/download",
+	"/haproxy/diff",
+	"/haproxy/health",
+	"/haproxy/queue",
+	"/haproxy/reload",
+	"/haproxy/stats",
+	"/haproxy/template",
+	"/schema/backend",
+	"/schema/frontend",
+	"/restart",
+	"/admin/compact",
+	"/admin/db/recover",
+}
+
 // Server represents an http server.
 type Server interface {
 	Run(config *Config, dbMgr DBManager, tmpl *template.Template)
@@ -52,6 +137,7 @@ type Server interface {
 type serverImpl struct {
 	stopChan   chan int
 	signalChan chan os.Signal
+	done       chan struct{}
 	shutdown   bool
 	timeout    time.Duration
 }
@@ -64,6 +150,7 @@ func NewServer(timeout time.Duration, signalChan chan os.Signal) Server {
 	return &serverImpl{
 		stopChan:   make(chan int, 1),
 		signalChan: signalChan,
+		done:       make(chan struct{}),
 		shutdown:   false,
 		timeout:    timeout,
 	}
@@ -112,15 +199,28 @@ func start(signalChan chan os.Signal) (bool, int) {
 	defer dbManager.Close()
 
 	// load haproxy config template
-	t, err := ioutil.ReadFile(config.HATemplatePath)
+	template, err := loadTemplate(config, dbManager.NewDatastore())
 	if err != nil {
 		os.Stderr.WriteString(fmt.Sprintf("error loading HAProxy config template file: %s", err.Error()))
 		return true, 1
 	}
-	template, _ := template.New("test").Parse(string(t))
+
+	// create the HAProxy config file, if it doesn't exist yet
+	if config.InitEmptyConfig {
+		initEmptyConfig(config, template)
+	}
+
+	// rebuild the HAProxy config file from the stored frontends and backends
+	if config.SyncOnStartup {
+		syncOnStartup(config, dbManager, template)
+	}
 
 	// start the web server in a new goroutine
-	d, _ := time.ParseDuration(defaultTimeout)
+	timeout := config.ShutdownTimeout
+	if timeout == "" {
+		timeout = defaultTimeout
+	}
+	d, _ := time.ParseDuration(timeout)
 	server := NewServer(d, signalChan)
 	go server.Run(config, dbManager, template)
 
@@ -128,6 +228,84 @@ func start(signalChan chan os.Signal) (bool, int) {
 	return waitForSignal(signalChan, server)
 }
 
+// loads the HAProxy config template, preferring a template stored in the datastore over the
+// template file so that a template set through the API survives a restart; a template that can't
+// be parsed is logged and replaced with the default template
+func loadTemplate(config *Config, db Datastore) (*template.Template, error) {
+	text, derr := db.GetTemplate()
+	if derr != nil {
+		return nil, derr
+	}
+	source := "stored"
+	if text == "" {
+		t, err := ioutil.ReadFile(config.HATemplatePath)
+		if err != nil {
+			return nil, err
+		}
+		text = string(t)
+		source = config.HATemplatePath
+	}
+	tmpl, err := template.New("test").Parse(text)
+	if err != nil {
+		log.Printf("[WARN] The %s HAProxy config template is invalid - using the default template: %v", source, err)
+		return template.New("test").Parse(defaultTemplate)
+	}
+	return tmpl, nil
+}
+
+// writes the HAProxy config file from the data in the datastore and reloads HAProxy, so that the
+// proxy reflects the stored state before the first write; if the config file already matches the
+// stored state, e.g. after a SIGHUP restart, it's left as-is and HAProxy isn't reloaded. Failures
+// are logged but not fatal.
+func syncOnStartup(config *Config, dbMgr DBManager, tmpl *template.Template) {
+	ha := NewHAProxy(config, tmpl)
+	svc := NewDataSvc(dbMgr.NewDatastore(), ha, config)
+	if configMatchesStored(svc, ha) {
+		log.Printf("[INFO] HAProxy config is already in sync on startup - skipping the reload")
+		return
+	}
+	if derr := svc.Sync(); derr != nil {
+		log.Printf("[WARN] Failed to sync HAProxy config on startup: %v", derr)
+		return
+	}
+	log.Printf("[INFO] Synced HAProxy config on startup")
+}
+
+// writes an HAProxy config file without any frontends or backends if the config file doesn't exist,
+// so that it can always be read; failures are logged but not fatal
+func initEmptyConfig(config *Config, tmpl *template.Template) {
+	if _, err := os.Stat(config.HAConfigPath); !os.IsNotExist(err) {
+		return
+	}
+	if err := NewHAProxy(config, tmpl).WriteConfig(Frontends{}, Backends{}); err != nil {
+		log.Printf("[WARN] Failed to create an empty HAProxy config on startup: %v", err)
+		return
+	}
+	log.Printf("[INFO] Created an empty HAProxy config at %s", config.HAConfigPath)
+}
+
+// determines whether the HAProxy config file matches the config rendered from the frontends and
+// backends in the data store; if either can't be read, they're assumed to differ
+func configMatchesStored(svc DataSvc, ha HAProxy) bool {
+	live, err := ha.GetConfig()
+	if err != nil {
+		return false
+	}
+	f, derr := svc.GetAllFrontends()
+	if derr != nil {
+		return false
+	}
+	b, derr := svc.GetAllBackends()
+	if derr != nil {
+		return false
+	}
+	rendered, err := ha.RenderConfig(f.ToHAProxyFrontends(), b.ToHAProxyBackends())
+	if err != nil {
+		return false
+	}
+	return stripConfigHeader(live) == rendered
+}
+
 // waits for a signal to reload config or shutdown the web server
 func waitForSignal(signalChan chan os.Signal, server Server) (bool, int) {
 	select {
@@ -136,6 +314,10 @@ func waitForSignal(signalChan chan os.Signal, server Server) (bool, int) {
 		case syscall.SIGHUP:
 			log.Printf("[INFO] Received SIGHUP signal - reloading configuration")
 			server.Stop()
+			if sig, ok := drainSignals(signalChan); ok {
+				log.Printf("[WARN] Received %v signal - shutting down", sig)
+				return true, 0
+			}
 			return false, 0
 		default:
 			log.Printf("[WARN] Received %v signal - shutting down", sig)
@@ -145,10 +327,64 @@ func waitForSignal(signalChan chan os.Signal, server Server) (bool, int) {
 	}
 }
 
+// deletes the expired frontends and backends every interval until stop is closed; failures are
+// logged and retried on the next sweep
+func sweepExpired(svc DataSvc, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			count, derr := svc.SweepExpired(now)
+			if derr != nil {
+				log.Printf("[WARN] Failed to delete expired frontends and backends: %v", derr)
+			} else if count > 0 {
+				log.Printf("[INFO] Deleted %d expired frontends and backends", count)
+			}
+		}
+	}
+}
+
+// discards the SIGHUP signals received while the server was stopping for a reload, so that a burst
+// of them causes a single reload; if another signal was received, it is returned along with true
+func drainSignals(signalChan chan os.Signal) (os.Signal, bool) {
+	for {
+		select {
+		case sig := <-signalChan:
+			if sig != syscall.SIGHUP {
+				return sig, true
+			}
+		default:
+			return nil, false
+		}
+	}
+}
+
 //
 func (s *serverImpl) Run(config *Config, dbMgr DBManager, tmpl *template.Template) {
-	router := initRouter(s, config, dbMgr, tmpl)
-	neg := initNegroni(router)
+	defer close(s.done)
+
+	// reloads are queued so that HAProxy is reloaded by one request at a time; pending reloads are
+	// drained once the server has stopped
+	queue := NewReloadQueue(NewHAProxy(config, tmpl), config.ReloadQueueSize)
+	defer queue.Close()
+
+	// writes in progress when the server stops are allowed to finish syncing HAProxy, so that the
+	// config file is never left half-written by a shutdown or restart
+	svc := NewDataSvc(dbMgr.NewDatastore(), queue, config)
+	defer svc.Drain()
+
+	// the expiry sweep is stopped before the writes in progress are drained
+	if interval, _ := time.ParseDuration(config.ExpirySweepInterval); interval > 0 {
+		stopSweep := make(chan struct{})
+		defer close(stopSweep)
+		go sweepExpired(svc, interval, stopSweep)
+	}
+
+	router := initRouter(s, config, dbMgr, svc, queue)
+	neg := initNegroni(config, router)
 
 	server := &http.Server{Addr: ":" + config.Port, Handler: neg}
 
@@ -177,41 +413,126 @@ func (s *serverImpl) Run(config *Config, dbMgr DBManager, tmpl *template.Templat
 	}
 }
 
-// func initRouter(server Server, config *Config, dbMgr DBManager, tmpl *template.Template) *mux.Router {
-func initRouter(server Server, config *Config, dbMgr DBManager, tmpl *template.Template) *mux.Router {
-	r := mux.NewRouter()
+// func initRouter(server Server, config *Config, dbMgr DBManager, svc DataSvc, queue *ReloadQueue) *mux.Router {
+func initRouter(server Server, config *Config, dbMgr DBManager, svc DataSvc, queue *ReloadQueue) *mux.Router {
+	root := mux.NewRouter()
+
+	// register the routes under the route prefix, if any
+	r := root
+	if config.RoutePrefix != "" {
+		r = root.PathPrefix(config.RoutePrefix).Subrouter()
+	}
 
 	// initialize values to inject into handlers
-	enc := JSONEncoder{}
-	ha := NewHAProxy(config.HAConfigPath, tmpl, config.HAReloadCommand)
-	svc := NewDataSvc(dbMgr.NewDatastore(), ha)
+	enc := JSONEncoder{PreserveUnknownFields: config.PreserveUnknownFields, FieldStyle: config.JSONFieldStyle}
+	var ha HAProxy = queue
+	cacheable := CacheControl(config.GETCacheMaxAge)
+	warnable := BackendLimitWarning(svc)
 
 	// admin routes
+	r.HandleFunc(`/`, func(w http.ResponseWriter, r *http.Request) {
+		GetIndex(w, enc, config.RoutePrefix)
+	}).Methods("GET")
+
 	r.HandleFunc(`/status`, func(w http.ResponseWriter, r *http.Request) {
 		GetStatus(w)
 	}).Methods("GET")
 
-	r.HandleFunc(`/haproxy/config`, func(w http.ResponseWriter, r *http.Request) {
+	r.HandleFunc(`/healthz`, func(w http.ResponseWriter, r *http.Request) {
+		GetHealthz(w)
+	}).Methods("GET")
+
+	r.HandleFunc(`/readyz`, func(w http.ResponseWriter, r *http.Request) {
+		GetReadyz(w, enc, svc, ha)
+	}).Methods("GET")
+
+	r.HandleFunc(`/haproxy/config`, cacheable(func(w http.ResponseWriter, r *http.Request) {
 		GetHAProxyConfig(w, enc, ha)
+	})).Methods("GET")
+
+	r.HandleFunc(`/haproxy/config/download`, cacheable(func(w http.ResponseWriter, r *http.Request) {
+		DownloadHAProxyConfig(w, enc, ha)
+	})).Methods("GET")
+
+	r.HandleFunc(`/haproxy/diff`, func(w http.ResponseWriter, r *http.Request) {
+		snap, release, derr := svc.Snapshot()
+		if derr != nil {
+			util{}.writeError(w, enc, derr)
+			return
+		}
+		defer release()
+		GetHAProxyDiff(w, enc, snap, ha)
+	}).Methods("GET")
+
+	r.HandleFunc(`/haproxy/health`, func(w http.ResponseWriter, r *http.Request) {
+		GetHAProxyHealth(w, enc, ha)
 	}).Methods("GET")
 
 	r.HandleFunc(`/haproxy/reload`, func(w http.ResponseWriter, r *http.Request) {
 		ReloadHAProxy(w, enc, ha)
 	}).Methods("GET")
 
+	r.HandleFunc(`/haproxy/queue`, func(w http.ResponseWriter, r *http.Request) {
+		GetReloadQueue(w, enc, queue)
+	}).Methods("GET")
+
+	r.HandleFunc(`/haproxy/stats`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return GetHAProxyStats(enc, ha)
+	})).Methods("GET")
+
+	r.HandleFunc(`/haproxy/template`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return GetHAProxyTemplate(w, svc)
+	})).Methods("GET")
+
+	r.HandleFunc(`/haproxy/template`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return PutHAProxyTemplate(r, svc)
+	})).Methods("PUT")
+
 	r.HandleFunc(`/restart`, func(w http.ResponseWriter, r *http.Request) {
 		GetRestart(w, server)
 	}).Methods("GET")
 
-	// frontend routes
-	r.HandleFunc(`/frontends`, func(w http.ResponseWriter, r *http.Request) {
-		GetFrontends(w, enc, svc)
+	r.HandleFunc(`/admin/compact`, func(w http.ResponseWriter, r *http.Request) {
+		PostCompact(w, enc, dbMgr)
+	}).Methods("POST")
+
+	r.HandleFunc(`/admin/db/recover`, func(w http.ResponseWriter, r *http.Request) {
+		PostRecoverDB(w, enc, dbMgr)
+	}).Methods("POST")
+
+	r.HandleFunc(`/all`, cacheable(Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return GetAll(r, enc, svc)
+	}))).Methods("GET")
+
+	// schema routes
+	backendSchema := NewJSONSchema(Backend{}, config.JSONFieldStyle, "name")
+	r.HandleFunc(`/schema/backend`, func(w http.ResponseWriter, r *http.Request) {
+		GetSchema(w, backendSchema)
 	}).Methods("GET")
 
-	r.HandleFunc(`/frontends/{name}`, func(w http.ResponseWriter, r *http.Request) {
-		GetFrontend(w, enc, svc, mux.Vars(r))
+	frontendSchema := NewJSONSchema(Frontend{}, config.JSONFieldStyle, "name")
+	r.HandleFunc(`/schema/frontend`, func(w http.ResponseWriter, r *http.Request) {
+		GetSchema(w, frontendSchema)
 	}).Methods("GET")
 
+	// frontend routes
+	r.HandleFunc(`/frontends`, cacheable(func(w http.ResponseWriter, r *http.Request) {
+		GetFrontends(w, r, enc, svc)
+	})).Methods("GET")
+
+	// registered before the {name} routes, which would otherwise match it
+	r.HandleFunc(`/frontends/validate`, func(w http.ResponseWriter, r *http.Request) {
+		PostFrontendsValidate(w, r, enc)
+	}).Methods("POST")
+
+	r.HandleFunc(`/frontends/{name}`, cacheable(func(w http.ResponseWriter, r *http.Request) {
+		GetFrontend(w, r, enc, svc, mux.Vars(r))
+	})).Methods("GET")
+
+	r.HandleFunc(`/frontends/{name}`, func(w http.ResponseWriter, r *http.Request) {
+		HeadFrontend(w, svc, mux.Vars(r))
+	}).Methods("HEAD")
+
 	r.HandleFunc(`/frontends/{name}`, func(w http.ResponseWriter, r *http.Request) {
 		PutFrontend(w, r, enc, svc, mux.Vars(r))
 	}).Methods("PUT")
@@ -225,43 +546,126 @@ func initRouter(server Server, config *Config, dbMgr DBManager, tmpl *template.T
 	}).Methods("DELETE")
 
 	// backend routes
-	r.HandleFunc(`/backends`, func(w http.ResponseWriter, r *http.Request) {
-		GetBackends(w, enc, svc)
-	}).Methods("GET")
+	r.HandleFunc(`/backends`, cacheable(Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return GetBackends(r, enc, svc)
+	}))).Methods("GET")
 
-	r.HandleFunc(`/backends/{name}`, func(w http.ResponseWriter, r *http.Request) {
-		GetBackend(w, enc, svc, mux.Vars(r))
-	}).Methods("GET")
+	r.HandleFunc(`/backends`, warnable(Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return CreateBackend(w, r, enc, svc)
+	}))).Methods("POST")
 
-	r.HandleFunc(`/backends/{name}`, func(w http.ResponseWriter, r *http.Request) {
-		PutBackend(w, r, enc, svc, mux.Vars(r))
-	}).Methods("PUT")
+	// registered before the {name} routes, which would otherwise match it
+	r.HandleFunc(`/backends/validate`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return PostBackendsValidate(r, enc)
+	})).Methods("POST")
 
-	r.HandleFunc(`/backends/{name}`, func(w http.ResponseWriter, r *http.Request) {
-		PostBackend(w, r, enc, svc, mux.Vars(r))
-	}).Methods("POST")
+	r.HandleFunc(`/backends/{name}`, cacheable(Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return GetBackend(r, enc, svc, mux.Vars(r))
+	}))).Methods("GET")
 
-	r.HandleFunc(`/backends/{name}`, func(w http.ResponseWriter, r *http.Request) {
-		DeleteBackend(w, enc, svc, mux.Vars(r))
-	}).Methods("DELETE")
+	r.HandleFunc(`/backends/{name}`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return HeadBackend(svc, mux.Vars(r))
+	})).Methods("HEAD")
 
-	r.HandleFunc(`/backends/{name}/members`, func(w http.ResponseWriter, r *http.Request) {
-		GetBackendMembers(w, enc, svc, mux.Vars(r))
-	}).Methods("GET")
+	r.HandleFunc(`/backends/{name}`, warnable(Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return PutBackend(w, r, enc, svc, mux.Vars(r))
+	}))).Methods("PUT")
+
+	r.HandleFunc(`/backends/{name}`, warnable(Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return PostBackend(w, r, enc, svc, mux.Vars(r))
+	}))).Methods("POST")
+
+	r.HandleFunc(`/backends/{name}`, warnable(Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return PatchBackend(w, r, enc, svc, mux.Vars(r))
+	}))).Methods("PATCH")
+
+	r.HandleFunc(`/backends/{name}`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return DeleteBackend(r, svc, mux.Vars(r))
+	})).Methods("DELETE")
+
+	r.HandleFunc(`/backends/{name}/config`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return GetBackendConfig(w, svc, ha, mux.Vars(r))
+	})).Methods("GET")
 
-	return r
+	r.HandleFunc(`/backends/{name}/members`, cacheable(Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return GetBackendMembers(r, enc, svc, mux.Vars(r))
+	}))).Methods("GET")
+
+	r.HandleFunc(`/backends/{name}/members/swap`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return SwapBackendMembers(r, enc, svc, mux.Vars(r))
+	})).Methods("POST")
+
+	r.HandleFunc(`/backends/{name}/clone`, warnable(Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return CloneBackend(w, r, enc, svc, mux.Vars(r))
+	}))).Methods("POST")
+
+	r.HandleFunc(`/backends/{name}/members/version`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return PostBackendMembersVersion(r, enc, svc, mux.Vars(r))
+	})).Methods("POST")
+
+	r.HandleFunc(`/backends/{name}/members/{memberName:.+}/meta`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return GetBackendMemberMeta(svc, mux.Vars(r))
+	})).Methods("GET")
+
+	r.HandleFunc(`/backends/{name}/members/{memberName:.+}/meta`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return PutBackendMemberMeta(r, svc, mux.Vars(r))
+	})).Methods("PUT")
+
+	// member routes
+	r.HandleFunc(`/members/heartbeat`, Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return PostMembersHeartbeat(r, enc, svc)
+	})).Methods("POST")
+
+	return root
 }
 
 // initialize Negroni (middleware, handler)
-func initNegroni(handler http.Handler) *negroni.Negroni {
+func initNegroni(config *Config, handler http.Handler) *negroni.Negroni {
 	n := negroni.New()
 	n.Use(negroni.NewRecovery())
-	n.Use(negroni.NewLogger())
+	n.Use(LoggerMiddleware(log.New(os.Stdout, "[negroni] ", 0), config.TrustProxyHeaders))
 	n.Use(ContentTypeMiddleware())
+	n.Use(DisabledEndpointsMiddleware(JSONEncoder{}, config.DisabledEndpoints))
 	n.UseHandler(handler)
 	return n
 }
 
+// LoggerMiddleware gets Negroni middleware that logs each request, along with the IP address of the
+// client, as it goes in and its response as it goes out. When trustProxyHeaders is true, the client
+// address is taken from the X-Forwarded-For or X-Real-IP header set by a proxy in front of Conduit;
+// otherwise these headers are ignored, since any client can set them.
+func LoggerMiddleware(l *log.Logger, trustProxyHeaders bool) negroni.HandlerFunc {
+	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		start := time.Now()
+		l.Printf("Started %s %s from %s", r.Method, r.URL.Path, clientIP(r, trustProxyHeaders))
+
+		next(w, r)
+
+		if res, ok := w.(negroni.ResponseWriter); ok {
+			l.Printf("Completed %v %s in %v", res.Status(), http.StatusText(res.Status()), time.Since(start))
+		}
+	})
+}
+
+// returns the IP address of the client that made the given request; when trustProxyHeaders is true,
+// the original client named by a proxy is returned instead of the proxy
+func clientIP(r *http.Request, trustProxyHeaders bool) string {
+	if trustProxyHeaders {
+		// the first address is the original client, followed by each proxy it passed through
+		if fwd := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-For"), ",")[0]); fwd != "" {
+			return fwd
+		}
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // ContentTypeMiddleware gets Negroni middleware that sets the Content-Type header for all responses.
 func ContentTypeMiddleware() negroni.HandlerFunc {
 	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
@@ -270,24 +674,211 @@ func ContentTypeMiddleware() negroni.HandlerFunc {
 	})
 }
 
+// DisabledEndpointsMiddleware gets Negroni middleware that responds with a 403 to any request whose
+// path matches one of the given patterns (see path.Match for the pattern syntax).
+func DisabledEndpointsMiddleware(enc Encoder, patterns []string) negroni.HandlerFunc {
+	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, r.URL.Path); ok {
+				util{}.writeResponse(w, http.StatusForbidden,
+					enc.Encode(NewErrorResponse(http.StatusForbidden, fmt.Sprintf("the endpoint %s is disabled", r.URL.Path))))
+				return
+			}
+		}
+		next(w, r)
+	})
+}
+
+// CacheControl returns a function that wraps a handler so that it sets a Cache-Control header
+// allowing the response to be cached for maxAge seconds; if maxAge is zero, no header is set.
+func CacheControl(maxAge int) func(http.HandlerFunc) http.HandlerFunc {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		if maxAge <= 0 {
+			return h
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", maxAge))
+			h(w, r)
+		}
+	}
+}
+
+// BackendLimitWarning returns a function that wraps a handler so that a successful response has a
+// Warning header when the number of backends exceeds the configured soft limit.
+func BackendLimitWarning(svc DataSvc) func(http.HandlerFunc) http.HandlerFunc {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			h(&limitWarningWriter{w, svc}, r)
+		}
+	}
+}
+
+// limitWarningWriter adds the backend limit warning, if any, to the headers of a successful
+// response as they're written.
+type limitWarningWriter struct {
+	http.ResponseWriter
+	svc DataSvc
+}
+
+// WriteHeader writes the response headers, including the warning, with the given status code.
+func (w *limitWarningWriter) WriteHeader(code int) {
+	if code < http.StatusMultipleChoices {
+		if msg := w.svc.BackendLimitWarning(); msg != "" {
+			w.Header().Add("Warning", fmt.Sprintf("199 - %q", msg))
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// IndexResponse represents the serializable response of the index endpoint.
+type IndexResponse struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// GetIndex is a REST handler that returns the service name and version along with the top-level
+// endpoints, each under the given route prefix, so that requests to the root path get a response.
+func GetIndex(w http.ResponseWriter, enc Encoder, prefix string) {
+	res := &IndexResponse{Name: "Thalassa Conduit", Version: version}
+	for _, e := range indexEndpoints {
+		res.Endpoints = append(res.Endpoints, prefix+e)
+	}
+	util{}.writeResponse(w, http.StatusOK, enc.Encode(res))
+}
+
+// typedItem is a frontend or backend in a list of both, which is encoded as the object itself
+// preceded by a "type" field naming its type.
+type typedItem struct {
+	Type string
+	Item interface{}
+}
+
+// MarshalJSON encodes the item with its type as its first field.
+func (t typedItem) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(t.Item)
+	if err != nil {
+		return nil, err
+	}
+	typ, _ := json.Marshal(t.Type)
+	buf := append([]byte(`{"type":`), typ...)
+	if len(b) > 2 {
+		buf = append(buf, ',')
+	}
+	return append(buf, b[1:]...), nil
+}
+
+// GetAll is a REST handler that returns the frontends followed by the backends as a single list,
+// each tagged with its type; the list options apply to the combined list.
+func GetAll(r *http.Request, enc Encoder, svc DataSvc) (int, string, *Error) {
+	opts, e := util{}.parseListOptions(r)
+	if e != nil {
+		return 0, "", NewError(ErrBadData, e)
+	}
+	f, err := svc.GetAllFrontends()
+	if err != nil {
+		return 0, "", err
+	}
+	b, err := svc.GetAllBackends()
+	if err != nil {
+		return 0, "", err
+	}
+	if !opts.ModifiedSince.IsZero() {
+		f = f.ModifiedSince(opts.ModifiedSince)
+		b = b.ModifiedSince(opts.ModifiedSince)
+	}
+
+	items := []interface{}{}
+	for _, x := range f {
+		items = append(items, typedItem{"frontend", x})
+	}
+	for _, x := range b {
+		items = append(items, typedItem{"backend", x})
+	}
+	return http.StatusOK, util{}.list(enc, items, opts), nil
+}
+
 // GetStatus is a REST handler that will return the application status.
 func GetStatus(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"status":"ok"}`))
 }
 
+// GetHealthz is a REST handler for liveness probes that returns a 200 whenever the process is up.
+func GetHealthz(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+// ReadinessResponse represents the serializable result of a readiness check.
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// GetReadyz is a REST handler for readiness probes that checks that the datastore and the HAProxy
+// config file can be read, returning a 503 if either of them is unavailable.
+func GetReadyz(w http.ResponseWriter, enc Encoder, svc DataSvc, ha HAProxy) {
+	res := &ReadinessResponse{Status: "ok", Checks: map[string]string{}}
+	code := http.StatusOK
+
+	res.Checks["datastore"] = "ok"
+	if _, err := svc.GetAllBackends(); err != nil {
+		res.Checks["datastore"] = err.Error()
+		res.Status, code = "unavailable", http.StatusServiceUnavailable
+	}
+
+	res.Checks["haproxyConfig"] = "ok"
+	if _, err := ha.GetConfig(); err != nil {
+		res.Checks["haproxyConfig"] = err.Error()
+		res.Status, code = "unavailable", http.StatusServiceUnavailable
+	}
+
+	util{}.writeResponse(w, code, enc.Encode(res))
+}
+
 // GetRestart is a REST handler that will stop the http server, reload configuration, and restart it.
 func GetRestart(w http.ResponseWriter, server Server) {
 	server.SignalRestart()
 	w.WriteHeader(http.StatusOK)
 }
 
-// Stop will stop the server.
+// PostCompact is a REST handler that compacts the database to reclaim the space taken by deleted
+// and overwritten data.
+func PostCompact(w http.ResponseWriter, enc Encoder, dbMgr DBManager) {
+	if err := dbMgr.Compact(); err != nil {
+		util{}.writeResponse(w, http.StatusInternalServerError,
+			enc.Encode(NewErrorResponse(http.StatusInternalServerError, "error compacting the database")))
+		return
+	}
+	util{}.writeResponse(w, http.StatusOK, `{"status":"ok"}`)
+}
+
+// PostRecoverDB is a REST handler that closes the database and reopens it through the recovery
+// path, e.g. after it has become corrupt; requests that arrive meanwhile wait for it to reopen.
+func PostRecoverDB(w http.ResponseWriter, enc Encoder, dbMgr DBManager) {
+	if err := dbMgr.Recover(); err != nil {
+		log.Printf("[ERROR] %v", err)
+		util{}.writeResponse(w, http.StatusInternalServerError,
+			enc.Encode(NewErrorResponse(http.StatusInternalServerError, err.Error())))
+		return
+	}
+	util{}.writeResponse(w, http.StatusOK, `{"status":"ok"}`)
+}
+
+// Stop will stop the server once the HAProxy syncs in progress have completed, waiting no longer
+// than the server's timeout for them.
 func (s *serverImpl) Stop() {
 	if !s.shutdown {
 		s.shutdown = true
 		s.stopChan <- 1
-		<-time.After(s.timeout)
+
+		// wait for the server to finish the HAProxy syncs and reloads in progress
+		select {
+		case <-s.done:
+		case <-time.After(s.timeout):
+			log.Printf("[WARN] Stopped without waiting for in-progress HAProxy syncs to complete")
+		}
 		close(s.stopChan)
 	}
 }
@@ -1,11 +1,123 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
+	"text/template"
+	"time"
+
+	"github.com/gorilla/mux"
 )
 
+// Tests that the GetIndex() handler lists the service version and the main routes.
+func Test_GetIndex(t *testing.T) {
+	rw := httptest.NewRecorder()
+	enc := JSONEncoder{}
+	GetIndex(rw, enc, "")
+
+	res := &IndexResponse{}
+	err := enc.Decode(rw.Body.Bytes(), res)
+	assert.EnsureNil(t, err, "GetIndex() returned an invalid body: %v", err)
+	assert.Equal(t, rw.Code, http.StatusOK, "GetIndex() returned unexpected status code")
+	assert.Equal(t, res.Name, "Thalassa Conduit", "GetIndex() returned unexpected name")
+	assert.Equal(t, res.Version, version, "GetIndex() returned unexpected version")
+	for _, e := range []string{"/status", "/frontends", "/backends", "/haproxy/config", "/haproxy/reload"} {
+		assert.StringContains(t, rw.Body.String(), `"`+e+`"`, "GetIndex() did not list %s", e)
+	}
+}
+
+// Tests that the GetAll() handler lists the frontends and backends together, tagged with their types,
+// and pages across the combined list.
+func Test_GetAll(t *testing.T) {
+	enc := JSONEncoder{}
+	svc := testHelpers.NewDataSvcMock()
+	f := fData.OneFrontend()
+	b1 := bData.OneBackend()
+	b2 := bData.OtherBackend()
+	svc.SaveFrontend(f)
+	svc.SaveBackend(b1)
+	svc.SaveBackend(b2)
+
+	type item struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	get := func(query string) []item {
+		r, _ := http.NewRequest("GET", "/all"+query, nil)
+		status, body, derr := GetAll(r, enc, svc)
+		assert.EnsureNil(t, derr, "GetAll() returned an unexpected error: %v", derr)
+		assert.Equal(t, status, http.StatusOK, "GetAll() returned unexpected status code")
+		items := []item{}
+		err := enc.Decode([]byte(body), &items)
+		assert.EnsureNil(t, err, "GetAll() returned an invalid body: %v", err)
+		return items
+	}
+
+	items := get("")
+	assert.Equal(t, items, []item{{"frontend", f.Name}, {"backend", b1.Name}, {"backend", b2.Name}},
+		"GetAll() returned unexpected items")
+
+	items = get("?offset=0&limit=2")
+	assert.Equal(t, items, []item{{"frontend", f.Name}, {"backend", b1.Name}}, "GetAll() returned an unexpected first page")
+	items = get("?offset=2&limit=2")
+	assert.Equal(t, items, []item{{"backend", b2.Name}}, "GetAll() returned an unexpected second page")
+
+	r, _ := http.NewRequest("GET", "/all?fields=type,name&limit=1&envelope=true", nil)
+	_, body, _ := GetAll(r, enc, svc)
+	assert.Equal(t, body, `{"data":[{"type":"frontend","name":"`+f.Name+`"}],"total":3,"limit":1,"offset":0}`,
+		"GetAll() returned an unexpected envelope")
+
+	r, _ = http.NewRequest("GET", "/all?limit=x", nil)
+	_, _, derr := GetAll(r, enc, svc)
+	assert.EnsureNotNil(t, derr, "GetAll() failed to return an error when expected")
+	assert.Equal(t, derr.Type, ErrBadData, "GetAll() returned an unexpected error type")
+}
+
+// Tests that the index is served at the root path, under the route prefix if one is configured.
+func Test_initRouter_Index(t *testing.T) {
+	for _, prefix := range []string{"", "/conduit"} {
+		config := &Config{RoutePrefix: prefix}
+		queue := NewReloadQueue(testHelpers.NewHAProxyMock(), 0)
+		svc := NewDataSvc(testHelpers.NewDatastoreMock(), queue, config)
+		router := initRouter(nil, config, testHelpers.NewDBManagerMock(), svc, queue)
+
+		rw := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", prefix+"/", nil)
+		router.ServeHTTP(rw, r)
+		assert.Equal(t, rw.Code, http.StatusOK, "initRouter() did not serve the index for prefix %q", prefix)
+		assert.StringContains(t, rw.Body.String(), `"`+prefix+`/backends"`, "initRouter() served an unexpected index for prefix %q", prefix)
+		queue.Close()
+	}
+}
+
+// Tests that the /haproxy/diff route responds with the status of the error when the data store
+// snapshot can't be taken.
+func Test_initRouter_HAProxyDiff_SnapshotError(t *testing.T) {
+	config := &Config{}
+	queue := NewReloadQueue(testHelpers.NewHAProxyMock(), 0)
+	defer queue.Close()
+	svc := testHelpers.NewDataSvcMock()
+	svc.SnapshotError = NewErrorf(ErrDB, "the database has been closed")
+	router := initRouter(nil, config, testHelpers.NewDBManagerMock(), svc, queue)
+
+	rw := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/haproxy/diff", nil)
+	router.ServeHTTP(rw, r)
+	assert.Equal(t, rw.Code, http.StatusInternalServerError, "initRouter() returned unexpected status code")
+	assert.StringContains(t, rw.Body.String(), "the database has been closed", "initRouter() returned unexpected body")
+}
+
 // Tests that the GetStatus() handler behaves properly.
 func Test_GetStatus(t *testing.T) {
 	rw := httptest.NewRecorder()
@@ -16,3 +128,545 @@ func Test_GetStatus(t *testing.T) {
 	assert.Equal(t, rw.Code, expCode, "GetStatus() returned unexpected status code")
 	assert.Equal(t, rw.Body.String(), expBody, "GetStatus() returned unexpected body")
 }
+
+// Tests that the GetHealthz() handler behaves properly.
+func Test_GetHealthz(t *testing.T) {
+	rw := httptest.NewRecorder()
+	GetHealthz(rw)
+
+	assert.Equal(t, rw.Code, http.StatusOK, "GetHealthz() returned unexpected status code")
+	assert.Equal(t, rw.Body.String(), `{"status":"ok"}`, "GetHealthz() returned unexpected body")
+}
+
+// Tests the "happy path" for the GetReadyz() handler.
+func Test_GetReadyz(t *testing.T) {
+	rw := httptest.NewRecorder()
+	GetReadyz(rw, JSONEncoder{}, testHelpers.NewDataSvcMock(), testHelpers.NewHAProxyMock())
+
+	expBody := `{"status":"ok","checks":{"datastore":"ok","haproxyConfig":"ok"}}`
+	assert.Equal(t, rw.Code, http.StatusOK, "GetReadyz() returned unexpected status code")
+	assert.Equal(t, rw.Body.String(), expBody, "GetReadyz() returned unexpected body")
+}
+
+// Tests that the GetReadyz() handler reports a datastore failure.
+func Test_GetReadyz_DatastoreDown(t *testing.T) {
+	rw := httptest.NewRecorder()
+	svc := testHelpers.NewDataSvcMock()
+	svc.GetAllError = NewErrorf(ErrDB, "db is closed")
+	GetReadyz(rw, JSONEncoder{}, svc, testHelpers.NewHAProxyMock())
+
+	expBody := `{"status":"unavailable","checks":{"datastore":"db is closed","haproxyConfig":"ok"}}`
+	assert.Equal(t, rw.Code, http.StatusServiceUnavailable, "GetReadyz() returned unexpected status code")
+	assert.Equal(t, rw.Body.String(), expBody, "GetReadyz() returned unexpected body")
+}
+
+// Tests that the GetReadyz() handler reports an HAProxy config failure.
+func Test_GetReadyz_ConfigUnreadable(t *testing.T) {
+	rw := httptest.NewRecorder()
+	ha := testHelpers.NewHAProxyMock()
+	ha.getConfigAction = func() (string, error) { return "", errors.New("no such file") }
+	GetReadyz(rw, JSONEncoder{}, testHelpers.NewDataSvcMock(), ha)
+
+	expBody := `{"status":"unavailable","checks":{"datastore":"ok","haproxyConfig":"no such file"}}`
+	assert.Equal(t, rw.Code, http.StatusServiceUnavailable, "GetReadyz() returned unexpected status code")
+	assert.Equal(t, rw.Body.String(), expBody, "GetReadyz() returned unexpected body")
+}
+
+// Tests the "happy path" for the PostCompact() handler.
+func Test_PostCompact(t *testing.T) {
+	rw := httptest.NewRecorder()
+	dbMgr := testHelpers.NewDBManagerMock()
+	PostCompact(rw, JSONEncoder{}, dbMgr)
+
+	assert.Equal(t, rw.Code, http.StatusOK, "PostCompact() returned unexpected status code")
+	assert.Equal(t, rw.Body.String(), `{"status":"ok"}`, "PostCompact() returned unexpected body")
+	assert.Equal(t, dbMgr.Compactions, 1, "PostCompact() did not compact the database")
+}
+
+// Tests that the PostCompact() handler reports a failed compaction.
+func Test_PostCompact_Error(t *testing.T) {
+	rw := httptest.NewRecorder()
+	dbMgr := testHelpers.NewDBManagerMock()
+	dbMgr.CompactError = errors.New("compaction failed")
+	PostCompact(rw, JSONEncoder{}, dbMgr)
+
+	expCode := http.StatusInternalServerError
+	assert.Equal(t, rw.Code, expCode, "PostCompact() returned unexpected status code")
+	assert.StringContains(t, rw.Body.String(), fmt.Sprintf(`"code":%d`, expCode), "PostCompact() returned unexpected body")
+}
+
+// Tests the "happy path" for the PostRecoverDB() handler.
+func Test_PostRecoverDB(t *testing.T) {
+	rw := httptest.NewRecorder()
+	dbMgr := testHelpers.NewDBManagerMock()
+	PostRecoverDB(rw, JSONEncoder{}, dbMgr)
+
+	assert.Equal(t, rw.Code, http.StatusOK, "PostRecoverDB() returned unexpected status code")
+	assert.Equal(t, rw.Body.String(), `{"status":"ok"}`, "PostRecoverDB() returned unexpected body")
+	assert.Equal(t, dbMgr.Recoveries, 1, "PostRecoverDB() did not recover the database")
+}
+
+// Tests that the PostRecoverDB() handler reports a failed recovery.
+func Test_PostRecoverDB_Error(t *testing.T) {
+	rw := httptest.NewRecorder()
+	dbMgr := testHelpers.NewDBManagerMock()
+	dbMgr.RecoverError = errors.New("the database could not be recovered: corrupt")
+	PostRecoverDB(rw, JSONEncoder{}, dbMgr)
+
+	expCode := http.StatusInternalServerError
+	assert.Equal(t, rw.Code, expCode, "PostRecoverDB() returned unexpected status code")
+	assert.StringContains(t, rw.Body.String(), "the database could not be recovered: corrupt", "PostRecoverDB() returned unexpected body")
+}
+
+// Tests that the DisabledEndpointsMiddleware() middleware blocks disabled paths and allows others.
+func Test_DisabledEndpointsMiddleware(t *testing.T) {
+	mw := DisabledEndpointsMiddleware(JSONEncoder{}, []string{"/restart", "/haproxy/*"})
+
+	testCases := []struct {
+		Path    string
+		ExpCode int
+	}{
+		{Path: "/restart", ExpCode: http.StatusForbidden},
+		{Path: "/haproxy/reload", ExpCode: http.StatusForbidden},
+		{Path: "/haproxy/config", ExpCode: http.StatusForbidden},
+		{Path: "/backends", ExpCode: http.StatusOK},
+		{Path: "/status", ExpCode: http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		rw := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", testCase.Path, nil)
+		called := false
+		mw(rw, r, func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			w.WriteHeader(http.StatusOK)
+		})
+
+		assert.Equal(t, rw.Code, testCase.ExpCode, "DisabledEndpointsMiddleware() returned unexpected status code for %s", testCase.Path)
+		assert.Equal(t, called, testCase.ExpCode == http.StatusOK, "DisabledEndpointsMiddleware() called next handler unexpectedly for %s", testCase.Path)
+		if testCase.ExpCode == http.StatusForbidden {
+			assert.StringContains(t, rw.Body.String(), `"code":403`, "DisabledEndpointsMiddleware() returned unexpected body for %s", testCase.Path)
+		}
+	}
+}
+
+// Tests that the routes of initRouter() resolve only under the configured route prefix.
+func Test_initRouter_RoutePrefix(t *testing.T) {
+	config := &Config{RoutePrefix: "/conduit"}
+	queue := NewReloadQueue(testHelpers.NewHAProxyMock(), 0)
+	defer queue.Close()
+	svc := NewDataSvc(testHelpers.NewDatastoreMock(), queue, config)
+	router := initRouter(nil, config, testHelpers.NewDBManagerMock(), svc, queue)
+
+	testCases := []struct {
+		Path    string
+		ExpCode int
+	}{
+		{Path: "/conduit/backends", ExpCode: http.StatusOK},
+		{Path: "/conduit/status", ExpCode: http.StatusOK},
+		{Path: "/backends", ExpCode: http.StatusNotFound},
+		{Path: "/status", ExpCode: http.StatusNotFound},
+		{Path: "/conduitbackends", ExpCode: http.StatusNotFound},
+	}
+	for _, testCase := range testCases {
+		rw := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", testCase.Path, nil)
+		router.ServeHTTP(rw, r)
+		assert.Equal(t, rw.Code, testCase.ExpCode, "initRouter() routed %s unexpectedly", testCase.Path)
+	}
+}
+
+// Tests that the CacheControl() wrapper sets the Cache-Control header with the configured max-age.
+func Test_CacheControl(t *testing.T) {
+	rw := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/backends", nil)
+	CacheControl(60)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})(rw, r)
+
+	assert.Equal(t, rw.Code, http.StatusOK, "CacheControl() returned unexpected status code")
+	assert.Equal(t, rw.Header().Get("Cache-Control"), "max-age=60", "CacheControl() set an unexpected Cache-Control header")
+}
+
+// Tests that the CacheControl() wrapper doesn't set a Cache-Control header when disabled.
+func Test_CacheControl_Disabled(t *testing.T) {
+	rw := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/backends", nil)
+	svc := testHelpers.NewDataSvcMock()
+	CacheControl(0)(Handle(JSONEncoder{}, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return GetBackends(r, JSONEncoder{}, svc)
+	}))(rw, r)
+
+	assert.Equal(t, rw.Code, http.StatusOK, "CacheControl() returned unexpected status code")
+	assert.Equal(t, rw.Header().Get("Cache-Control"), "", "CacheControl() set a Cache-Control header when disabled")
+}
+
+// Tests that syncOnStartup() writes the HAProxy config file from the stored frontends and backends.
+func Test_syncOnStartup(t *testing.T) {
+	dbPath := testHelpers.DBPath(t)
+	defer os.RemoveAll(dbPath)
+	config := &Config{
+		DBPath:          dbPath,
+		HAConfigPath:    filepath.Join(dbPath, "haproxy.cfg"),
+		HAReloadCommand: "true",
+		SyncOnStartup:   true,
+	}
+
+	dbMgr, err := NewDBManager(config)
+	assert.EnsureNil(t, err, "NewDBManager() returned an unexpected error: %v", err)
+	defer closeDB(dbMgr)
+
+	b := bsData.OneBackend()
+	f := fsData.OneFrontend()
+	db := dbMgr.NewDatastore()
+	assert.EnsureNil(t, db.SaveBackend(b), "Datastore.SaveBackend() returned an unexpected error")
+	assert.EnsureNil(t, db.SaveFrontend(f), "Datastore.SaveFrontend() returned an unexpected error")
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	syncOnStartup(config, dbMgr, tmpl)
+
+	c, err := ioutil.ReadFile(config.HAConfigPath)
+	assert.EnsureNil(t, err, "syncOnStartup() failed to write the HAProxy config file: %v", err)
+	assert.True(t, strings.Contains(string(c), "backend "+b.Name), "syncOnStartup() did not write the stored backend:\n%s", c)
+	assert.True(t, strings.Contains(string(c), "frontend "+f.Name), "syncOnStartup() did not write the stored frontend:\n%s", c)
+}
+
+// Tests that syncOnStartup() doesn't reload HAProxy when the config file already matches the stored
+// frontends and backends, e.g. after a restart, but does once they've changed.
+func Test_syncOnStartup_Unchanged(t *testing.T) {
+	dbPath := testHelpers.DBPath(t)
+	defer os.RemoveAll(dbPath)
+	marker := filepath.Join(dbPath, "reloaded")
+	config := &Config{
+		DBPath:          dbPath,
+		HAConfigPath:    filepath.Join(dbPath, "haproxy.cfg"),
+		HAReloadCommand: "touch " + marker,
+		SyncOnStartup:   true,
+	}
+
+	dbMgr, err := NewDBManager(config)
+	assert.EnsureNil(t, err, "NewDBManager() returned an unexpected error: %v", err)
+	defer closeDB(dbMgr)
+
+	b := bsData.OneBackend()
+	db := dbMgr.NewDatastore()
+	assert.EnsureNil(t, db.SaveBackend(b), "Datastore.SaveBackend() returned an unexpected error")
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	syncOnStartup(config, dbMgr, tmpl)
+	_, err = os.Stat(marker)
+	assert.EnsureNil(t, err, "syncOnStartup() did not reload HAProxy when the config file was missing")
+
+	// a restart with nothing changed
+	os.Remove(marker)
+	syncOnStartup(config, dbMgr, tmpl)
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err), "syncOnStartup() reloaded HAProxy when the config file was unchanged")
+
+	// a restart after the stored data changed
+	b2 := bsData.OneBackend()
+	b2.Name = "other"
+	assert.EnsureNil(t, db.SaveBackend(b2), "Datastore.SaveBackend() returned an unexpected error")
+	syncOnStartup(config, dbMgr, tmpl)
+	_, err = os.Stat(marker)
+	assert.Nil(t, err, "syncOnStartup() did not reload HAProxy when the stored data changed")
+}
+
+// Tests that initEmptyConfig() creates a config file with only the template's global sections when
+// it's missing, and leaves an existing config file alone.
+func Test_initEmptyConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conduit-init-config")
+	assert.EnsureNil(t, err, "ioutil.TempDir() returned an unexpected error: %v", err)
+	defer os.RemoveAll(dir)
+	config := &Config{HAConfigPath: filepath.Join(dir, "haproxy.cfg"), InitEmptyConfig: true}
+	tmpl, _ := template.New("test").Parse(testTemplate)
+
+	initEmptyConfig(config, tmpl)
+	c, err := ioutil.ReadFile(config.HAConfigPath)
+	assert.EnsureNil(t, err, "initEmptyConfig() failed to create the HAProxy config file: %v", err)
+	expected, _ := NewHAProxy(config, tmpl).RenderConfig(Frontends{}, Backends{})
+	assert.Equal(t, string(c), expected, "initEmptyConfig() wrote unexpected content")
+	assert.StringContains(t, string(c), "global", "initEmptyConfig() wrote a config without a global section")
+	assert.NotStringContains(t, string(c), "frontend ", "initEmptyConfig() wrote a config with a frontend")
+	assert.NotStringContains(t, string(c), "backend ", "initEmptyConfig() wrote a config with a backend")
+
+	existing := "global\n  daemon\n"
+	assert.EnsureNil(t, ioutil.WriteFile(config.HAConfigPath, []byte(existing), 0644), "ioutil.WriteFile() returned an unexpected error")
+	initEmptyConfig(config, tmpl)
+	c, _ = ioutil.ReadFile(config.HAConfigPath)
+	assert.Equal(t, string(c), existing, "initEmptyConfig() replaced an existing config file")
+}
+
+// Tests that loadTemplate() prefers the template stored in the datastore over the template file,
+// and that the stored template is used to render the HAProxy config.
+func Test_loadTemplate_Stored(t *testing.T) {
+	dbPath := testHelpers.DBPath(t)
+	defer os.RemoveAll(dbPath)
+	config := &Config{
+		DBPath:          dbPath,
+		HAConfigPath:    filepath.Join(dbPath, "haproxy.cfg"),
+		HATemplatePath:  "test-fixtures/template.txt",
+		HAReloadCommand: "true",
+	}
+
+	dbMgr, err := NewDBManager(config)
+	assert.EnsureNil(t, err, "NewDBManager() returned an unexpected error: %v", err)
+	defer closeDB(dbMgr)
+
+	b := bsData.OneBackend()
+	db := dbMgr.NewDatastore()
+	assert.EnsureNil(t, db.SaveBackend(b), "Datastore.SaveBackend() returned an unexpected error")
+	stored := "# stored template\n{{range .Backends}}backend {{.Name}}\n{{end}}"
+	assert.EnsureNil(t, db.SaveTemplate(stored), "Datastore.SaveTemplate() returned an unexpected error")
+
+	tmpl, err := loadTemplate(config, db)
+	assert.EnsureNil(t, err, "loadTemplate() returned an unexpected error: %v", err)
+	syncOnStartup(config, dbMgr, tmpl)
+
+	c, err := ioutil.ReadFile(config.HAConfigPath)
+	assert.EnsureNil(t, err, "syncOnStartup() failed to write the HAProxy config file: %v", err)
+	assert.Equal(t, string(c), "# stored template\nbackend "+b.Name+"\n", "loadTemplate() did not use the stored template")
+}
+
+// Tests that loadTemplate() reads the template file when no template is stored in the datastore.
+func Test_loadTemplate_File(t *testing.T) {
+	config := &Config{HATemplatePath: "test-fixtures/template.txt"}
+	expected, _ := ioutil.ReadFile(config.HATemplatePath)
+
+	tmpl, err := loadTemplate(config, testHelpers.NewDatastoreMock())
+	assert.EnsureNil(t, err, "loadTemplate() returned an unexpected error: %v", err)
+	assert.EnsureNotNil(t, tmpl, "loadTemplate() returned a nil template")
+	assert.Equal(t, tmpl.Tree.Root.String(), string(expected), "loadTemplate() did not use the template file")
+
+	config.HATemplatePath = "test-fixtures/does-not-exist.txt"
+	_, err = loadTemplate(config, testHelpers.NewDatastoreMock())
+	assert.NotNil(t, err, "loadTemplate() failed to return an error for a missing template file")
+}
+
+// Tests that loadTemplate() falls back to the default template when the stored template can't be
+// parsed.
+func Test_loadTemplate_Invalid(t *testing.T) {
+	config := &Config{HATemplatePath: "test-fixtures/template.txt"}
+	db := testHelpers.NewDatastoreMock()
+	db.Template = "{{range .Backends}}backend {{.Name}}"
+
+	tmpl, err := loadTemplate(config, db)
+	assert.EnsureNil(t, err, "loadTemplate() returned an unexpected error: %v", err)
+	assert.EnsureNotNil(t, tmpl, "loadTemplate() returned a nil template")
+	expected := template.Must(template.New("test").Parse(defaultTemplate))
+	assert.Equal(t, tmpl.Tree.Root.String(), expected.Tree.Root.String(), "loadTemplate() did not fall back to the default template")
+}
+
+// Tests that the expiry sweep deletes expired backends on each interval and returns once stopped.
+func Test_sweepExpired(t *testing.T) {
+	svc := testHelpers.NewDataSvcMock()
+	svc.SaveBackend(&Backend{Name: "preview", ExpiresAt: time.Now().Add(20 * time.Millisecond)})
+	svc.SaveBackend(&Backend{Name: "live"})
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		sweepExpired(svc, 10*time.Millisecond, stop)
+		close(done)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sweepExpired() did not return once stopped")
+	}
+	assert.EnsureEqual(t, len(svc.Backends), 1, "sweepExpired() did not delete the expired backend")
+	assert.Equal(t, svc.Backends[0].Name, "live", "sweepExpired() deleted an unexpired backend")
+}
+
+// Tests that a burst of SIGHUP signals received while the server is stopping for a reload causes a
+// single restart.
+func Test_waitForSignal_CoalescesSIGHUP(t *testing.T) {
+	signalChan := make(chan os.Signal, 4)
+	server := testHelpers.NewServerMock()
+	server.stopAction = func() {
+		for i := 0; i < 3; i++ {
+			signalChan <- syscall.SIGHUP
+		}
+	}
+	signalChan <- syscall.SIGHUP
+
+	exit, code := waitForSignal(signalChan, server)
+	assert.False(t, exit, "waitForSignal() exited on SIGHUP")
+	assert.Equal(t, code, 0, "waitForSignal() returned an unexpected exit code")
+	assert.Equal(t, server.Stops, 1, "waitForSignal() stopped the server an unexpected number of times")
+	assert.Equal(t, len(signalChan), 0, "waitForSignal() left SIGHUP signals to cause further restarts")
+}
+
+// Tests that an interrupt received while the server is stopping for a reload shuts it down.
+func Test_waitForSignal_InterruptDuringReload(t *testing.T) {
+	signalChan := make(chan os.Signal, 4)
+	server := testHelpers.NewServerMock()
+	server.stopAction = func() {
+		signalChan <- syscall.SIGHUP
+		signalChan <- os.Interrupt
+	}
+	signalChan <- syscall.SIGHUP
+
+	exit, code := waitForSignal(signalChan, server)
+	assert.True(t, exit, "waitForSignal() did not exit on an interrupt received during a reload")
+	assert.Equal(t, code, 0, "waitForSignal() returned an unexpected exit code")
+	assert.Equal(t, server.Stops, 1, "waitForSignal() stopped the server an unexpected number of times")
+}
+
+// starts a server whose HAProxy reloads take the given number of seconds, saves a backend through
+// it, and waits for the resulting reload to start; the server's config and the path of the file
+// touched once the reload completes are returned, along with a function that cleans up
+func startServerDuringSync(t *testing.T, timeout time.Duration, reloadSecs string) (Server, *Config, string, func()) {
+	dbPath := testHelpers.DBPath(t)
+
+	// find a free port for the server to listen on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.EnsureNil(t, err, "net.Listen() returned an unexpected error: %v", err)
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	l.Close()
+
+	started := filepath.Join(dbPath, "reload-started")
+	reloaded := filepath.Join(dbPath, "reloaded")
+	config := &Config{
+		Port:            port,
+		DBPath:          dbPath,
+		HAConfigPath:    filepath.Join(dbPath, "haproxy.cfg"),
+		HAReloadCommand: fmt.Sprintf("touch %s && sleep %s && touch %s", started, reloadSecs, reloaded),
+	}
+
+	dbMgr, err := NewDBManager(config)
+	assert.EnsureNil(t, err, "NewDBManager() returned an unexpected error: %v", err)
+	cleanup := func() {
+		closeDB(dbMgr)
+		os.RemoveAll(dbPath)
+	}
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	server := NewServer(timeout, make(chan os.Signal, 1))
+	go server.Run(config, dbMgr, tmpl)
+
+	// save a backend, which syncs HAProxy using the slow reload command
+	b := bsData.OneBackend()
+	go func() {
+		body := strings.NewReader(fmt.Sprintf(`{"name":"%s"}`, b.Name))
+		for i := 0; i < 100; i++ {
+			req, _ := http.NewRequest("PUT", "http://127.0.0.1:"+port+"/backends/"+b.Name, body)
+			if _, err := http.DefaultClient.Do(req); err == nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	// wait until the reload is underway
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			cleanup()
+			t.Fatal("the HAProxy reload did not start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return server, config, reloaded, cleanup
+}
+
+// Tests that stopping the server waits for an in-progress HAProxy sync to complete.
+func Test_Server_Stop_DuringSync(t *testing.T) {
+	server, config, reloaded, cleanup := startServerDuringSync(t, 5*time.Second, "0.5")
+	defer cleanup()
+	server.Stop()
+
+	_, err := os.Stat(reloaded)
+	assert.Nil(t, err, "Server.Stop() returned before the in-progress HAProxy reload completed")
+	c, err := ioutil.ReadFile(config.HAConfigPath)
+	assert.EnsureNil(t, err, "Server.Stop() returned before the HAProxy config file was written: %v", err)
+	assert.True(t, strings.Contains(string(c), "backend "+bsData.OneBackend().Name), "the HAProxy config file is missing the saved backend:\n%s", c)
+}
+
+// Tests that stopping the server waits no longer than its timeout for an in-progress HAProxy sync.
+func Test_Server_Stop_Timeout(t *testing.T) {
+	server, _, reloaded, cleanup := startServerDuringSync(t, 50*time.Millisecond, "1")
+	defer cleanup()
+
+	start := time.Now()
+	server.Stop()
+	assert.True(t, time.Since(start) < 900*time.Millisecond, "Server.Stop() waited longer than its timeout")
+
+	_, err := os.Stat(reloaded)
+	assert.True(t, os.IsNotExist(err), "Server.Stop() waited for the in-progress HAProxy reload to complete")
+}
+
+// Tests that the BackendLimitWarning() wrapper adds a Warning header to successful responses only
+// while the backend count exceeds the soft limit.
+func Test_BackendLimitWarning(t *testing.T) {
+	svc := NewDataSvc(testHelpers.NewDatastoreMock(), testHelpers.NewHAProxyMock(), &Config{BackendSoftLimit: 1})
+	enc := JSONEncoder{}
+	h := BackendLimitWarning(svc)(Handle(enc, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return PutBackend(w, r, enc, svc, Params{"name": mux.Vars(r)["name"]})
+	}))
+	router := mux.NewRouter()
+	router.HandleFunc("/backends/{name}", h).Methods("PUT")
+
+	put := func(name string, body string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		r, _ := http.NewRequest("PUT", "/backends/"+name, strings.NewReader(body))
+		router.ServeHTTP(rw, r)
+		return rw
+	}
+
+	rw := put("one", `{"balance":"roundrobin"}`)
+	assert.Equal(t, rw.Code, http.StatusCreated, "PUT /backends/{name} returned unexpected status code")
+	assert.Equal(t, rw.Header().Get("Warning"), "", "BackendLimitWarning() warned about a backend within the soft limit")
+
+	rw = put("two", `{"balance":"roundrobin"}`)
+	assert.Equal(t, rw.Code, http.StatusCreated, "PUT /backends/{name} returned unexpected status code")
+	assert.Equal(t, rw.Header().Get("Warning"), `199 - "there are 2 backends, more than the soft limit of 1"`,
+		"BackendLimitWarning() failed to warn about a backend beyond the soft limit")
+
+	// failed saves aren't warned about
+	rw = put("three", `{"reloadStrategy":"bogus"}`)
+	assert.Equal(t, rw.Code, http.StatusBadRequest, "PUT /backends/{name} returned unexpected status code")
+	assert.Equal(t, rw.Header().Get("Warning"), "", "BackendLimitWarning() warned about a failed save")
+}
+
+// Tests that the LoggerMiddleware() middleware logs the forwarded client IP only when proxy headers
+// are trusted.
+func Test_LoggerMiddleware(t *testing.T) {
+	testCases := []struct {
+		Trust   bool
+		Headers map[string]string
+		ExpIP   string
+	}{
+		{Trust: false, Headers: nil, ExpIP: "10.0.0.1"},
+		{Trust: false, Headers: map[string]string{"X-Forwarded-For": "192.168.1.5"}, ExpIP: "10.0.0.1"},
+		{Trust: false, Headers: map[string]string{"X-Real-IP": "192.168.1.6"}, ExpIP: "10.0.0.1"},
+		{Trust: true, Headers: nil, ExpIP: "10.0.0.1"},
+		{Trust: true, Headers: map[string]string{"X-Forwarded-For": "192.168.1.5, 10.0.0.2"}, ExpIP: "192.168.1.5"},
+		{Trust: true, Headers: map[string]string{"X-Real-IP": "192.168.1.6"}, ExpIP: "192.168.1.6"},
+		{Trust: true, Headers: map[string]string{"X-Forwarded-For": "192.168.1.5", "X-Real-IP": "192.168.1.6"}, ExpIP: "192.168.1.5"},
+	}
+
+	for _, testCase := range testCases {
+		var buf bytes.Buffer
+		mw := LoggerMiddleware(log.New(&buf, "", 0), testCase.Trust)
+		r, _ := http.NewRequest("GET", "/backends", nil)
+		r.RemoteAddr = "10.0.0.1:51234"
+		for k, v := range testCase.Headers {
+			r.Header.Set(k, v)
+		}
+		called := false
+		mw(httptest.NewRecorder(), r, func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		})
+
+		assert.True(t, called, "LoggerMiddleware() failed to call the next handler")
+		assert.StringContains(t, buf.String(), "Started GET /backends from "+testCase.ExpIP+"\n",
+			"LoggerMiddleware() logged an unexpected client IP (trusted: %t, headers: %v)", testCase.Trust, testCase.Headers)
+	}
+}
@@ -4,17 +4,61 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
 )
 
 // Config stores configuration information.
 type Config struct {
-	Port            string `json:"port"`
-	HAConfigPath    string `json:"haconfig"`
-	HATemplatePath  string `json:"hatemplate"`
-	HAReloadCommand string `json:"hareload"`
-	DBPath          string `json:"db-path"`
+	Port              string `json:"port" toml:"port"`
+	HAConfigPath      string `json:"haconfig" toml:"haconfig"`
+	HATemplatePath    string `json:"hatemplate" toml:"hatemplate"`
+	HAReloadCommand   string `json:"hareload" toml:"hareload"`
+	DBPath            string `json:"db-path" toml:"db-path"`
+	ReloadStrategy    string `json:"reload-strategy" toml:"reload-strategy"`
+	HASocketPath      string `json:"hasocket" toml:"hasocket"`
+	HAStatsSocketPath string `json:"hastats-socket" toml:"hastats-socket"`
+	KeyNamespace      string `json:"key-namespace" toml:"key-namespace"`
+
+	DisabledEndpoints        []string            `json:"disabled-endpoints" toml:"disabled-endpoints"`
+	PreserveUnknownFields    bool                `json:"preserve-unknown-fields" toml:"preserve-unknown-fields"`
+	GETCacheMaxAge           int                 `json:"get-cache-max-age" toml:"get-cache-max-age"`
+	SyncOnStartup            bool                `json:"sync-on-startup" toml:"sync-on-startup"`
+	ReloadQueueSize          int                 `json:"reload-queue-size" toml:"reload-queue-size"`
+	ValidateReloadCommand    bool                `json:"validate-reload-command" toml:"validate-reload-command"`
+	DrainWait                string              `json:"drain-wait" toml:"drain-wait"`
+	JSONFieldStyle           string              `json:"json-field-style" toml:"json-field-style"`
+	DefaultMode              string              `json:"default-mode" toml:"default-mode"`
+	ConfigBackupCount        int                 `json:"config-backup-count" toml:"config-backup-count"`
+	StrictSync               bool                `json:"strict-sync" toml:"strict-sync"`
+	ShutdownTimeout          string              `json:"shutdown-timeout" toml:"shutdown-timeout"`
+	BlockWritesOnDrift       bool                `json:"block-writes-on-drift" toml:"block-writes-on-drift"`
+	NormalizeNames           bool                `json:"normalize-names" toml:"normalize-names"`
+	RoutePrefix              string              `json:"route-prefix" toml:"route-prefix"`
+	AllowedOptions           []string            `json:"allowed-options" toml:"allowed-options"`
+	ConfigHeader             bool                `json:"config-header" toml:"config-header"`
+	ExpirySweepInterval      string              `json:"expiry-sweep-interval" toml:"expiry-sweep-interval"`
+	PostReloadCommand        string              `json:"post-reload-command" toml:"post-reload-command"`
+	UniqueNamesAcrossTypes   bool                `json:"unique-names-across-types" toml:"unique-names-across-types"`
+	MaxNameLength            int                 `json:"max-name-length" toml:"max-name-length"`
+	ValidateDBPath           bool                `json:"validate-db-path" toml:"validate-db-path"`
+	InitEmptyConfig          bool                `json:"init-empty-config" toml:"init-empty-config"`
+	DedupeReloads            bool                `json:"dedupe-reloads" toml:"dedupe-reloads"`
+	TrustProxyHeaders        bool                `json:"trust-proxy-headers" toml:"trust-proxy-headers"`
+	ReconcileOnOutOfSync     bool                `json:"reconcile-on-out-of-sync" toml:"reconcile-on-out-of-sync"`
+	VerifyConfig             bool                `json:"verify-config" toml:"verify-config"`
+	HAReloadArgv             []string            `json:"hareload-argv" toml:"hareload-argv"`
+	BackendSoftLimit         int                 `json:"backend-soft-limit" toml:"backend-soft-limit"`
+	WarnSingleMemberBackends bool                `json:"warn-single-member-backends" toml:"warn-single-member-backends"`
+	ModeDefaults             map[string][]string `json:"mode-defaults" toml:"mode-defaults"`
 }
 
 // GetConfig retrieves configuration information for the application.
@@ -25,6 +69,12 @@ func GetConfig() (*Config, []error) {
 		HATemplatePath:  "haproxy.tmpl",
 		HAReloadCommand: "service haproxy reload",
 		DBPath:          "/var/db/conduit",
+		ReloadStrategy:  reloadStrategyCommand,
+		ReloadQueueSize: defaultReloadQueueSize,
+		DefaultMode:     modeHTTP,
+		ShutdownTimeout: defaultTimeout,
+		NormalizeNames:  true,
+		MaxNameLength:   defaultMaxNameLength,
 	}
 
 	port := flag.String("port", "", "port the rest server will listen on")
@@ -32,6 +82,40 @@ func GetConfig() (*Config, []error) {
 	hatemplate := flag.String("hatemplate", "", "the path to the haproxy config template file")
 	hareload := flag.String("hareload", "", "the command to execute to reload HAProxy config")
 	dbPath := flag.String("db-path", "", "Location to read or create database files")
+	reloadStrategy := flag.String("reload-strategy", "", "how to reload HAProxy: command or socket")
+	haSocket := flag.String("hasocket", "", "the path to the HAProxy master/admin socket")
+	haStatsSocket := flag.String("hastats-socket", "", "the path to the HAProxy stats socket, if not hasocket")
+	keyNamespace := flag.String("key-namespace", "", "namespace prepended to all database keys")
+	disabledEndpoints := flag.String("disabled-endpoints", "", "comma-separated list of endpoint path patterns to disable")
+	preserveUnknown := flag.Bool("preserve-unknown-fields", false, "store unrecognized JSON fields in the meta map")
+	getCacheMaxAge := flag.Int("get-cache-max-age", 0, "max-age in seconds of the Cache-Control header on GET responses")
+	syncOnStartup := flag.Bool("sync-on-startup", false, "write the haproxy config file from the database on startup")
+	reloadQueueSize := flag.Int("reload-queue-size", 0, "maximum number of pending haproxy reloads")
+	validateReload := flag.Bool("validate-reload-command", false, "verify on startup that the hareload command exists")
+	drainWait := flag.String("drain-wait", "", "how long to let old members drain when swapping backend members")
+	jsonFieldStyle := flag.String("json-field-style", "", "naming style of JSON keys: camelCase or snake_case")
+	defaultMode := flag.String("default-mode", "", "mode applied to frontends and backends saved without one")
+	configBackupCount := flag.Int("config-backup-count", 0, "number of timestamped haproxy config backups to keep")
+	strictSync := flag.Bool("strict-sync", false, "roll back changes that HAProxy fails to reload")
+	shutdownTimeout := flag.String("shutdown-timeout", "", "how long to wait for in-progress HAProxy syncs when stopping")
+	blockWritesOnDrift := flag.Bool("block-writes-on-drift", false, "refuse changes while the haproxy config file differs from the database")
+	normalizeNames := flag.Bool("normalize-names", true, "replace spaces in frontend and backend names with underscores")
+	routePrefix := flag.String("route-prefix", "", "base path under which all endpoints are served")
+	allowedOptions := flag.String("allowed-options", "", "comma-separated list of the frontend option values that may be set")
+	configHeader := flag.Bool("config-header", false, "start the haproxy config file with a comment noting when Conduit generated it")
+	expirySweepInterval := flag.String("expiry-sweep-interval", "", "how often to delete the frontends and backends that have expired")
+	postReloadCommand := flag.String("post-reload-command", "", "a command to execute after each successful haproxy reload")
+	uniqueNames := flag.Bool("unique-names-across-types", false, "reject a frontend or backend name already used by the other type")
+	maxNameLength := flag.Int("max-name-length", 0, "maximum length of frontend and backend names")
+	validateDBPath := flag.Bool("validate-db-path", false, "verify on startup that the db-path directory is writable")
+	initEmptyConfig := flag.Bool("init-empty-config", false, "create the haproxy config file on startup if it doesn't exist")
+	dedupeReloads := flag.Bool("dedupe-reloads", false, "skip the haproxy reload when a change renders the same config as the last one")
+	trustProxyHeaders := flag.Bool("trust-proxy-headers", false, "log the client ip from the X-Forwarded-For or X-Real-IP header")
+	reconcileOnOutOfSync := flag.Bool("reconcile-on-out-of-sync", false, "rewrite the haproxy config from the database when a rollback fails")
+	verifyConfig := flag.Bool("verify-config", false, "verify the haproxy config file after each reload")
+	haReloadArgv := flag.String("hareload-argv", "", "comma-separated command and arguments to reload haproxy without a shell")
+	backendSoftLimit := flag.Int("backend-soft-limit", 0, "number of backends above which saves are warned about")
+	warnSingleMemberBackends := flag.Bool("warn-single-member-backends", false, "warn when a backend is saved with a single member")
 	file := flag.String("f", "", "config file")
 	flag.Parse()
 
@@ -58,6 +142,108 @@ func GetConfig() (*Config, []error) {
 	if *dbPath != "" {
 		config.DBPath = *dbPath
 	}
+	if *reloadStrategy != "" {
+		config.ReloadStrategy = *reloadStrategy
+	}
+	if *haSocket != "" {
+		config.HASocketPath = *haSocket
+	}
+	if *haStatsSocket != "" {
+		config.HAStatsSocketPath = *haStatsSocket
+	}
+	if *keyNamespace != "" {
+		config.KeyNamespace = *keyNamespace
+	}
+	if *disabledEndpoints != "" {
+		config.DisabledEndpoints = strings.Split(*disabledEndpoints, ",")
+	}
+	if *preserveUnknown {
+		config.PreserveUnknownFields = true
+	}
+	if *getCacheMaxAge != 0 {
+		config.GETCacheMaxAge = *getCacheMaxAge
+	}
+	if *syncOnStartup {
+		config.SyncOnStartup = true
+	}
+	if *reloadQueueSize != 0 {
+		config.ReloadQueueSize = *reloadQueueSize
+	}
+	if *validateReload {
+		config.ValidateReloadCommand = true
+	}
+	if *drainWait != "" {
+		config.DrainWait = *drainWait
+	}
+	if *jsonFieldStyle != "" {
+		config.JSONFieldStyle = *jsonFieldStyle
+	}
+	if *defaultMode != "" {
+		config.DefaultMode = *defaultMode
+	}
+	if *configBackupCount != 0 {
+		config.ConfigBackupCount = *configBackupCount
+	}
+	if *strictSync {
+		config.StrictSync = true
+	}
+	if *shutdownTimeout != "" {
+		config.ShutdownTimeout = *shutdownTimeout
+	}
+	if *blockWritesOnDrift {
+		config.BlockWritesOnDrift = true
+	}
+	if !*normalizeNames {
+		config.NormalizeNames = false
+	}
+	if *routePrefix != "" {
+		config.RoutePrefix = *routePrefix
+	}
+	if *allowedOptions != "" {
+		config.AllowedOptions = strings.Split(*allowedOptions, ",")
+	}
+	if *configHeader {
+		config.ConfigHeader = true
+	}
+	if *expirySweepInterval != "" {
+		config.ExpirySweepInterval = *expirySweepInterval
+	}
+	if *postReloadCommand != "" {
+		config.PostReloadCommand = *postReloadCommand
+	}
+	if *uniqueNames {
+		config.UniqueNamesAcrossTypes = true
+	}
+	if *maxNameLength != 0 {
+		config.MaxNameLength = *maxNameLength
+	}
+	if *validateDBPath {
+		config.ValidateDBPath = true
+	}
+	if *initEmptyConfig {
+		config.InitEmptyConfig = true
+	}
+	if *dedupeReloads {
+		config.DedupeReloads = true
+	}
+	if *trustProxyHeaders {
+		config.TrustProxyHeaders = true
+	}
+	if *reconcileOnOutOfSync {
+		config.ReconcileOnOutOfSync = true
+	}
+	if *verifyConfig {
+		config.VerifyConfig = true
+	}
+	if *haReloadArgv != "" {
+		config.HAReloadArgv = strings.Split(*haReloadArgv, ",")
+	}
+	if *backendSoftLimit != 0 {
+		config.BackendSoftLimit = *backendSoftLimit
+	}
+	if *warnSingleMemberBackends {
+		config.WarnSingleMemberBackends = true
+	}
 
 	// validate the loaded config values
 	if errs := validateConfig(config); errs != nil {
@@ -68,21 +254,25 @@ func GetConfig() (*Config, []error) {
 }
 
 // readConfigFile reads config information into the given config instance from the specified configuration file.
-func readConfigFile(path string, config *Config) error {
-	file, err := os.Open(path)
+// Files with a .toml extension are decoded as TOML; all other files are decoded as JSON.
+func readConfigFile(filePath string, config *Config) error {
+	file, err := os.Open(filePath)
 	defer file.Close()
 	if os.IsNotExist(err) {
-		return fmt.Errorf("Config file '%s' does not exist", path)
+		return fmt.Errorf("Config file '%s' does not exist", filePath)
 	}
 
 	if err == nil {
-		decoder := json.NewDecoder(file)
-		err = decoder.Decode(config)
+		if strings.ToLower(path.Ext(filePath)) == ".toml" {
+			_, err = toml.DecodeReader(file, config)
+		} else {
+			err = json.NewDecoder(file).Decode(config)
+		}
 		if err == nil {
 			return nil
 		}
 	}
-	return fmt.Errorf("Unable to load config file '%s': %v", path, err)
+	return fmt.Errorf("Unable to load config file '%s': %v", filePath, err)
 }
 
 // validateConfig determines if the given Config instance contains valid values.
@@ -111,11 +301,145 @@ func validateConfig(config *Config) []error {
 	// validate reload
 	if config.HAReloadCommand == "" {
 		errs = append(errs, fmt.Errorf("a hareload value is required"))
+	} else if config.ValidateReloadCommand && config.ReloadStrategy != reloadStrategySocket && len(config.HAReloadArgv) == 0 {
+		if err := checkReloadCommand("hareload command", config.HAReloadCommand); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(config.HAReloadArgv) > 0 {
+		if config.HAReloadArgv[0] == "" {
+			errs = append(errs, fmt.Errorf("hareload-argv is invalid - the first element must be the executable"))
+		} else if config.ValidateReloadCommand && config.ReloadStrategy != reloadStrategySocket {
+			if _, err := exec.LookPath(config.HAReloadArgv[0]); err != nil {
+				errs = append(errs, fmt.Errorf("hareload-argv is invalid - executable '%s' was not found", config.HAReloadArgv[0]))
+			}
+		}
+	}
+	if config.ValidateReloadCommand && config.PostReloadCommand != "" {
+		if err := checkReloadCommand("post-reload-command", config.PostReloadCommand); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	// validate db-path
 	if config.DBPath == "" {
 		errs = append(errs, fmt.Errorf("a database path value is required"))
+	} else if config.ValidateDBPath {
+		if err := checkDBPath(config.DBPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// validate reload-strategy
+	switch config.ReloadStrategy {
+	case "", reloadStrategyCommand:
+	case reloadStrategySocket:
+		if config.HASocketPath == "" {
+			errs = append(errs, fmt.Errorf("an hasocket value is required when reload-strategy is '%s'", reloadStrategySocket))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("reload-strategy value '%s' is invalid - must be '%s' or '%s'",
+			config.ReloadStrategy, reloadStrategyCommand, reloadStrategySocket))
+	}
+
+	// validate get-cache-max-age
+	if config.GETCacheMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("get-cache-max-age value '%d' is invalid - must not be negative", config.GETCacheMaxAge))
+	}
+
+	// validate reload-queue-size
+	if config.ReloadQueueSize < 0 {
+		errs = append(errs, fmt.Errorf("reload-queue-size value '%d' is invalid - must not be negative", config.ReloadQueueSize))
+	}
+
+	// validate config-backup-count
+	if config.ConfigBackupCount < 0 {
+		errs = append(errs, fmt.Errorf("config-backup-count value '%d' is invalid - must not be negative", config.ConfigBackupCount))
+	}
+
+	// validate max-name-length
+	if config.MaxNameLength < 0 {
+		errs = append(errs, fmt.Errorf("max-name-length value '%d' is invalid - must not be negative", config.MaxNameLength))
+	}
+
+	// validate backend-soft-limit
+	if config.BackendSoftLimit < 0 {
+		errs = append(errs, fmt.Errorf("backend-soft-limit value '%d' is invalid - must not be negative", config.BackendSoftLimit))
+	}
+
+	// validate drain-wait
+	if config.DrainWait != "" {
+		if d, err := time.ParseDuration(config.DrainWait); err != nil || d < 0 {
+			errs = append(errs, fmt.Errorf("drain-wait value '%s' is invalid - must be a duration such as '30s'", config.DrainWait))
+		}
+	}
+
+	// validate expiry-sweep-interval
+	if config.ExpirySweepInterval != "" {
+		if d, err := time.ParseDuration(config.ExpirySweepInterval); err != nil || d < 0 {
+			errs = append(errs, fmt.Errorf("expiry-sweep-interval value '%s' is invalid - must be a duration such as '1m'", config.ExpirySweepInterval))
+		}
+	}
+
+	// validate shutdown-timeout
+	if config.ShutdownTimeout != "" {
+		if d, err := time.ParseDuration(config.ShutdownTimeout); err != nil || d < 0 {
+			errs = append(errs, fmt.Errorf("shutdown-timeout value '%s' is invalid - must be a duration such as '5s'", config.ShutdownTimeout))
+		}
+	}
+
+	// validate json-field-style
+	switch config.JSONFieldStyle {
+	case "", fieldStyleCamel, fieldStyleSnake:
+	default:
+		errs = append(errs, fmt.Errorf("json-field-style value '%s' is invalid - must be '%s' or '%s'",
+			config.JSONFieldStyle, fieldStyleCamel, fieldStyleSnake))
+	}
+
+	// validate default-mode
+	switch config.DefaultMode {
+	case "", modeHTTP, modeTCP, modeHealth:
+	default:
+		errs = append(errs, fmt.Errorf("default-mode value '%s' is invalid - must be '%s', '%s', or '%s'",
+			config.DefaultMode, modeHTTP, modeTCP, modeHealth))
+	}
+
+	// validate mode-defaults
+	for mode, lines := range config.ModeDefaults {
+		switch mode {
+		case modeHTTP, modeTCP, modeHealth:
+		default:
+			errs = append(errs, fmt.Errorf("mode-defaults mode '%s' is invalid - must be '%s', '%s', or '%s'",
+				mode, modeHTTP, modeTCP, modeHealth))
+		}
+		for _, l := range lines {
+			if strings.TrimSpace(l) == "" || strings.ContainsAny(l, "\r\n") {
+				errs = append(errs, fmt.Errorf("mode-defaults line '%s' for mode '%s' is invalid - must be a single non-empty line", l, mode))
+			}
+		}
+	}
+
+	// validate route-prefix
+	if config.RoutePrefix != "" && (!strings.HasPrefix(config.RoutePrefix, "/") || strings.HasSuffix(config.RoutePrefix, "/")) {
+		errs = append(errs, fmt.Errorf("route-prefix value '%s' is invalid - must begin with '/' and not end with '/'", config.RoutePrefix))
+	}
+
+	// validate key-namespace; a namespace containing '/' or named like one of the key prefixes would
+	// let its keys be read as those of the unnamespaced store
+	if strings.Contains(config.KeyNamespace, "/") {
+		errs = append(errs, fmt.Errorf("key-namespace value '%s' is invalid - must not contain '/'", config.KeyNamespace))
+	} else {
+		switch config.KeyNamespace {
+		case "frontend", "backend", "haproxy":
+			errs = append(errs, fmt.Errorf("key-namespace value '%s' is invalid - '%s' is a reserved key prefix", config.KeyNamespace, config.KeyNamespace))
+		}
+	}
+
+	// validate disabled-endpoints
+	for _, pattern := range config.DisabledEndpoints {
+		if _, err := path.Match(pattern, "/"); err != nil {
+			errs = append(errs, fmt.Errorf("disabled-endpoints pattern '%s' is invalid", pattern))
+		}
 	}
 
 	if len(errs) > 0 {
@@ -123,3 +447,42 @@ func validateConfig(config *Config) []error {
 	}
 	return nil
 }
+
+// checkDBPath determines if the database can be created or opened at the given path, i.e. that the
+// path is a writable directory or, if it doesn't exist yet, that its parent is one.
+func checkDBPath(dbPath string) error {
+	dir := dbPath
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		dir = filepath.Dir(dbPath)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("db-path '%s' is invalid - the directory '%s' does not exist or can't be read", dbPath, dir)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("db-path '%s' is invalid - '%s' is not a directory", dbPath, dir)
+	}
+	f, err := ioutil.TempFile(dir, ".conduit-check")
+	if err != nil {
+		return fmt.Errorf("db-path '%s' is invalid - the directory '%s' is not writable", dbPath, dir)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+// checkReloadCommand determines if the executable of the given shell command can be found; any
+// leading environment variable assignments (e.g. "FOO=bar") are skipped. The label names the
+// setting in the returned error.
+func checkReloadCommand(label, cmd string) error {
+	for _, field := range strings.Fields(cmd) {
+		if strings.Contains(field, "=") {
+			continue
+		}
+		if _, err := exec.LookPath(field); err != nil {
+			return fmt.Errorf("%s '%s' is invalid - executable '%s' was not found", label, cmd, field)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s '%s' is invalid - no executable was specified", label, cmd)
+}
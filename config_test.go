@@ -3,7 +3,10 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
 	"testing"
 )
 
@@ -44,6 +47,16 @@ func getConfigTestCases() []configTestCase {
 			ExpDBPath:        "/var/db/conduit",
 			CommandLineFlags: []string{},
 		},
+		{
+			Name:             "GetConfigFromTOMLFile",
+			ConfigPath:       "test-fixtures/config.toml",
+			ExpPort:          "3000",
+			ExpHAConfig:      "test-fixtures/haproxy.cfg",
+			ExpHATemplate:    "text-fixtures/template.txt",
+			ExpHAReload:      "reload haproxy",
+			ExpDBPath:        "/var/db/conduit",
+			CommandLineFlags: []string{},
+		},
 		{
 			Name:          "GetConfigWithFlags",
 			ConfigPath:    "",
@@ -116,6 +129,34 @@ func Test_GetConfig(t *testing.T) {
 	}
 }
 
+// Tests that the readConfigFile() function resolves the same values from equivalent JSON and TOML
+// config files.
+func Test_readConfigFile_TOML(t *testing.T) {
+	jsonConfig := &Config{}
+	err := readConfigFile("test-fixtures/config.json", jsonConfig)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	tomlConfig := &Config{}
+	err = readConfigFile("test-fixtures/config.toml", tomlConfig)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	assert.Equal(t, tomlConfig, jsonConfig, "readConfigFile() resolved different values from the TOML file")
+}
+
+// Tests that the readConfigFile() function returns an error for a malformed TOML file.
+func Test_readConfigFile_TOMLInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conduit-config")
+	assert.EnsureNil(t, err, "ioutil.TempDir() returned an unexpected error: %v", err)
+	defer os.RemoveAll(dir)
+
+	filePath := path.Join(dir, "config.toml")
+	err = ioutil.WriteFile(filePath, []byte("port = 3000 3000\n"), 0644)
+	assert.EnsureNil(t, err, "ioutil.WriteFile() returned an unexpected error: %v", err)
+
+	err = readConfigFile(filePath, &Config{})
+	assert.NotNil(t, err, "readConfigFile() failed to return an error for a malformed TOML file")
+}
+
 // ----------------------------------------------
 // validateConfig TESTS
 // ----------------------------------------------
@@ -147,3 +188,278 @@ func Test_validateConfig_InvalidValues(t *testing.T) {
 	errs = validateConfig(config)
 	assert.EnsureEqual(t, len(errs), 5, "validateConfig() returned unexpected error count")
 }
+
+// Tests that the validateConfig() function properly validates the reload strategy values.
+func Test_validateConfig_ReloadStrategy(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	config.ReloadStrategy = reloadStrategyCommand
+	errs := validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+
+	config.ReloadStrategy = reloadStrategySocket
+	errs = validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 1, "validateConfig() should require hasocket for the socket strategy")
+
+	config.HASocketPath = "/var/run/haproxy-master.sock"
+	errs = validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+
+	config.ReloadStrategy = "restart"
+	errs = validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject an unknown reload strategy")
+}
+
+// Tests that the validateConfig() function rejects malformed disabled endpoint patterns.
+func Test_validateConfig_DisabledEndpoints(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	config.DisabledEndpoints = []string{"/restart", "/haproxy/*"}
+	errs := validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+
+	config.DisabledEndpoints = []string{"/haproxy/[reload"}
+	errs = validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject a malformed endpoint pattern")
+}
+
+// Tests that the validateConfig() function rejects mode defaults for unknown modes or with lines
+// that aren't a single line.
+func Test_validateConfig_ModeDefaults(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	config.ModeDefaults = map[string][]string{"http": {"timeout client 30s"}, "tcp": {"timeout client 1h"}}
+	errs := validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+
+	config.ModeDefaults = map[string][]string{"udp": {"timeout client 30s"}}
+	errs = validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject mode defaults for an unknown mode")
+
+	config.ModeDefaults = map[string][]string{"http": {"", "timeout client 30s\nbind *:81"}}
+	errs = validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 2, "validateConfig() should reject empty and multi-line mode defaults")
+}
+
+// Tests that the validateConfig() function checks that the reload command exists when requested.
+func Test_validateConfig_ValidateReloadCommand(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	// the command is not checked unless validation is enabled
+	config.HAReloadCommand = "conduit-missing-command reload"
+	errs := validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+
+	config.ValidateReloadCommand = true
+	errs = validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject a missing reload command")
+	assert.StringContains(t, errs[0].Error(), "hareload command 'conduit-missing-command reload' is invalid", "validateConfig() returned unexpected error message")
+
+	config.HAReloadCommand = "sh -c 'exit 0'"
+	errs = validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+
+	config.HAReloadCommand = "HAPROXY_OPTS=-q sh -c 'exit 0'"
+	errs = validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+
+	// the reload argv is checked instead of the command when it's set
+	config.HAReloadCommand = "conduit-missing-command reload"
+	config.HAReloadArgv = []string{"sh", "-c", "exit 0"}
+	errs = validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+
+	config.HAReloadArgv = []string{"conduit-missing-command", "reload"}
+	errs = validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject a missing reload argv executable")
+
+	config.ValidateReloadCommand = false
+	config.HAReloadArgv = []string{"", "reload"}
+	errs = validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject a reload argv without an executable")
+}
+
+// Tests that the validateConfig() function checks the post-reload command, naming it in the error.
+func Test_validateConfig_ValidatePostReloadCommand(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+	config.HAReloadCommand = "sh -c 'exit 0'"
+
+	// the command is not checked unless validation is enabled
+	config.PostReloadCommand = "conduit-missing-command notify"
+	errs := validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+
+	config.ValidateReloadCommand = true
+	errs = validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject a missing post-reload command")
+	assert.StringContains(t, errs[0].Error(), "post-reload-command 'conduit-missing-command notify' is invalid", "validateConfig() returned unexpected error message")
+
+	config.PostReloadCommand = "sh -c 'exit 0'"
+	errs = validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+}
+
+// Tests that the validateConfig() function checks that the database path is writable when requested.
+func Test_validateConfig_ValidateDBPath(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	dir, err := ioutil.TempDir("", "conduit-db-path")
+	assert.EnsureNil(t, err, "ioutil.TempDir() returned an unexpected error: %v", err)
+	defer os.RemoveAll(dir)
+
+	// the path is not checked unless validation is enabled
+	config.DBPath = filepath.Join(dir, "missing", "db")
+	errs := validateConfig(config)
+	assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors: %v", errs)
+
+	config.ValidateDBPath = true
+	for _, dbPath := range []string{dir, filepath.Join(dir, "db")} {
+		config.DBPath = dbPath
+		errs = validateConfig(config)
+		assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors for db-path '%s': %v", dbPath, errs)
+	}
+
+	file := filepath.Join(dir, "file")
+	assert.EnsureNil(t, ioutil.WriteFile(file, []byte{}, 0644), "ioutil.WriteFile() returned an unexpected error")
+	for _, dbPath := range []string{filepath.Join(dir, "missing", "db"), filepath.Join(file, "db")} {
+		config.DBPath = dbPath
+		errs = validateConfig(config)
+		assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject the db-path '%s'", dbPath)
+		assert.StringContains(t, errs[0].Error(), "db-path '"+dbPath+"' is invalid", "validateConfig() returned an unclear error")
+	}
+
+	// permissions aren't enforced for root
+	if os.Geteuid() == 0 {
+		return
+	}
+	readOnly := filepath.Join(dir, "read-only")
+	assert.EnsureNil(t, os.Mkdir(readOnly, 0555), "os.Mkdir() returned an unexpected error")
+	config.DBPath = filepath.Join(readOnly, "db")
+	errs = validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject a db-path in a read-only directory")
+	assert.StringContains(t, errs[0].Error(), "is not writable", "validateConfig() returned an unclear error")
+}
+
+// Tests that the validateConfig() function properly validates the default mode values.
+func Test_validateConfig_DefaultMode(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	for _, mode := range []string{"", modeHTTP, modeTCP, modeHealth} {
+		config.DefaultMode = mode
+		errs := validateConfig(config)
+		assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors for mode '%s': %v", mode, errs)
+	}
+
+	config.DefaultMode = "udp"
+	errs := validateConfig(config)
+	assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject an unknown default mode")
+}
+
+// Tests that the validateConfig() function requires the route prefix to be a path without a trailing
+// slash.
+func Test_validateConfig_RoutePrefix(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	for _, prefix := range []string{"", "/conduit", "/api/conduit"} {
+		config.RoutePrefix = prefix
+		errs := validateConfig(config)
+		assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors for prefix '%s': %v", prefix, errs)
+	}
+
+	for _, prefix := range []string{"conduit", "/conduit/", "/"} {
+		config.RoutePrefix = prefix
+		errs := validateConfig(config)
+		assert.Equal(t, len(errs), 1, "validateConfig() should reject the route prefix '%s'", prefix)
+	}
+}
+
+// Tests that the validateConfig() function rejects key namespaces that would overlap other keys.
+func Test_validateConfig_KeyNamespace(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	for _, ns := range []string{"", "staging", "frontends"} {
+		config.KeyNamespace = ns
+		errs := validateConfig(config)
+		assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors for namespace '%s': %v", ns, errs)
+	}
+
+	for _, ns := range []string{"a/b", "/", "staging/", "frontend", "backend", "haproxy"} {
+		config.KeyNamespace = ns
+		errs := validateConfig(config)
+		assert.EnsureEqual(t, len(errs), 1, "validateConfig() should reject the key namespace '%s'", ns)
+		assert.StringContains(t, errs[0].Error(), "key-namespace value '"+ns+"' is invalid", "validateConfig() returned unexpected error message")
+	}
+}
+
+// Tests that the validateConfig() function requires the expiry sweep interval to be a duration.
+func Test_validateConfig_ExpirySweepInterval(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	for _, interval := range []string{"", "0s", "1m"} {
+		config.ExpirySweepInterval = interval
+		errs := validateConfig(config)
+		assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors for interval '%s': %v", interval, errs)
+	}
+
+	for _, interval := range []string{"1", "-1m", "soon"} {
+		config.ExpirySweepInterval = interval
+		errs := validateConfig(config)
+		assert.Equal(t, len(errs), 1, "validateConfig() should reject the expiry sweep interval '%s'", interval)
+	}
+}
+
+// Tests that the validateConfig() function rejects a negative maximum name length.
+func Test_validateConfig_MaxNameLength(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	for _, length := range []int{0, 1, defaultMaxNameLength} {
+		config.MaxNameLength = length
+		errs := validateConfig(config)
+		assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors for length %d: %v", length, errs)
+	}
+
+	config.MaxNameLength = -1
+	errs := validateConfig(config)
+	assert.Equal(t, len(errs), 1, "validateConfig() should reject a negative max name length")
+}
+
+// Tests that the validateConfig() function requires the shutdown timeout to be a duration.
+func Test_validateConfig_ShutdownTimeout(t *testing.T) {
+	config := &Config{}
+	err := readConfigFile("test-fixtures/config.json", config)
+	assert.EnsureNil(t, err, "readConfigFile() returned an unexpected error: %v", err)
+
+	for _, timeout := range []string{"", "0s", "30s", "1m30s"} {
+		config.ShutdownTimeout = timeout
+		errs := validateConfig(config)
+		assert.Empty(t, errs, "validateConfig() returned non-empty slice of errors for timeout '%s': %v", timeout, errs)
+	}
+
+	for _, timeout := range []string{"30", "-5s", "soon"} {
+		config.ShutdownTimeout = timeout
+		errs := validateConfig(config)
+		assert.Equal(t, len(errs), 1, "validateConfig() should reject the shutdown timeout '%s'", timeout)
+	}
+}
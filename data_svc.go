@@ -1,78 +1,337 @@
 package main
 
-import "strings"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const defaultMaxNameLength = 253
 
 // DataSvc represents a service that provided read/write access to HAProxy data.
 type DataSvc interface {
 	GetAllBackends() (Backends, *Error)
 	GetBackend(key string) (*Backend, *Error)
 	SaveBackend(f *Backend) *Error
+	CloneBackend(source, name, version string) (*Backend, *Error)
 	DeleteBackend(key string) *Error
+	DeleteBackendCascade(key string) *Error
+	SwapBackendMembers(name string, members BackendMembers) (*Backend, *Error)
+	HeartbeatMembers(beats []MemberHeartbeat) ([]MemberHeartbeat, *Error)
+	SaveBackendMemberMeta(name, member string, meta map[string]string) *Error
+	SetBackendMemberVersions(name, version string, backendToo bool) (*Backend, *Error)
+	BackendLimitWarning() string
+	SingleMemberWarning(b *Backend) string
 
 	GetAllFrontends() (Frontends, *Error)
 	GetFrontend(key string) (*Frontend, *Error)
 	SaveFrontend(f *Frontend) *Error
 	DeleteFrontend(key string) *Error
+
+	GetRaw(proxyType, key string) (*RawRecord, *Error)
+
+	GetTemplate() (string, *Error)
+	SaveTemplate(text string) *Error
+
+	SweepExpired(now time.Time) (int, *Error)
+
+	WithTransaction(fn func(tx DataSvc) error) *Error
+	Snapshot() (DataSvc, func(), *Error)
+	Sync() *Error
+	Drain()
 }
 
 type dataSvcImpl struct {
-	db Datastore
-	ha HAProxy
+	db          Datastore
+	ha          HAProxy
+	drainWait   time.Duration
+	sleep       func(time.Duration)
+	writes      *writeGuard
+	defaultMode string
+	strictSync  bool
+
+	// serializes the writes made outside of a transaction, so that a read-modify-write of a record
+	// can't be interleaved with, and so revert, another write
+	writeLock *sync.Mutex
+
+	// true when writes are refused while the HAProxy config file differs from the data store
+	blockWritesOnDrift bool
+
+	// true when spaces in names are replaced with underscores, rather than rejected
+	normalizeNames bool
+
+	// the frontend option values that may be set, or nil if any option may be set
+	allowedOptions []string
+
+	// true when an HAProxy master/admin socket is configured, so that backends may be reloaded over it
+	hasSocket bool
+
+	// the shell command executed after each successful HAProxy reload, or empty for none
+	postReloadCmd string
+
+	// true when a frontend and a backend may not share a name
+	uniqueNamesAcrossTypes bool
+
+	// the maximum length of frontend and backend names, or 0 for no limit
+	maxNameLength int
+
+	// true when a sync that renders the same config as the last one applied is skipped, along with
+	// the HAProxy reload
+	dedupeReloads bool
+	lastRender    *renderHash
+
+	// true when the HAProxy config is rewritten from the data store after a failed rollback
+	reconcileOnOutOfSync bool
+
+	// true when the config file is read back after each reload to check that it's the config written
+	verifyConfig bool
+
+	// the number of backends above which saves are warned about, or 0 for no limit
+	backendSoftLimit int
+
+	// true when saving a backend with a single member, and so no redundancy, is warned about
+	warnSingleMemberBackends bool
+
+	// true when the service is operating within a transaction, in which case HAProxy is not
+	// synced until the transaction commits
+	inTransaction bool
 }
 
 // NewDataSvc retrieves a new BackendSvc instance.
-func NewDataSvc(db Datastore, ha HAProxy) DataSvc {
-	return &dataSvcImpl{db: db, ha: ha}
+func NewDataSvc(db Datastore, ha HAProxy, config *Config) DataSvc {
+	drainWait, _ := time.ParseDuration(config.DrainWait)
+	return &dataSvcImpl{
+		db:          db,
+		ha:          ha,
+		drainWait:   drainWait,
+		sleep:       time.Sleep,
+		writes:      &writeGuard{},
+		defaultMode: config.DefaultMode,
+		strictSync:  config.StrictSync,
+
+		writeLock: &sync.Mutex{},
+
+		blockWritesOnDrift: config.BlockWritesOnDrift,
+		normalizeNames:     config.NormalizeNames,
+		allowedOptions:     config.AllowedOptions,
+		hasSocket:          config.HASocketPath != "",
+		postReloadCmd:      config.PostReloadCommand,
+
+		uniqueNamesAcrossTypes: config.UniqueNamesAcrossTypes,
+		maxNameLength:          config.MaxNameLength,
+
+		dedupeReloads: config.DedupeReloads,
+		lastRender:    &renderHash{},
+
+		reconcileOnOutOfSync: config.ReconcileOnOutOfSync,
+		verifyConfig:         config.VerifyConfig,
+		backendSoftLimit:     config.BackendSoftLimit,
+
+		warnSingleMemberBackends: config.WarnSingleMemberBackends,
+	}
+}
+
+// renderHash holds the hash of the last HAProxy config written and reloaded, so that a sync which
+// wouldn't change it can be skipped.
+type renderHash struct {
+	mu  sync.Mutex
+	sum string
 }
 
-// GetAllBackends returns all the backends in the system, or nil.
+// returns true if the given hash matches the last config applied
+func (h *renderHash) matches(sum string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return sum != "" && sum == h.sum
+}
+
+// records the hash of the config applied, or clears it when given an empty hash
+func (h *renderHash) set(sum string) {
+	h.mu.Lock()
+	h.sum = sum
+	h.mu.Unlock()
+}
+
+// writeGuard tracks the writes and HAProxy syncs in progress, so that they can be allowed to
+// complete before the server stops or restarts.
+type writeGuard struct {
+	mu       sync.RWMutex
+	draining bool
+}
+
+// registers the start of a write; an error is returned if the guard is draining
+func (g *writeGuard) enter() *Error {
+	g.mu.RLock()
+	if g.draining {
+		g.mu.RUnlock()
+		return NewErrorf(ErrSync, "the server is stopping - no further changes are accepted")
+	}
+	return nil
+}
+
+// registers the end of a write
+func (g *writeGuard) exit() {
+	g.mu.RUnlock()
+}
+
+// waits for the writes in progress to complete and rejects any further writes
+func (g *writeGuard) drain() {
+	g.mu.Lock()
+	g.draining = true
+	g.mu.Unlock()
+}
+
+// GetAllBackends returns all the backends in the system; if there are none, an empty list is
+// returned rather than nil.
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ds *dataSvcImpl) GetAllBackends() (Backends, *Error) {
-	return ds.db.GetAllBackends()
+	b, derr := ds.db.GetAllBackends()
+	if derr != nil {
+		return nil, derr
+	}
+	if b == nil {
+		// so empty results encode as '[]' and not 'null'
+		b = Backends{}
+	}
+	return b, nil
 }
 
 // GetBackend returns the backend that has the specified name, or nil.
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ds *dataSvcImpl) GetBackend(name string) (*Backend, *Error) {
-	return ds.db.GetBackend(name)
+	return ds.db.GetBackend(ds.correctName(name))
 }
 
 // SaveBackend persists a backend and returns an error if the operation failed.
 // Potential error types:
-//   ErrBadData: the backend Name is empty
+//   ErrBadData: the backend is invalid
+//   ErrConflict: a frontend with the same name exists and names must be unique across types
 //   ErrSync: HAProxy config sync failed and delete has been rolled back
 //   ErrOutOfSync: HAProxy config and backend data store are out of sync
+//   ErrDrift: the HAProxy config file has drifted from the data store
 //   ErrDB: error reading/writing to the database
 func (ds *dataSvcImpl) SaveBackend(b *Backend) *Error {
-	// validate name
-	if b.Name == "" {
-		return NewErrorf(ErrBadData, "Name is required")
+	return ds.saveBackend(b, false)
+}
+
+// CloneBackend saves a copy of an existing backend, including its members, under a new name; if a
+// version is given, it replaces the version of the copy and of its members.
+// Potential error types:
+//   ErrNotFound: the backend to clone doesn't exist
+//   ErrBadData: the new name is invalid
+//   ErrConflict: a backend with the new name exists
+//   ErrSync: HAProxy config sync failed and the clone has been rolled back
+//   ErrOutOfSync: HAProxy config and backend data store are out of sync
+//   ErrDrift: the HAProxy config file has drifted from the data store
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) CloneBackend(source, name, version string) (*Backend, *Error) {
+	src, derr := ds.db.GetBackend(ds.correctName(source))
+	if derr != nil {
+		return nil, derr
+	}
+	if src == nil {
+		return nil, NewErrorf(ErrNotFound, "the backend to clone does not exist")
+	}
+
+	b := src.Copy()
+	b.Name = name
+	if version != "" {
+		b.Version = version
+		for i := range b.Members {
+			b.Members[i].Version = version
+		}
+	}
+	if derr := ds.saveBackend(b, true); derr != nil {
+		return nil, derr
+	}
+	return b, nil
+}
+
+// validates and persists a backend; when create is true, an ErrConflict error is returned if a
+// backend with the same name exists
+func (ds *dataSvcImpl) saveBackend(b *Backend, create bool) *Error {
+	// apply the default mode, before validation so that the rules that depend on the mode apply to
+	// the mode the backend is saved with
+	if b.Mode == "" {
+		b.Mode = ds.defaultMode
+	}
+
+	// apply the default port to the members saved without one
+	b.Members = withDefaultPort(b.Members, b.DefaultPort)
+
+	if errs := ValidateBackend(b); errs != nil {
+		return NewError(ErrBadData, ValidationError(errs))
+	}
+	if derr := ds.checkReloadStrategy(b.ReloadStrategy); derr != nil {
+		return derr
+	}
+	if derr := ds.checkName(b.Name); derr != nil {
+		return derr
 	}
 
 	// get key value
 	b.Name = ds.correctName(b.Name)
+	if derr := ds.checkNameUnused(b.Name, "frontend"); derr != nil {
+		return derr
+	}
+
+	b.ModifiedAt = time.Now().UTC()
 
-	// save record to update in case we need to rollback
-	old, derr := ds.db.GetBackend(b.Name)
+	// execute save and sync HAProxy config
+	derr := ds.write(func(db Datastore) *Error {
+		if create {
+			existing, derr := db.GetBackend(b.Name)
+			if derr != nil {
+				return derr
+			}
+			if existing != nil {
+				return NewErrorf(ErrConflict, "a backend with the name %s already exists", b.Name)
+			}
+		}
+		return db.SaveBackend(b)
+	})
 	if derr != nil {
 		return derr
 	}
+	if msg := ds.BackendLimitWarning(); msg != "" {
+		log.Printf("[WARN] %s", msg)
+	}
+	if msg := ds.SingleMemberWarning(b); msg != "" {
+		log.Printf("[WARN] %s", msg)
+	}
+	return nil
+}
 
-	// execute save
-	if derr = ds.db.SaveBackend(b); derr != nil {
-		return derr
+// BackendLimitWarning returns a warning if the number of backends exceeds the configured soft
+// limit, or an empty string if it doesn't or there's no limit; the limit is advisory, so saves
+// aren't refused.
+func (ds *dataSvcImpl) BackendLimitWarning() string {
+	if ds.backendSoftLimit <= 0 {
+		return ""
+	}
+	b, derr := ds.db.GetAllBackends()
+	if derr != nil || len(b) <= ds.backendSoftLimit {
+		return ""
 	}
+	return fmt.Sprintf("there are %d backends, more than the soft limit of %d", len(b), ds.backendSoftLimit)
+}
 
-	// sync HAProxy config
-	var rollback func() *Error
-	if old != nil {
-		rollback = func() *Error { return ds.db.SaveBackend(old) }
-	} else {
-		rollback = func() *Error { return ds.db.DeleteBackend(b.Name) }
+// SingleMemberWarning returns a warning if the given backend has exactly one member, and so no
+// redundancy, or an empty string if it doesn't or single-member backends aren't warned about.
+func (ds *dataSvcImpl) SingleMemberWarning(b *Backend) string {
+	if !ds.warnSingleMemberBackends || len(b.Members) != 1 {
+		return ""
 	}
-	return ds.syncHAProxy(rollback)
+	return fmt.Sprintf("the backend %s has a single member and no redundancy", b.Name)
 }
 
 // DeleteBackend removes the backend with the specified id; if the backend does not exist, no action is taken.
@@ -80,77 +339,393 @@ func (ds *dataSvcImpl) SaveBackend(b *Backend) *Error {
 //   ErrNotFound: the backend to delete doesn't exist
 //   ErrSync: HAProxy config sync failed and delete has been rolled back
 //   ErrOutOfSync: HAProxy config and backend data store are out of sync
+//   ErrDrift: the HAProxy config file has drifted from the data store
 //   ErrDB: error reading/writing to the database
 func (ds *dataSvcImpl) DeleteBackend(key string) *Error {
-	// save record to delete in case we need to rollback
+	key = ds.correctName(key)
+
+	// check that backend to delete exists
 	old, derr := ds.db.GetBackend(key)
 	if derr != nil {
 		return derr
 	}
-
-	// check that backend to delete exists
 	if old == nil {
 		return NewErrorf(ErrNotFound, "the backend to delete does not exist")
 	}
 
-	// execute delete
-	if derr = ds.db.DeleteBackend(key); derr != nil {
+	// execute delete and sync HAProxy config
+	return ds.write(func(db Datastore) *Error { return db.DeleteBackend(key) })
+}
+
+// DeleteBackendCascade removes the backend with the specified id, clears the default backend of any
+// frontends that reference it, and drops their routing rules to it, syncing HAProxy once for all of
+// the changes.
+// Potential error types:
+//   ErrNotFound: the backend to delete doesn't exist
+//   ErrSync: HAProxy config sync failed and all of the changes have been rolled back
+//   ErrOutOfSync: HAProxy config and data store are out of sync
+//   ErrDrift: the HAProxy config file has drifted from the data store
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) DeleteBackendCascade(key string) *Error {
+	key = ds.correctName(key)
+	return ds.WithTransaction(func(tx DataSvc) error {
+		if derr := tx.DeleteBackend(key); derr != nil {
+			return derr
+		}
+		frontends, derr := tx.GetAllFrontends()
+		if derr != nil {
+			return derr
+		}
+		for _, f := range frontends {
+			changed := false
+			if ds.correctName(f.DefaultBackend) == key {
+				f.DefaultBackend = ""
+				changed = true
+			}
+			rules := []Rule{}
+			for _, r := range f.RoutingRules {
+				if ds.correctName(r.Backend) == key {
+					changed = true
+					continue
+				}
+				rules = append(rules, r)
+			}
+			if !changed {
+				continue
+			}
+			if f.RoutingRules != nil {
+				f.RoutingRules = rules
+			}
+			if derr := tx.SaveFrontend(f); derr != nil {
+				return derr
+			}
+		}
+		return nil
+	})
+}
+
+// SwapBackendMembers replaces the members of a backend in two phases: the existing members are first
+// disabled and synced so that they can drain, and then, once the drain wait has elapsed, they are
+// replaced with the given members and synced again. The backend with the new members is validated
+// before anything is disabled, and if the second phase fails, the original members are restored.
+// Potential error types:
+//   ErrNotFound: the backend doesn't exist
+//   ErrBadData: the backend with the new members is invalid
+//   ErrSync: HAProxy config sync failed and the current phase has been rolled back
+//   ErrOutOfSync: HAProxy config and backend data store are out of sync
+//   ErrDrift: the HAProxy config file has drifted from the data store
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) SwapBackendMembers(name string, members BackendMembers) (*Backend, *Error) {
+	name = ds.correctName(name)
+	b, derr := ds.db.GetBackend(name)
+	if derr != nil {
+		return nil, derr
+	}
+	if b == nil {
+		return nil, NewErrorf(ErrNotFound, "the backend does not exist")
+	}
+	original := b.Members
+
+	// validate the new members before the existing ones are disabled, so that an invalid swap never
+	// leaves the backend drained
+	target := b.Copy()
+	target.Members = members
+	if errs := ValidateBackend(target); errs != nil {
+		return nil, NewError(ErrBadData, ValidationError(errs))
+	}
+
+	// disable the existing members so that they stop receiving new connections
+	drained := make(BackendMembers, len(b.Members))
+	for i, m := range b.Members {
+		m.Disabled = true
+		drained[i] = m
+	}
+	b.Members = drained
+	if derr := ds.SaveBackend(b); derr != nil {
+		return nil, derr
+	}
+
+	// wait for the existing connections to drain, then replace the members of the backend as it is
+	// now, so that any changes made to it during the drain are kept
+	ds.sleep(ds.drainWait)
+	b, derr = ds.db.GetBackend(name)
+	if derr != nil {
+		return nil, derr
+	}
+	if b == nil {
+		return nil, NewErrorf(ErrNotFound, "the backend was deleted while its members were draining")
+	}
+	b.Members = members
+	if derr := ds.SaveBackend(b); derr != nil {
+		ds.restoreMembers(name, original)
+		return nil, derr
+	}
+	return b, nil
+}
+
+// restores the members of a backend whose member swap failed after its members were disabled, so
+// that the backend isn't left drained
+func (ds *dataSvcImpl) restoreMembers(name string, members BackendMembers) {
+	b, derr := ds.db.GetBackend(name)
+	if derr == nil && b != nil {
+		b.Members = members
+		derr = ds.SaveBackend(b)
+	}
+	if derr != nil {
+		log.Printf("[WARN] Failed to restore the members of backend %s after a failed swap - its members may be left disabled: %v", name, derr)
+	}
+}
+
+// MemberHeartbeat identifies a backend member whose liveness is being reported.
+type MemberHeartbeat struct {
+	Backend string `json:"backend"`
+	Member  string `json:"member"`
+}
+
+// HeartbeatMembers sets the LastKnown time of each of the given backend members to the current
+// time, saving each affected backend once. HAProxy is not synced, since the members' addresses are
+// unchanged. The heartbeats whose backend or member does not exist are returned.
+// Potential error types:
+//   ErrSync: the server is stopping
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) HeartbeatMembers(beats []MemberHeartbeat) ([]MemberHeartbeat, *Error) {
+	var notFound []MemberHeartbeat
+	derr := ds.writeData(func() *Error {
+		var derr *Error
+		notFound, derr = ds.heartbeatMembers(beats)
 		return derr
+	})
+	if derr != nil {
+		return nil, derr
+	}
+	return notFound, nil
+}
+
+// sets the LastKnown time of the given backend members and returns the heartbeats that don't match
+// a member
+func (ds *dataSvcImpl) heartbeatMembers(beats []MemberHeartbeat) ([]MemberHeartbeat, *Error) {
+	// group the heartbeats by backend so that each backend is read and saved once
+	names := []string{}
+	byBackend := map[string][]MemberHeartbeat{}
+	for _, hb := range beats {
+		name := ds.correctName(hb.Backend)
+		if _, ok := byBackend[name]; !ok {
+			names = append(names, name)
+		}
+		byBackend[name] = append(byBackend[name], hb)
+	}
+
+	now := time.Now()
+	notFound := []MemberHeartbeat{}
+	for _, name := range names {
+		b, derr := ds.db.GetBackend(name)
+		if derr != nil {
+			return nil, derr
+		}
+		if b == nil {
+			notFound = append(notFound, byBackend[name]...)
+			continue
+		}
+
+		updated := false
+		for _, hb := range byBackend[name] {
+			found := false
+			for i := range b.Members {
+				if b.Members[i].Name == hb.Member {
+					b.Members[i].LastKnown = now
+					found = true
+				}
+			}
+			if !found {
+				notFound = append(notFound, hb)
+			}
+			updated = updated || found
+		}
+		if updated {
+			b.ModifiedAt = now.UTC()
+			if derr := ds.db.SaveBackend(b); derr != nil {
+				return nil, derr
+			}
+		}
 	}
+	return notFound, nil
+}
 
-	// sync HAProxy config
-	rollback := func() *Error { return ds.db.SaveBackend(old) }
-	return ds.syncHAProxy(rollback)
+// SaveBackendMemberMeta replaces the metadata of a backend member without changing its other
+// fields. HAProxy is not synced, since member metadata isn't part of its config. The backend is read
+// and saved with other writes held off, so a concurrent save of the backend isn't reverted.
+// Potential error types:
+//   ErrNotFound: the backend or member doesn't exist
+//   ErrSync: the server is stopping
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) SaveBackendMemberMeta(name, member string, meta map[string]string) *Error {
+	return ds.writeData(func() *Error {
+		b, derr := ds.db.GetBackend(ds.correctName(name))
+		if derr != nil {
+			return derr
+		}
+		if b == nil {
+			return NewErrorf(ErrNotFound, "the backend does not exist")
+		}
+		for i := range b.Members {
+			if b.Members[i].Name == member {
+				b.Members[i].Meta = copyMeta(meta)
+				b.ModifiedAt = time.Now().UTC()
+				return ds.db.SaveBackend(b)
+			}
+		}
+		return NewErrorf(ErrNotFound, "the backend member does not exist")
+	})
 }
 
-// GetAllFrontends returns all the frontends in the system, or nil.
+// SetBackendMemberVersions sets the version of every member of a backend, and of the backend itself
+// if backendToo is true, saving the backend once. HAProxy is not synced, since versions aren't part
+// of its config. The backend is read and saved with other writes held off, so a concurrent save of
+// the backend isn't reverted.
+// Potential error types:
+//   ErrNotFound: the backend doesn't exist
+//   ErrSync: the server is stopping
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) SetBackendMemberVersions(name, version string, backendToo bool) (*Backend, *Error) {
+	var b *Backend
+	derr := ds.writeData(func() *Error {
+		var derr *Error
+		b, derr = ds.db.GetBackend(ds.correctName(name))
+		if derr != nil {
+			return derr
+		}
+		if b == nil {
+			return NewErrorf(ErrNotFound, "the backend does not exist")
+		}
+		for i := range b.Members {
+			b.Members[i].Version = version
+		}
+		if backendToo {
+			b.Version = version
+		}
+		b.ModifiedAt = time.Now().UTC()
+		return ds.db.SaveBackend(b)
+	})
+	if derr != nil {
+		return nil, derr
+	}
+	return b, nil
+}
+
+// GetAllFrontends returns all the frontends in the system; if there are none, an empty list is
+// returned rather than nil.
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ds *dataSvcImpl) GetAllFrontends() (Frontends, *Error) {
-	return ds.db.GetAllFrontends()
+	f, derr := ds.db.GetAllFrontends()
+	if derr != nil {
+		return nil, derr
+	}
+	if f == nil {
+		// so empty results encode as '[]' and not 'null'
+		f = Frontends{}
+	}
+	return f, nil
 }
 
 // GetFrontend returns the frontend that has the specified id, or nil.
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ds *dataSvcImpl) GetFrontend(key string) (*Frontend, *Error) {
-	return ds.db.GetFrontend(key)
+	return ds.db.GetFrontend(ds.correctName(key))
+}
+
+// GetRaw returns the stored form of the frontend or backend of the given proxy type ("frontend" or
+// "backend") that has the specified name, or nil; it's intended for debugging.
+// Potential error types:
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) GetRaw(proxyType, key string) (*RawRecord, *Error) {
+	return ds.db.GetRaw(proxyType, ds.correctName(key))
+}
+
+// GetTemplate returns the HAProxy config template stored in the data store, or an empty string if
+// none is stored.
+// Potential error types:
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) GetTemplate() (string, *Error) {
+	return ds.db.GetTemplate()
+}
+
+// SaveTemplate stores the HAProxy config template and rewrites the HAProxy config file with it; if
+// the sync fails, the previous template is restored.
+// Potential error types:
+//   ErrBadData: the template can't be parsed
+//   ErrSync: HAProxy config sync failed and the template has been rolled back
+//   ErrOutOfSync: HAProxy config and data store are out of sync
+//   ErrDrift: the HAProxy config file has drifted from the data store
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) SaveTemplate(text string) *Error {
+	tmpl, err := template.New("test").Parse(text)
+	if err != nil {
+		return NewErrorf(ErrBadData, "the template is invalid: %v", err)
+	}
+
+	return ds.write(func(db Datastore) *Error {
+		if derr := db.SaveTemplate(text); derr != nil {
+			return derr
+		}
+
+		// writes are made through a txDatastore, so the template in use is restored along with the
+		// stored one if the write is rolled back
+		old := ds.ha.Template()
+		ds.ha.SetTemplate(tmpl)
+		if tx, ok := db.(*txDatastore); ok {
+			tx.record("use", "template", "haproxy", func() *Error {
+				ds.ha.SetTemplate(old)
+				return nil
+			})
+		}
+		return nil
+	})
 }
 
 // SaveFrontend persists a frontend and returns an error if the operation failed.
 // Potential error types:
-//   ErrBadData: the frontend Name is empty
+//   ErrBadData: the frontend is invalid, its option isn't allowed, or a routing rule's backend doesn't exist
+//   ErrConflict: a backend with the same name exists and names must be unique across types
 //   ErrSync: HAProxy config sync failed and update has been rolled back
 //   ErrOutOfSync: HAProxy config and frontend data store are out of sync
+//   ErrDrift: the HAProxy config file has drifted from the data store
 //   ErrDB: error reading/writing to the database
 func (ds *dataSvcImpl) SaveFrontend(f *Frontend) *Error {
-	// validate name
-	if f.Name == "" {
-		return NewErrorf(ErrBadData, "Name is required")
+	if errs := ValidateFrontend(f); errs != nil {
+		return NewError(ErrBadData, ValidationError(errs))
+	}
+	if derr := ds.checkName(f.Name); derr != nil {
+		return derr
+	}
+	if derr := ds.checkOption(f.Option); derr != nil {
+		return derr
 	}
 
 	// get key value
 	f.Name = ds.correctName(f.Name)
-
-	// save record to update in case we need to rollback
-	old, derr := ds.db.GetFrontend(f.Name)
-	if derr != nil {
+	if derr := ds.checkNameUnused(f.Name, "backend"); derr != nil {
 		return derr
 	}
 
-	// execute save
-	if derr = ds.db.SaveFrontend(f); derr != nil {
-		return derr
+	// apply the default mode
+	if f.Mode == "" {
+		f.Mode = ds.defaultMode
 	}
 
-	// sync HAProxy config
-	var rollback func() *Error
-	if old != nil {
-		rollback = func() *Error { return ds.db.SaveFrontend(old) }
-	} else {
-		rollback = func() *Error { return ds.db.DeleteFrontend(f.Name) }
+	// normalize bind addresses
+	f.Bind, _ = normalizeBind(f.Bind)
+
+	// ensure that the routing rules route to existing backends
+	if derr := ds.checkRuleBackends(f); derr != nil {
+		return derr
 	}
-	return ds.syncHAProxy(rollback)
+
+	f.ModifiedAt = time.Now().UTC()
+
+	// execute save and sync HAProxy config
+	return ds.write(func(db Datastore) *Error { return db.SaveFrontend(f) })
 }
 
 // DeleteFrontend removes the frontend with the specified id; if the frontend does not exist, no action is taken.
@@ -158,64 +733,665 @@ func (ds *dataSvcImpl) SaveFrontend(f *Frontend) *Error {
 //   ErrNotFound: the frontend to delete doesn't exist
 //   ErrSync: HAProxy config sync failed and delete has been rolled back
 //   ErrOutOfSync: HAProxy config and frontend data store are out of sync
+//   ErrDrift: the HAProxy config file has drifted from the data store
 //   ErrDB: error reading/writing to the database
 func (ds *dataSvcImpl) DeleteFrontend(key string) *Error {
-	// save record to delete in case we need to rollback
+	key = ds.correctName(key)
+
+	// check that frontend to delete exists
 	old, derr := ds.db.GetFrontend(key)
 	if derr != nil {
 		return derr
 	}
-
-	// check that frontend to delete exists
 	if old == nil {
 		return NewErrorf(ErrNotFound, "the frontend to delete does not exist")
 	}
 
-	// execute delete
-	if derr = ds.db.DeleteFrontend(key); derr != nil {
+	// execute delete and sync HAProxy config
+	return ds.write(func(db Datastore) *Error { return db.DeleteFrontend(key) })
+}
+
+// snapshotter is implemented by data stores that can provide a consistent, read-only view of their
+// data as of a point in time.
+type snapshotter interface {
+	Snapshot() (Datastore, func(), *Error)
+}
+
+// Snapshot returns a read-only view of the service over a snapshot of the data store, so that a
+// request making several reads sees the data as it was at a single point in time, along with a
+// function that releases the snapshot once the reads are done. If the data store doesn't support
+// snapshots, the service itself is returned.
+// Potential error types:
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) Snapshot() (DataSvc, func(), *Error) {
+	s, ok := ds.db.(snapshotter)
+	if !ok {
+		return ds, func() {}, nil
+	}
+	db, release, derr := s.Snapshot()
+	if derr != nil {
+		return nil, nil, derr
+	}
+	snap := *ds
+	snap.db = db
+	return &snap, release, nil
+}
+
+// WithTransaction executes the given function against a transactional view of the service; the
+// datastore writes made through tx are either all committed followed by a single HAProxy sync,
+// or, if the function returns an error or the sync fails, all rolled back. Nested transactions
+// are folded into the enclosing one.
+// Potential error types:
+//   ErrSync: HAProxy config sync failed and the transaction has been rolled back
+//   ErrOutOfSync: HAProxy config and data store are out of sync
+//   ErrDrift: the HAProxy config file has drifted from the data store
+//   ErrDB: error reading/writing to the database
+//   any error type returned by fn; other errors are returned as ErrUnknown
+func (ds *dataSvcImpl) WithTransaction(fn func(tx DataSvc) error) *Error {
+	return ds.write(func(db Datastore) *Error {
+		tx := *ds
+		tx.db = db
+		tx.inTransaction = true
+		if err := fn(&tx); err != nil {
+			if derr, ok := err.(*Error); ok {
+				return derr
+			}
+			return NewError(ErrUnknown, err)
+		}
+		return nil
+	})
+}
+
+// SweepExpired deletes the frontends and backends that have expired as of the given time, syncing
+// HAProxy once for all of them, and returns the number deleted. The default backend of any
+// remaining frontends that reference a deleted backend is cleared.
+// Potential error types:
+//   ErrSync: HAProxy config sync failed and all of the deletes have been rolled back
+//   ErrOutOfSync: HAProxy config and data store are out of sync
+//   ErrDrift: the HAProxy config file has drifted from the data store
+//   ErrDB: error reading/writing to the database
+func (ds *dataSvcImpl) SweepExpired(now time.Time) (int, *Error) {
+	frontends, derr := ds.db.GetAllFrontends()
+	if derr != nil {
+		return 0, derr
+	}
+	backends, derr := ds.db.GetAllBackends()
+	if derr != nil {
+		return 0, derr
+	}
+
+	expiredFrontends, expiredBackends := []string{}, []string{}
+	for _, f := range frontends {
+		if f.Expired(now) {
+			expiredFrontends = append(expiredFrontends, f.Name)
+		}
+	}
+	for _, b := range backends {
+		if b.Expired(now) {
+			expiredBackends = append(expiredBackends, b.Name)
+		}
+	}
+	count := len(expiredFrontends) + len(expiredBackends)
+	if count == 0 {
+		return 0, nil
+	}
+
+	derr = ds.WithTransaction(func(tx DataSvc) error {
+		for _, name := range expiredFrontends {
+			if derr := tx.DeleteFrontend(name); derr != nil {
+				return derr
+			}
+		}
+		for _, name := range expiredBackends {
+			if derr := tx.DeleteBackendCascade(name); derr != nil {
+				return derr
+			}
+		}
+		return nil
+	})
+	if derr != nil {
+		return 0, derr
+	}
+	return count, nil
+}
+
+// Sync writes the HAProxy config file from the frontends and backends in the data store and
+// instructs HAProxy to reload it.
+// Potential error types:
+//   ErrSync: HAProxy config sync failed
+func (ds *dataSvcImpl) Sync() *Error {
+	if derr := ds.writes.enter(); derr != nil {
 		return derr
 	}
+	defer ds.writes.exit()
 
-	// sync HAProxy config
-	rollback := func() *Error { return ds.db.SaveFrontend(old) }
-	return ds.syncHAProxy(rollback)
+	// an explicit sync always writes and reloads, even if the config is unchanged
+	ds.lastRender.set("")
+	return ds.syncHAProxy(func() *Error { return nil }, "", changeAll)
 }
 
-// syncs the HAProxy config file with the backend data in the data store
-func (ds *dataSvcImpl) syncHAProxy(rollback func() *Error) *Error {
-	// function that syncs HAProxy config file
-	sync := func() error {
-		b, derr := ds.db.GetAllBackends()
-		if derr != nil {
+// Drain waits for the writes and HAProxy syncs in progress to complete; any writes made afterwards
+// fail with an ErrSync error, so that a stopping server never leaves a half-written config file.
+func (ds *dataSvcImpl) Drain() {
+	ds.writes.drain()
+}
+
+// executes the given datastore writes and syncs the HAProxy config; if a write or the sync fails,
+// all of the writes are rolled back - within a transaction, the writes are left for the
+// transaction to commit or roll back
+func (ds *dataSvcImpl) write(fn func(db Datastore) *Error) *Error {
+	if ds.inTransaction {
+		return fn(ds.db)
+	}
+	if derr := ds.writes.enter(); derr != nil {
+		return derr
+	}
+	defer ds.writes.exit()
+	ds.writeLock.Lock()
+	defer ds.writeLock.Unlock()
+
+	if ds.blockWritesOnDrift {
+		if derr := ds.checkDrift(); derr != nil {
 			return derr
 		}
-		f, err := ds.db.GetAllFrontends()
-		if err != nil {
-			return err
+	}
+
+	tx := &txDatastore{Datastore: ds.db}
+	if derr := fn(tx); derr != nil {
+		if len(tx.writes) > 0 {
+			log.Printf("[WARN] Data store write failed - rolling back: %v", derr)
 		}
-		if err := ds.ha.WriteConfig(f.ToHAProxyFrontends(), b.ToHAProxyBackends()); err != nil {
-			return err
+		if rerr := tx.rollback(); rerr != nil {
+			return ds.outOfSync(rerr)
 		}
+		return derr
+	}
+	return ds.syncHAProxy(tx.rollback, tx.reloadStrategy, tx.changes())
+}
+
+// executes the given datastore writes without syncing HAProxy, for changes that don't affect the
+// config file; the writes are serialized with all other writes, so the records read by fn can't be
+// changed by another write before fn saves them
+func (ds *dataSvcImpl) writeData(fn func() *Error) *Error {
+	if !ds.inTransaction {
+		if derr := ds.writes.enter(); derr != nil {
+			return derr
+		}
+		defer ds.writes.exit()
+		ds.writeLock.Lock()
+		defer ds.writeLock.Unlock()
+	}
+	return fn()
+}
+
+// ensures that the HAProxy config file matches the config rendered from the data store, so that
+// rewriting it doesn't silently discard changes made outside of Conduit
+func (ds *dataSvcImpl) checkDrift() *Error {
+	live, err := ds.ha.GetConfig()
+	if err != nil {
+		return NewErrorf(ErrSync, "unable to check the HAProxy config file for drift: %v", err)
+	}
+	b, derr := ds.db.GetAllBackends()
+	if derr != nil {
+		return derr
+	}
+	f, derr := ds.db.GetAllFrontends()
+	if derr != nil {
+		return derr
+	}
+	rendered, err := ds.ha.RenderConfig(f.ToHAProxyFrontends(), b.ToHAProxyBackends())
+	if err != nil {
+		return NewErrorf(ErrSync, "unable to check the HAProxy config file for drift: %v", err)
+	}
+	if stripConfigHeader(live) != rendered {
+		log.Printf("[WARN] HAProxy config file has drifted from the data store - refusing changes")
+		return NewErrorf(ErrDrift, "the HAProxy config file has been changed outside of Conduit - reconcile it with the data store (see GET /haproxy/diff) before making further changes")
+	}
+	return nil
+}
+
+// changeReloader is implemented by HAProxy instances that record the type of change that caused
+// each reload.
+type changeReloader interface {
+	ReloadConfigFor(strategy string, change changeType) error
+}
+
+// syncs the HAProxy config file with the backend data in the data store, reloading HAProxy using
+// the given reload strategy (or the configured one, if empty); the type of change that caused the
+// sync is passed on to HAProxy instances that record it
+func (ds *dataSvcImpl) syncHAProxy(rollback func() *Error, strategy string, change changeType) *Error {
+	// skip the write and reload if the config would be identical to the last one applied
+	sum := ds.renderSum()
+	if ds.lastRender.matches(sum) {
+		log.Printf("[INFO] HAProxy config is unchanged - skipping reload")
 		return nil
 	}
+	ds.lastRender.set("")
+
+	// function that syncs HAProxy config file, recording the checksum of the config written
+	written := ""
+	sync := func() error {
+		sum, err := ds.writeConfig()
+		written = sum
+		return err
+	}
 
 	// execute sync function - if sync fails, execute passed in rollback function
 	if err := sync(); err != nil {
+		log.Printf("[WARN] HAProxy config sync failed - rolling back: %v", err)
 		if derr := rollback(); derr != nil {
-			return NewError(ErrOutOfSync, derr)
+			log.Printf("[WARN] Rollback failed - HAProxy config and data store are out of sync: %v", derr)
+			return ds.outOfSync(derr)
 		}
 		return NewError(ErrSync, err)
 	}
 
 	// instruct HAProxy to reload it's config file
-	if err := ds.ha.ReloadConfig(); err != nil {
+	reload := func() error {
+		if r, ok := ds.ha.(changeReloader); ok {
+			return r.ReloadConfigFor(strategy, change)
+		}
+		return ds.ha.ReloadConfigUsing(strategy)
+	}
+	if err := reload(); err != nil {
+		if !ds.strictSync {
+			log.Printf("[WARN] HAProxy reload failed - the config file has been written but is not yet in effect: %v", err)
+			return NewError(ErrSync, err)
+		}
+
+		// in strict mode, the data store and config file are reverted to match the running HAProxy
+		log.Printf("[WARN] HAProxy reload failed - rolling back: %v", err)
+		if derr := rollback(); derr != nil {
+			log.Printf("[WARN] Rollback failed - HAProxy config and data store are out of sync: %v", derr)
+			return ds.outOfSync(derr)
+		}
+		if serr := sync(); serr != nil {
+			log.Printf("[WARN] Failed to restore the HAProxy config file - HAProxy config and data store are out of sync: %v", serr)
+			return ds.outOfSync(serr)
+		}
 		return NewError(ErrSync, err)
 	}
+	ds.runPostReloadCommand()
+	if ds.verifyConfig {
+		if derr := ds.verifyWrittenConfig(written); derr != nil {
+			return derr
+		}
+	}
+	ds.lastRender.set(sum)
+	return nil
+}
+
+// ensures that the HAProxy config file matches the config written, which has the given checksum, in
+// case another process overwrote it while HAProxy was reloading; the config written is compared
+// rather than the data store, which other writes may have changed since
+func (ds *dataSvcImpl) verifyWrittenConfig(written string) *Error {
+	live, err := ds.ha.GetConfig()
+	if err != nil {
+		return NewErrorf(ErrSync, "unable to verify the HAProxy config file: %v", err)
+	}
+	if configSum(stripConfigHeader(live)) != written {
+		log.Printf("[WARN] HAProxy config file doesn't match the config written - it may have been changed by another process")
+		return NewErrorf(ErrDrift, "the HAProxy config file was changed by another process while HAProxy was reloaded - reconcile it with the data store (see GET /haproxy/diff)")
+	}
 	return nil
 }
 
-// formats and returns the key for the given backend
+// writes the HAProxy config file from the data store; when the config file is verified after each
+// reload, the checksum of the config written is returned, or else an empty string
+func (ds *dataSvcImpl) writeConfig() (string, error) {
+	b, derr := ds.db.GetAllBackends()
+	if derr != nil {
+		return "", derr
+	}
+	f, err := ds.db.GetAllFrontends()
+	if err != nil {
+		return "", err
+	}
+	frontends, backends := f.ToHAProxyFrontends(), b.ToHAProxyBackends()
+	sum := ""
+	if ds.verifyConfig {
+		rendered, err := ds.ha.RenderConfig(frontends, backends)
+		if err != nil {
+			return "", err
+		}
+		sum = configSum(rendered)
+	}
+	if err := ds.ha.WriteConfig(frontends, backends); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
+
+// returns the error for a failed rollback, which leaves the HAProxy config and data store out of
+// sync; if configured, the config file is first rewritten from the data store and HAProxy reloaded,
+// which may succeed where the rollback didn't, in which case an ErrSync error is returned instead
+func (ds *dataSvcImpl) outOfSync(err error) *Error {
+	if !ds.reconcileOnOutOfSync {
+		return NewError(ErrOutOfSync, err)
+	}
+	log.Printf("[INFO] Reconciling the HAProxy config with the data store")
+	_, rerr := ds.writeConfig()
+	if rerr == nil {
+		rerr = ds.ha.ReloadConfig()
+	}
+	if rerr != nil {
+		log.Printf("[WARN] Reconcile failed - HAProxy config and data store are out of sync: %v", rerr)
+		return NewError(ErrOutOfSync, err)
+	}
+	log.Printf("[INFO] Reconcile succeeded - HAProxy config and data store are in sync")
+	return NewErrorf(ErrSync, "the change could not be rolled back, but the HAProxy config has been rewritten from the data store: %v", err)
+}
+
+// returns the hash of the HAProxy config rendered from the data store, or an empty string if reloads
+// aren't deduped or the config can't be rendered
+func (ds *dataSvcImpl) renderSum() string {
+	if !ds.dedupeReloads {
+		return ""
+	}
+	rendered, err := ds.renderStored()
+	if err != nil {
+		return ""
+	}
+	return configSum(rendered)
+}
+
+// returns the HAProxy config rendered from the data store
+func (ds *dataSvcImpl) renderStored() (string, error) {
+	b, derr := ds.db.GetAllBackends()
+	if derr != nil {
+		return "", derr
+	}
+	f, derr := ds.db.GetAllFrontends()
+	if derr != nil {
+		return "", derr
+	}
+	return ds.ha.RenderConfig(f.ToHAProxyFrontends(), b.ToHAProxyBackends())
+}
+
+// returns the SHA-256 checksum of the given config
+func configSum(config string) string {
+	sum := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(sum[:])
+}
+
+// executes the configured post-reload command, if any; a failure is logged but otherwise ignored,
+// since the change has already taken effect
+func (ds *dataSvcImpl) runPostReloadCommand() {
+	if ds.postReloadCmd == "" {
+		return
+	}
+	cmd := exec.Command("/bin/sh", "-c", ds.postReloadCmd)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Printf("[WARN] Post-reload command '%s' failed: %v", ds.postReloadCmd, err)
+	}
+}
+
+// formats and returns the key for the given frontend or backend name; names are corrected the same
+// way on save, get, and delete, so that a name containing spaces refers to the same record
 func (ds *dataSvcImpl) correctName(name string) string {
+	if !ds.normalizeNames {
+		return name
+	}
 	// remove spaces in name and replace with underscores
 	return strings.Replace(name, " ", "_", -1)
 }
+
+// ensures that the given name of a frontend or backend to save isn't too long, and can be used
+// as-is when names aren't normalized, since HAProxy names can't contain spaces
+func (ds *dataSvcImpl) checkName(name string) *Error {
+	if ds.maxNameLength > 0 && len(name) > ds.maxNameLength {
+		return NewError(ErrBadData, ValidationError{fieldErrorf("name",
+			"name is invalid - must not be longer than %d characters", ds.maxNameLength)})
+	}
+	if ds.normalizeNames || !strings.Contains(name, " ") {
+		return nil
+	}
+	return NewError(ErrBadData, ValidationError{fieldErrorf("name", "name '%s' is invalid - must not contain spaces", name)})
+}
+
+// ensures that the given frontend option is one of the allowed options, if they're restricted
+func (ds *dataSvcImpl) checkOption(option string) *Error {
+	if option == "" || len(ds.allowedOptions) == 0 {
+		return nil
+	}
+	for _, allowed := range ds.allowedOptions {
+		if option == strings.TrimSpace(allowed) {
+			return nil
+		}
+	}
+	return NewError(ErrBadData, ValidationError{fieldErrorf("option", "option '%s' is not allowed - must be one of '%s'",
+		option, strings.Join(ds.allowedOptions, "', '"))})
+}
+
+// ensures that the given backend reload strategy can be used, i.e. that the socket strategy isn't
+// chosen unless an HAProxy socket is configured
+func (ds *dataSvcImpl) checkReloadStrategy(strategy string) *Error {
+	if strategy != reloadStrategySocket || ds.hasSocket {
+		return nil
+	}
+	return NewError(ErrBadData, ValidationError{fieldErrorf("reloadStrategy", "reloadStrategy '%s' is invalid - no HAProxy socket (hasocket) is configured",
+		strategy)})
+}
+
+// ensures that the backend of each routing rule of the given frontend exists, correcting the names
+// of the backends as they're stored
+func (ds *dataSvcImpl) checkRuleBackends(f *Frontend) *Error {
+	for i := range f.RoutingRules {
+		r := &f.RoutingRules[i]
+		r.Backend = ds.correctName(r.Backend)
+		b, derr := ds.db.GetBackend(r.Backend)
+		if derr != nil {
+			return derr
+		}
+		if b == nil {
+			return NewError(ErrBadData, ValidationError{fieldErrorf("routingRules",
+				"routing rule %d is invalid - the backend %s does not exist", i, r.Backend)})
+		}
+	}
+	return nil
+}
+
+// returns an ErrConflict error if names must be unique across types and a record of the other type
+// ("frontend" or "backend") already has the given name
+func (ds *dataSvcImpl) checkNameUnused(name, otherType string) *Error {
+	if !ds.uniqueNamesAcrossTypes {
+		return nil
+	}
+	var exists bool
+	if otherType == "frontend" {
+		f, derr := ds.db.GetFrontend(name)
+		if derr != nil {
+			return derr
+		}
+		exists = f != nil
+	} else {
+		b, derr := ds.db.GetBackend(name)
+		if derr != nil {
+			return derr
+		}
+		exists = b != nil
+	}
+	if exists {
+		return NewErrorf(ErrConflict, "the name '%s' is already used by a %s", name, otherType)
+	}
+	return nil
+}
+
+// txDatastore wraps a Datastore and records how to undo each write made through it, so that the
+// writes can be rolled back together.
+type txDatastore struct {
+	Datastore
+	writes []txWrite
+
+	// the reload strategy override of the changed backends
+	reloadStrategy string
+}
+
+// txWrite is a write made through a txDatastore, along with how to undo it.
+type txWrite struct {
+	op     string // "save", "delete", or "use"
+	entity string // "backend", "frontend", or "template"
+	name   string
+	undo   func() *Error
+}
+
+// String returns a description of the write, e.g. "save of backend 'app'".
+func (w txWrite) String() string {
+	return fmt.Sprintf("%s of %s '%s'", w.op, w.entity, w.name)
+}
+
+// changeType is the set of entity types changed by a write, so that an HAProxy sync can tell
+// frontend changes from backend changes.
+type changeType int
+
+const (
+	changeFrontend changeType = 1 << iota
+	changeBackend
+
+	// changeAll is a change to both frontends and backends, e.g. a full sync
+	changeAll = changeFrontend | changeBackend
+)
+
+// String returns the string representation of a changeType, e.g. "backend".
+func (c changeType) String() string {
+	switch c {
+	case changeFrontend:
+		return "frontend"
+	case changeBackend:
+		return "backend"
+	case changeAll:
+		return "all"
+	}
+	return ""
+}
+
+// SaveBackend persists a backend, recording its previous state.
+func (tx *txDatastore) SaveBackend(b *Backend) *Error {
+	old, derr := tx.Datastore.GetBackend(b.Name)
+	if derr != nil {
+		return derr
+	}
+	if derr = tx.Datastore.SaveBackend(b); derr != nil {
+		return derr
+	}
+	tx.useReloadStrategy(old, b)
+	name := b.Name
+	if old != nil {
+		tx.record("save", "backend", name, func() *Error { return tx.Datastore.SaveBackend(old) })
+	} else {
+		tx.record("save", "backend", name, func() *Error { return tx.Datastore.DeleteBackend(name) })
+	}
+	return nil
+}
+
+// DeleteBackend removes a backend, recording its previous state.
+func (tx *txDatastore) DeleteBackend(name string) *Error {
+	old, derr := tx.Datastore.GetBackend(name)
+	if derr != nil {
+		return derr
+	}
+	if derr = tx.Datastore.DeleteBackend(name); derr != nil {
+		return derr
+	}
+	if old != nil {
+		tx.useReloadStrategy(old)
+		tx.record("delete", "backend", name, func() *Error { return tx.Datastore.SaveBackend(old) })
+	}
+	return nil
+}
+
+// SaveFrontend persists a frontend, recording its previous state.
+func (tx *txDatastore) SaveFrontend(f *Frontend) *Error {
+	old, derr := tx.Datastore.GetFrontend(f.Name)
+	if derr != nil {
+		return derr
+	}
+	if derr = tx.Datastore.SaveFrontend(f); derr != nil {
+		return derr
+	}
+	name := f.Name
+	if old != nil {
+		tx.record("save", "frontend", name, func() *Error { return tx.Datastore.SaveFrontend(old) })
+	} else {
+		tx.record("save", "frontend", name, func() *Error { return tx.Datastore.DeleteFrontend(name) })
+	}
+	return nil
+}
+
+// DeleteFrontend removes a frontend, recording its previous state.
+func (tx *txDatastore) DeleteFrontend(name string) *Error {
+	old, derr := tx.Datastore.GetFrontend(name)
+	if derr != nil {
+		return derr
+	}
+	if derr = tx.Datastore.DeleteFrontend(name); derr != nil {
+		return derr
+	}
+	if old != nil {
+		tx.record("delete", "frontend", name, func() *Error { return tx.Datastore.SaveFrontend(old) })
+	}
+	return nil
+}
+
+// SaveTemplate stores the HAProxy config template, recording the previous template.
+func (tx *txDatastore) SaveTemplate(text string) *Error {
+	old, derr := tx.Datastore.GetTemplate()
+	if derr != nil {
+		return derr
+	}
+	if derr = tx.Datastore.SaveTemplate(text); derr != nil {
+		return derr
+	}
+	tx.record("save", "template", "haproxy", func() *Error { return tx.Datastore.SaveTemplate(old) })
+	return nil
+}
+
+// records the reload strategy override of the given changed backends; if the overrides of the
+// changed backends disagree, the graceful socket reload is used
+func (tx *txDatastore) useReloadStrategy(backends ...*Backend) {
+	for _, b := range backends {
+		if b == nil || b.ReloadStrategy == "" {
+			continue
+		}
+		if tx.reloadStrategy == "" || b.ReloadStrategy == reloadStrategySocket {
+			tx.reloadStrategy = b.ReloadStrategy
+		}
+	}
+}
+
+// records a write and how to undo it
+func (tx *txDatastore) record(op, entity, name string, undo func() *Error) {
+	tx.writes = append(tx.writes, txWrite{op: op, entity: entity, name: name, undo: undo})
+}
+
+// returns the entity types changed by the recorded writes
+func (tx *txDatastore) changes() changeType {
+	var c changeType
+	for _, w := range tx.writes {
+		switch w.entity {
+		case "frontend":
+			c |= changeFrontend
+		case "backend":
+			c |= changeBackend
+		case "template":
+			c |= changeAll
+		}
+	}
+	return c
+}
+
+// undoes the recorded writes in reverse order, logging the outcome of each
+func (tx *txDatastore) rollback() *Error {
+	for i := len(tx.writes) - 1; i >= 0; i-- {
+		w := tx.writes[i]
+		if derr := w.undo(); derr != nil {
+			log.Printf("[WARN] Failed to roll back %v: %v", w, derr)
+			return derr
+		}
+		log.Printf("[WARN] Rolled back %v", w)
+	}
+	tx.writes = nil
+	return nil
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"text/template"
+	"time"
 )
 
 type dataSvcTestCase struct {
@@ -11,6 +18,7 @@ type dataSvcTestCase struct {
 	Action   func(svc DataSvc)
 	Teardown func(svc DataSvc)
 	Mocks    dataSvcMocks
+	Config   *Config
 }
 
 type dataSvcMocks struct {
@@ -19,7 +27,11 @@ type dataSvcMocks struct {
 }
 
 func (c dataSvcTestCase) execute() {
-	svc := NewDataSvc(c.Mocks.DB, c.Mocks.HA)
+	config := c.Config
+	if config == nil {
+		config = &Config{}
+	}
+	svc := NewDataSvc(c.Mocks.DB, c.Mocks.HA, config)
 
 	// perform setup
 	if c.Setup != nil {
@@ -42,6 +54,14 @@ func defaultMocks() dataSvcMocks {
 	}
 }
 
+// a Datastore whose list methods return nil rather than an empty list
+type nilListDatastore struct {
+	*DatastoreMock
+}
+
+func (db nilListDatastore) GetAllFrontends() (Frontends, *Error) { return nil, nil }
+func (db nilListDatastore) GetAllBackends() (Backends, *Error)   { return nil, nil }
+
 var bsData = BackendTestData{}
 var fsData = FrontendTestData{}
 
@@ -80,6 +100,24 @@ func Test_frontendSvcImpl_GetAll(t *testing.T) {
 	testCase.execute()
 }
 
+// Tests that the frontendSvcImpl.GetAll() function returns an empty list, not nil, when there are
+// no frontends.
+func Test_frontendSvcImpl_GetAll_Empty(t *testing.T) {
+	testAction := func(svc DataSvc) {
+		frontends, derr := svc.GetAllFrontends()
+		assert.EnsureNil(t, derr, "frontendSvcImpl.GetAll() returned an unexpected error: %v", derr)
+		assert.Equal(t, JSONEncoder{}.Encode(frontends), "[]", "frontendSvcImpl.GetAll() returned an unexpected value")
+	}
+
+	testCase := dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: nilListDatastore{testHelpers.NewDatastoreMock()}, HA: testHelpers.NewHAProxyMock()},
+	}
+	testCase.execute()
+}
+
 // ----------------------------------------------
 // frontendSvcImpl.Get TESTS
 // ----------------------------------------------
@@ -136,6 +174,34 @@ func Test_frontendSvcImpl_Save_Create(t *testing.T) {
 	}.execute()
 }
 
+// Tests that the frontendSvcImpl.Save() function sets the modification time of the frontend on
+// each save, ignoring any time given by the caller.
+func Test_frontendSvcImpl_Save_ModifiedAt(t *testing.T) {
+	f := fsData.OneFrontend()
+	f.ModifiedAt = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testAction := func(svc DataSvc) {
+		before := time.Now()
+		derr := svc.SaveFrontend(f)
+		assert.EnsureNil(t, derr, "frontendSvcImpl.Save() returned an unexpected error: %v", derr)
+		first, _ := svc.GetFrontend(f.Name)
+		assert.EnsureNotNil(t, first, "frontendSvcImpl.Get() failed to return the frontend")
+		assert.False(t, first.ModifiedAt.Before(before), "frontendSvcImpl.Save() set an unexpected modification time: %v", first.ModifiedAt)
+
+		firstAt := first.ModifiedAt
+		time.Sleep(time.Millisecond)
+		derr = svc.SaveFrontend(first)
+		assert.EnsureNil(t, derr, "frontendSvcImpl.Save() returned an unexpected error: %v", derr)
+		second, _ := svc.GetFrontend(f.Name)
+		assert.True(t, second.ModifiedAt.After(firstAt), "frontendSvcImpl.Save() did not update the modification time")
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
 // Tests the "happy path" for updating a frontend with the frontendSvcImpl.Save() function.
 func Test_frontendSvcImpl_Save_Update(t *testing.T) {
 	f := fsData.OneFrontend()
@@ -344,6 +410,334 @@ func Test_frontendSvcImpl_Delete_SyncError(t *testing.T) {
 	}.execute()
 }
 
+// Tests that the frontendSvcImpl.Save() function accepts and normalizes valid bind addresses.
+func Test_frontendSvcImpl_Save_ValidBinds(t *testing.T) {
+	testCases := []struct{ Bind, Result string }{
+		{Bind: "*:80", Result: "*:80"},
+		{Bind: ":80", Result: ":80"},
+		{Bind: "1.2.3.4:80", Result: "1.2.3.4:80"},
+		{Bind: "80", Result: "*:80"},
+		{Bind: "*:8080,*:80", Result: "*:8080,*:80"},
+		{Bind: "8080, 1.2.3.4:80", Result: "*:8080,1.2.3.4:80"},
+		{Bind: "*:8000-8010", Result: "*:8000-8010"},
+		{Bind: "", Result: ""},
+	}
+
+	for _, testCase := range testCases {
+		f := fsData.OneFrontend()
+		f.Bind = testCase.Bind
+
+		testAction := func(svc DataSvc) {
+			derr := svc.SaveFrontend(f)
+			assert.EnsureNil(t, derr, "frontendSvcImpl.Save() returned an unexpected error for bind '%s': %v", testCase.Bind, derr)
+
+			returnedFrontend, derr := svc.GetFrontend(f.Name)
+			assert.EnsureNil(t, derr, "frontendSvcImpl.Get() returned an unexpected error: %v", derr)
+			assert.Equal(t, returnedFrontend.Bind, testCase.Result, "frontendSvcImpl.Save() stored an unexpected bind for '%s'", testCase.Bind)
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+		}.execute()
+	}
+}
+
+// Tests that the frontendSvcImpl.Save() function rejects invalid bind addresses.
+func Test_frontendSvcImpl_Save_InvalidBinds(t *testing.T) {
+	binds := []string{"http", "*:http", "*:0", "*:65536", "*:", "my host:80", "*:80,", "*:80-"}
+
+	for _, bind := range binds {
+		f := fsData.OneFrontend()
+		f.Bind = bind
+
+		testAction := func(svc DataSvc) {
+			derr := svc.SaveFrontend(f)
+			assert.EnsureNotNil(t, derr, "frontendSvcImpl.Save() failed to return an expected error for bind '%s'", bind)
+			assert.Equal(t, derr.Type, ErrBadData, "frontendSvcImpl.Save() returned an unexpected error type: '%v'", derr.Type.String())
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+		}.execute()
+	}
+}
+
+// Tests that the frontendSvcImpl.Save() function rejects a frontend that binds the same address twice.
+func Test_frontendSvcImpl_Save_DuplicateBinds(t *testing.T) {
+	for _, bind := range []string{"*:80,*:80", "*:8080,80,*:80", "1.2.3.4:80, 1.2.3.4:80", ":80,*:80"} {
+		f := fsData.OneFrontend()
+		f.Bind = bind
+
+		testAction := func(svc DataSvc) {
+			derr := svc.SaveFrontend(f)
+			assert.EnsureNotNil(t, derr, "frontendSvcImpl.Save() failed to return an expected error for bind '%s'", bind)
+			assert.Equal(t, derr.Type, ErrBadData, "frontendSvcImpl.Save() returned an unexpected error type: '%v'", derr.Type.String())
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+		}.execute()
+	}
+}
+
+// Tests that the frontendSvcImpl.Save() function accepts a frontend with distinct binds.
+func Test_frontendSvcImpl_Save_DistinctBinds(t *testing.T) {
+	for _, bind := range []string{"*:80,*:443", "1.2.3.4:80,1.2.3.5:80", ":80,:443"} {
+		f := fsData.OneFrontend()
+		f.Bind = bind
+
+		testAction := func(svc DataSvc) {
+			derr := svc.SaveFrontend(f)
+			assert.Nil(t, derr, "frontendSvcImpl.Save() returned an unexpected error for bind '%s': %v", bind, derr)
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+		}.execute()
+	}
+}
+
+// Tests that the backendSvcImpl.Save() function applies the default port to members saved without
+// a port, and keeps the explicit port of the others.
+func Test_backendSvcImpl_Save_DefaultPort(t *testing.T) {
+	b := bsData.OneBackend()
+	b.DefaultPort = 8080
+	b.Members = BackendMembers{
+		{Name: "m1", Host: "10.0.0.1"},
+		{Name: "m2", Host: "10.0.0.2", Port: 9090},
+	}
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "backendSvcImpl.Save() returned an unexpected error: %v", derr)
+
+		actual, derr := svc.GetBackend(b.Name)
+		assert.EnsureNil(t, derr, "backendSvcImpl.Get() returned an unexpected error: %v", derr)
+		assert.EnsureNotNil(t, actual, "backendSvcImpl.Get() did not return the saved backend")
+		assert.EnsureEqual(t, len(actual.Members), 2, "backendSvcImpl.Get() returned unexpected members")
+		assert.Equal(t, actual.Members[0].Port, 8080, "backendSvcImpl.Save() did not apply the default port")
+		assert.Equal(t, actual.Members[1].Port, 9090, "backendSvcImpl.Save() did not keep the explicit port")
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
+// Tests that the frontendSvcImpl.Save() function rejects a negative maxconn and accepts a positive one.
+func Test_frontendSvcImpl_Save_MaxConn(t *testing.T) {
+	for maxConn, valid := range map[int]bool{-1: false, 0: true, 2000: true} {
+		f := fsData.OneFrontend()
+		f.MaxConn = maxConn
+
+		testAction := func(svc DataSvc) {
+			derr := svc.SaveFrontend(f)
+			if valid {
+				assert.Nil(t, derr, "frontendSvcImpl.Save() returned an unexpected error for maxconn %d: %v", maxConn, derr)
+				return
+			}
+			assert.EnsureNotNil(t, derr, "frontendSvcImpl.Save() failed to return an expected error for maxconn %d", maxConn)
+			assert.Equal(t, derr.Type, ErrBadData, "frontendSvcImpl.Save() returned an unexpected error type: '%v'", derr.Type.String())
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+		}.execute()
+	}
+}
+
+// Tests that the frontendSvcImpl.Save() function only accepts the allowed options, when they're
+// restricted.
+func Test_frontendSvcImpl_Save_AllowedOptions(t *testing.T) {
+	testCases := []struct {
+		Allowed []string
+		Option  string
+		Valid   bool
+	}{
+		{Allowed: nil, Option: "http-server-close", Valid: true},
+		{Allowed: []string{"httplog", "tcplog"}, Option: "httplog", Valid: true},
+		{Allowed: []string{"httplog", "tcplog"}, Option: "", Valid: true},
+		{Allowed: []string{"httplog", "tcplog"}, Option: "http-server-close", Valid: false},
+		{Allowed: []string{"httplog", "tcplog"}, Option: "httplog\n    bind *:81", Valid: false},
+	}
+	for _, tc := range testCases {
+		f := fsData.OneFrontend()
+		f.Option = tc.Option
+
+		testAction := func(svc DataSvc) {
+			derr := svc.SaveFrontend(f)
+			if tc.Valid {
+				assert.Nil(t, derr, "frontendSvcImpl.Save() returned an unexpected error for option '%s': %v", tc.Option, derr)
+				return
+			}
+			assert.EnsureNotNil(t, derr, "frontendSvcImpl.Save() failed to reject option '%s'", tc.Option)
+			assert.Equal(t, derr.Type, ErrBadData, "frontendSvcImpl.Save() returned an unexpected error type: '%v'", derr.Type.String())
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+			Config: &Config{AllowedOptions: tc.Allowed},
+		}.execute()
+	}
+}
+
+// Tests that frontend and backend names longer than the maximum name length are rejected.
+func Test_dataSvcImpl_Save_MaxNameLength(t *testing.T) {
+	testCases := []struct {
+		Length int
+		Valid  bool
+	}{
+		{Length: defaultMaxNameLength - 1, Valid: true},
+		{Length: defaultMaxNameLength, Valid: true},
+		{Length: defaultMaxNameLength + 1, Valid: false},
+	}
+	for _, tc := range testCases {
+		name := strings.Repeat("a", tc.Length)
+
+		testAction := func(svc DataSvc) {
+			f := fsData.OneFrontend()
+			f.Name = name
+			derr := svc.SaveFrontend(f)
+			b := bsData.OneBackend()
+			b.Name = name
+			bderr := svc.SaveBackend(b)
+
+			if tc.Valid {
+				assert.Nil(t, derr, "dataSvcImpl.SaveFrontend() returned an unexpected error for a name of length %d: %v", tc.Length, derr)
+				assert.Nil(t, bderr, "dataSvcImpl.SaveBackend() returned an unexpected error for a name of length %d: %v", tc.Length, bderr)
+				return
+			}
+			assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveFrontend() failed to reject a name of length %d", tc.Length)
+			assert.Equal(t, derr.Type, ErrBadData, "dataSvcImpl.SaveFrontend() returned an unexpected error type: '%v'", derr.Type.String())
+			assert.EnsureNotNil(t, bderr, "dataSvcImpl.SaveBackend() failed to reject a name of length %d", tc.Length)
+			assert.Equal(t, bderr.Type, ErrBadData, "dataSvcImpl.SaveBackend() returned an unexpected error type: '%v'", bderr.Type.String())
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+			Config: &Config{MaxNameLength: defaultMaxNameLength},
+		}.execute()
+	}
+}
+
+// Tests that saving a frontend or backend whose name is used by the other type is only rejected
+// when names must be unique across types.
+func Test_dataSvcImpl_Save_UniqueNamesAcrossTypes(t *testing.T) {
+	testCases := []struct {
+		Unique    bool
+		OtherName string
+		Conflict  bool
+	}{
+		{Unique: false, OtherName: "app", Conflict: false},
+		{Unique: false, OtherName: "other", Conflict: false},
+		{Unique: true, OtherName: "app", Conflict: true},
+		{Unique: true, OtherName: "other", Conflict: false},
+	}
+	for _, tc := range testCases {
+		// a frontend saved when a backend exists
+		testAction := func(svc DataSvc) {
+			b := bsData.OneBackend()
+			b.Name = tc.OtherName
+			derr := svc.SaveBackend(b)
+			assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+
+			f := fsData.OneFrontend()
+			f.Name = "app"
+			derr = svc.SaveFrontend(f)
+			if !tc.Conflict {
+				assert.Nil(t, derr, "dataSvcImpl.SaveFrontend() returned an unexpected error: %v", derr)
+				return
+			}
+			assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveFrontend() failed to reject a name used by a backend")
+			assert.Equal(t, derr.Type, ErrConflict, "dataSvcImpl.SaveFrontend() returned an unexpected error type: '%v'", derr.Type.String())
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+			Config: &Config{UniqueNamesAcrossTypes: tc.Unique},
+		}.execute()
+
+		// a backend saved when a frontend exists
+		testAction = func(svc DataSvc) {
+			f := fsData.OneFrontend()
+			f.Name = tc.OtherName
+			derr := svc.SaveFrontend(f)
+			assert.EnsureNil(t, derr, "dataSvcImpl.SaveFrontend() returned an unexpected error: %v", derr)
+
+			b := bsData.OneBackend()
+			b.Name = "app"
+			derr = svc.SaveBackend(b)
+			if !tc.Conflict {
+				assert.Nil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+				return
+			}
+			assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveBackend() failed to reject a name used by a frontend")
+			assert.Equal(t, derr.Type, ErrConflict, "dataSvcImpl.SaveBackend() returned an unexpected error type: '%v'", derr.Type.String())
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+			Config: &Config{UniqueNamesAcrossTypes: tc.Unique},
+		}.execute()
+	}
+}
+
+// Tests that the frontendSvcImpl.Save() function stores routing rules that route to an existing
+// backend and returns them unchanged.
+func Test_frontendSvcImpl_Save_RoutingRules(t *testing.T) {
+	b := bsData.OneBackend()
+	f := fsData.OneFrontend()
+	f.RoutingRules = []Rule{{Condition: "path_beg /api", Backend: b.Name}}
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "backendSvcImpl.Save() returned an unexpected error: %v", derr)
+		derr = svc.SaveFrontend(f)
+		assert.EnsureNil(t, derr, "frontendSvcImpl.Save() returned an unexpected error: %v", derr)
+
+		actual, derr := svc.GetFrontend(f.Name)
+		assert.EnsureNil(t, derr, "frontendSvcImpl.Get() returned an unexpected error: %v", derr)
+		assert.EnsureNotNil(t, actual, "frontendSvcImpl.Get() did not return the saved frontend")
+		assert.Equal(t, actual.RoutingRules, f.RoutingRules, "frontendSvcImpl.Get() returned unexpected routing rules")
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
+// Tests that the frontendSvcImpl.Save() function rejects a routing rule whose backend doesn't exist.
+func Test_frontendSvcImpl_Save_RoutingRules_MissingBackend(t *testing.T) {
+	f := fsData.OneFrontend()
+	f.RoutingRules = []Rule{{Condition: "path_beg /api", Backend: "missing"}}
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveFrontend(f)
+		assert.EnsureNotNil(t, derr, "frontendSvcImpl.Save() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrBadData, "frontendSvcImpl.Save() returned an unexpected error type: '%v'", derr.Type.String())
+		assert.StringContains(t, derr.Error(), "missing", "frontendSvcImpl.Save() returned an unexpected error message")
+
+		actual, _ := svc.GetFrontend(f.Name)
+		assert.Nil(t, actual, "frontendSvcImpl.Save() saved a frontend with an invalid routing rule")
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
 // // ----------------------------------------------
 // // frontendSvcImpl.getFrontendKey TESTS
 // // ----------------------------------------------
@@ -433,6 +827,24 @@ func Test_backendSvcImpl_GetAll(t *testing.T) {
 	testCase.execute()
 }
 
+// Tests that the backendSvcImpl.GetAll() function returns an empty list, not nil, when there are
+// no backends.
+func Test_backendSvcImpl_GetAll_Empty(t *testing.T) {
+	testAction := func(svc DataSvc) {
+		backends, derr := svc.GetAllBackends()
+		assert.EnsureNil(t, derr, "backendSvcImpl.GetAll() returned an unexpected error: %v", derr)
+		assert.Equal(t, JSONEncoder{}.Encode(backends), "[]", "backendSvcImpl.GetAll() returned an unexpected value")
+	}
+
+	testCase := dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: nilListDatastore{testHelpers.NewDatastoreMock()}, HA: testHelpers.NewHAProxyMock()},
+	}
+	testCase.execute()
+}
+
 // ----------------------------------------------
 // backendSvcImpl.Get TESTS
 // ----------------------------------------------
@@ -467,6 +879,28 @@ func Test_backendSvcImpl_Get(t *testing.T) {
 // ----------------------------------------------
 
 // Tests the "happy path" for creating a backend with the backendSvcImpl.Save() function.
+// Tests that the backendSvcImpl.Save() function sets the modification time of the backend, ignoring
+// any time given by the caller.
+func Test_backendSvcImpl_Save_ModifiedAt(t *testing.T) {
+	b := bsData.OneBackend()
+	b.ModifiedAt = time.Now().Add(time.Hour)
+
+	testAction := func(svc DataSvc) {
+		before := time.Now()
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "backendSvcImpl.Save() returned an unexpected error: %v", derr)
+		r, _ := svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, r, "backendSvcImpl.Get() failed to return the backend")
+		assert.False(t, r.ModifiedAt.Before(before), "backendSvcImpl.Save() set an unexpected modification time: %v", r.ModifiedAt)
+		assert.False(t, r.ModifiedAt.After(time.Now()), "backendSvcImpl.Save() kept the modification time given by the caller: %v", r.ModifiedAt)
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
 func Test_backendSvcImpl_Save_Create(t *testing.T) {
 	b := bsData.OneBackend()
 
@@ -612,6 +1046,176 @@ func Test_backendSvcImpl_Save_UpdateSyncError(t *testing.T) {
 	}.execute()
 }
 
+// Tests that a sync failure logs the failure and each rolled back write.
+func Test_backendSvcImpl_Save_SyncErrorLogging(t *testing.T) {
+	b := bsData.OneBackend()
+	f := fsData.OneFrontend()
+
+	fail := false
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		if fail {
+			return errors.New("disk full")
+		}
+		return nil
+	}
+
+	setup := func(svc DataSvc) {
+		assert.EnsureNil(t, svc.SaveBackend(b), "backendSvcImpl.Save() returned an unexpected error")
+		fail = true
+	}
+	testAction := func(svc DataSvc) {
+		output := testHelpers.CaptureLog(func() {
+			svc.WithTransaction(func(tx DataSvc) error {
+				if derr := tx.SaveBackend(b); derr != nil {
+					return derr
+				}
+				if derr := tx.SaveFrontend(f); derr != nil {
+					return derr
+				}
+				return nil
+			})
+		})
+
+		assert.StringContains(t, output, "[WARN] HAProxy config sync failed - rolling back: disk full", "syncHAProxy() did not log the sync failure")
+		assert.StringContains(t, output, "[WARN] Rolled back save of frontend '"+f.Name+"'", "syncHAProxy() did not log the frontend rollback")
+		assert.StringContains(t, output, "[WARN] Rolled back save of backend '"+b.Name+"'", "syncHAProxy() did not log the backend rollback")
+		assert.True(t, strings.Index(output, "save of frontend") < strings.Index(output, "save of backend"), "syncHAProxy() did not roll back in reverse order:\n%s", output)
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that a failed rollback is logged as leaving the data out of sync.
+func Test_backendSvcImpl_Delete_RollbackErrorLogging(t *testing.T) {
+	b := bsData.OneBackend()
+
+	fail := false
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		if fail {
+			return errors.New("disk full")
+		}
+		return nil
+	}
+	db := testHelpers.NewDatastoreMock()
+
+	setup := func(svc DataSvc) {
+		assert.EnsureNil(t, svc.SaveBackend(b), "backendSvcImpl.Save() returned an unexpected error")
+		fail = true
+		db.SaveError = NewErrorf(ErrDB, "db unavailable")
+	}
+	testAction := func(svc DataSvc) {
+		var derr *Error
+		output := testHelpers.CaptureLog(func() {
+			derr = svc.DeleteBackend(b.Name)
+		})
+
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.Delete() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrOutOfSync, fmt.Sprintf("backendSvcImpl.Delete() returned an unexpected error type: '%v'", derr.Type.String()))
+		assert.StringContains(t, output, "[WARN] Failed to roll back delete of backend '"+b.Name+"': db unavailable", "syncHAProxy() did not log the failed rollback")
+		assert.StringContains(t, output, "[WARN] Rollback failed - HAProxy config and data store are out of sync", "syncHAProxy() did not log the out of sync state")
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  dataSvcMocks{DB: db, HA: ha},
+	}.execute()
+}
+
+// Tests that a failed rollback is followed by a reconcile of the HAProxy config with the data store
+// when configured, which turns the out of sync error into a sync error if it succeeds.
+func Test_backendSvcImpl_Delete_RollbackError_Reconcile(t *testing.T) {
+	b := bsData.OneBackend()
+
+	failures := 0
+	writes := 0
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		writes++
+		if failures > 0 {
+			failures--
+			return errors.New("disk full")
+		}
+		return nil
+	}
+	db := testHelpers.NewDatastoreMock()
+
+	setup := func(svc DataSvc) {
+		assert.EnsureNil(t, svc.SaveBackend(b), "backendSvcImpl.Save() returned an unexpected error")
+		writes = 0
+		ha.reloadStrategies = nil
+		failures = 1
+		db.SaveError = NewErrorf(ErrDB, "db unavailable")
+	}
+	testAction := func(svc DataSvc) {
+		var derr *Error
+		output := testHelpers.CaptureLog(func() {
+			derr = svc.DeleteBackend(b.Name)
+		})
+
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.Delete() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrSync, "backendSvcImpl.Delete() returned an unexpected error type: '%v'", derr.Type)
+		assert.Equal(t, writes, 2, "backendSvcImpl.Delete() failed to rewrite the HAProxy config")
+		assert.Equal(t, len(ha.reloadStrategies), 1, "backendSvcImpl.Delete() failed to reload HAProxy after the reconcile")
+		assert.StringContains(t, output, "[INFO] Reconcile succeeded", "syncHAProxy() did not log the reconcile")
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  dataSvcMocks{DB: db, HA: ha},
+		Config: &Config{ReconcileOnOutOfSync: true},
+	}.execute()
+}
+
+// Tests that the out of sync error is returned when the reconcile after a failed rollback fails too.
+func Test_backendSvcImpl_Delete_RollbackError_ReconcileError(t *testing.T) {
+	b := bsData.OneBackend()
+
+	fail := false
+	writes := 0
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		writes++
+		if fail {
+			return errors.New("disk full")
+		}
+		return nil
+	}
+	db := testHelpers.NewDatastoreMock()
+
+	setup := func(svc DataSvc) {
+		assert.EnsureNil(t, svc.SaveBackend(b), "backendSvcImpl.Save() returned an unexpected error")
+		writes = 0
+		fail = true
+		db.SaveError = NewErrorf(ErrDB, "db unavailable")
+	}
+	testAction := func(svc DataSvc) {
+		var derr *Error
+		output := testHelpers.CaptureLog(func() {
+			derr = svc.DeleteBackend(b.Name)
+		})
+
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.Delete() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrOutOfSync, "backendSvcImpl.Delete() returned an unexpected error type: '%v'", derr.Type)
+		assert.Equal(t, writes, 2, "backendSvcImpl.Delete() failed to attempt the reconcile")
+		assert.StringContains(t, output, "[WARN] Reconcile failed", "syncHAProxy() did not log the failed reconcile")
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  dataSvcMocks{DB: db, HA: ha},
+		Config: &Config{ReconcileOnOutOfSync: true},
+	}.execute()
+}
+
 // ----------------------------------------------
 // backendSvcImpl.Delete TESTS
 // ----------------------------------------------
@@ -697,55 +1301,2158 @@ func Test_backendSvcImpl_Delete_SyncError(t *testing.T) {
 	}.execute()
 }
 
-// // ----------------------------------------------
-// // backendSvcImpl.getBackendKey TESTS
-// // ----------------------------------------------
+// Tests that a backend's reload strategy override selects the reload path used when it changes.
+func Test_backendSvcImpl_Save_ReloadStrategy(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Strategy string
+		Action   func(svc DataSvc, b *Backend) *Error
+		Expected string
+	}{
+		{
+			Name:     "SaveDefault",
+			Strategy: "",
+			Action:   func(svc DataSvc, b *Backend) *Error { return svc.SaveBackend(b) },
+			Expected: "",
+		},
+		{
+			Name:     "SaveSocket",
+			Strategy: reloadStrategySocket,
+			Action:   func(svc DataSvc, b *Backend) *Error { return svc.SaveBackend(b) },
+			Expected: reloadStrategySocket,
+		},
+		{
+			Name:     "SaveCommand",
+			Strategy: reloadStrategyCommand,
+			Action:   func(svc DataSvc, b *Backend) *Error { return svc.SaveBackend(b) },
+			Expected: reloadStrategyCommand,
+		},
+		{
+			Name:     "DeleteSocket",
+			Strategy: reloadStrategySocket,
+			Action:   func(svc DataSvc, b *Backend) *Error { return svc.DeleteBackend(b.Name) },
+			Expected: reloadStrategySocket,
+		},
+	}
 
-// func Test_backendSvcImpl_getBackendKey(t *testing.T) {
-// 	// setup test
-// 	svc := &dataSvcImpl{}
+	for _, tc := range testCases {
+		b := bsData.OneBackend()
+		b.ReloadStrategy = tc.Strategy
+		ha := testHelpers.NewHAProxyMock()
 
-// 	testCases := []struct{ Name, Result string }{
-// 		{
-// 			Name:   "name-without_spaces",
-// 			Result: "name-without_spaces",
-// 		},
-// 		{
-// 			Name:   "",
-// 			Result: "",
-// 		},
-// 		{
-// 			Name:   "name with spaces",
-// 			Result: "name_with_spaces",
-// 		},
-// 	}
+		setup := func(svc DataSvc) {
+			derr := svc.SaveBackend(b)
+			assert.EnsureNil(t, derr, "backendSvcImpl.Save() returned an unexpected error in test '%s': %v", tc.Name, derr)
+			ha.reloadStrategies = nil
+		}
+		testAction := func(svc DataSvc) {
+			derr := tc.Action(svc, b)
+			assert.EnsureNil(t, derr, "backendSvcImpl returned an unexpected error in test '%s': %v", tc.Name, derr)
+			assert.Equal(t, ha.reloadStrategies, []string{tc.Expected}, "backendSvcImpl used an unexpected reload strategy in test '%s'", tc.Name)
+		}
 
-// 	for _, testcase := range testCases {
-// 		backend := &Backend{Name: testcase.Name}
-// 		actual := svc.getBackendKey(backend)
-// 		assert.Equal(t, actual, testcase.Result, fmt.Sprintf("backendSvcImpl.getBackendKey() return an unexpected value with input '%v'", testcase.Name))
-// 	}
+		dataSvcTestCase{
+			Setup:  setup,
+			Action: testAction,
+			Mocks:  dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+			Config: &Config{HASocketPath: "/var/run/haproxy.sock"},
+		}.execute()
+	}
+}
+
+// Tests that the backendSvcImpl.Save() function rejects the socket reload strategy when no HAProxy
+// socket is configured.
+func Test_backendSvcImpl_Save_SocketReloadStrategyWithoutSocket(t *testing.T) {
+	b := bsData.OneBackend()
+	b.ReloadStrategy = reloadStrategySocket
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.Save() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrBadData, fmt.Sprintf("backendSvcImpl.Save() returned an unexpected error type: '%v'", derr.Type.String()))
+		assert.StringContains(t, derr.Error(), "no HAProxy socket (hasocket) is configured", "backendSvcImpl.Save() returned an unexpected error message")
+
+		r, _ := svc.GetBackend(b.Name)
+		assert.Nil(t, r, "backendSvcImpl.Save() saved a backend with an unusable reload strategy")
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
+// Tests that the backendSvcImpl.Save() function rejects an unknown reload strategy override.
+func Test_backendSvcImpl_Save_InvalidReloadStrategy(t *testing.T) {
+	b := bsData.OneBackend()
+	b.ReloadStrategy = "restart"
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.Save() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrBadData, fmt.Sprintf("backendSvcImpl.Save() returned an unexpected error type: '%v'", derr.Type.String()))
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
+// Tests that the backendSvcImpl.Save() function rejects members that share a host and port.
+func Test_backendSvcImpl_Save_DuplicateMemberAddresses(t *testing.T) {
+	testCases := [][]BackendMember{
+		{{Name: "node1", Host: "10.0.0.1", Port: 8080}, {Name: "node2", Host: "10.0.0.1", Port: 8080}},
+		{{Name: "node1", Host: "app.example.com", Port: 80}, {Name: "node2", Host: "APP.example.com", Port: 80}},
+		{{Name: "node1", Host: "::1", Port: 8080}, {Name: "node2", Host: "10.0.0.2", Port: 8080}, {Name: "node3", Host: "::1", Port: 8080}},
+	}
+
+	for _, members := range testCases {
+		b := bsData.OneBackend()
+		b.Members = members
+		testAction := func(svc DataSvc) {
+			derr := svc.SaveBackend(b)
+			assert.EnsureNotNil(t, derr, "backendSvcImpl.Save() failed to reject duplicate member addresses: %v", members)
+			assert.Equal(t, derr.Type, ErrBadData, fmt.Sprintf("backendSvcImpl.Save() returned an unexpected error type: '%v'", derr.Type.String()))
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+		}.execute()
+	}
+}
+
+// Tests that the backendSvcImpl.Save() function accepts members with distinct hosts and ports.
+func Test_backendSvcImpl_Save_DistinctMemberAddresses(t *testing.T) {
+	testCases := [][]BackendMember{
+		{{Name: "node1", Host: "10.0.0.1", Port: 8080}, {Name: "node2", Host: "10.0.0.1", Port: 8081}},
+		{{Name: "node1", Host: "10.0.0.1", Port: 8080}, {Name: "node2", Host: "10.0.0.2", Port: 8080}},
+		{{Name: "node1", Host: "::1", Port: 8080}, {Name: "node2", Host: "::2", Port: 8080}},
+	}
+
+	for _, members := range testCases {
+		b := bsData.OneBackend()
+		b.Members = members
+		testAction := func(svc DataSvc) {
+			derr := svc.SaveBackend(b)
+			assert.Nil(t, derr, "backendSvcImpl.Save() rejected distinct member addresses %v: %v", members, derr)
+		}
+
+		dataSvcTestCase{
+			Action: testAction,
+			Mocks:  defaultMocks(),
+		}.execute()
+	}
+}
+
+// Tests that frontend changes use the configured reload strategy.
+func Test_frontendSvcImpl_Save_ReloadStrategy(t *testing.T) {
+	ha := testHelpers.NewHAProxyMock()
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveFrontend(fsData.OneFrontend())
+		assert.EnsureNil(t, derr, "frontendSvcImpl.Save() returned an unexpected error: %v", derr)
+		assert.Equal(t, ha.reloadStrategies, []string{""}, "frontendSvcImpl.Save() used an unexpected reload strategy")
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// ----------------------------------------------
+// backendSvcImpl.DeleteBackendCascade TESTS
+// ----------------------------------------------
+
+// Tests that the backendSvcImpl.DeleteBackendCascade() function deletes the backend and clears the
+// frontends that reference it in a single sync.
+func Test_backendSvcImpl_DeleteBackendCascade(t *testing.T) {
+	b := bsData.OneBackend()
+	f1 := fsData.OneFrontend()
+	f1.DefaultBackend = b.Name
+	f2 := fsData.OtherFrontend()
+	f2.DefaultBackend = "other"
+
+	syncs := 0
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		syncs++
+		return nil
+	}
+
+	setup := func(svc DataSvc) {
+		assert.EnsureNil(t, svc.SaveBackend(b), "backendSvcImpl.Save() returned an unexpected error")
+		assert.EnsureNil(t, svc.SaveFrontend(f1), "frontendSvcImpl.Save() returned an unexpected error")
+		assert.EnsureNil(t, svc.SaveFrontend(f2), "frontendSvcImpl.Save() returned an unexpected error")
+		syncs = 0
+	}
+	testAction := func(svc DataSvc) {
+		derr := svc.DeleteBackendCascade(b.Name)
+		assert.EnsureNil(t, derr, "backendSvcImpl.DeleteBackendCascade() returned an unexpected error: %v", derr)
+		assert.Equal(t, syncs, 1, "backendSvcImpl.DeleteBackendCascade() synced HAProxy an unexpected number of times")
+	}
+	teardown := func(svc DataSvc) {
+		rb, _ := svc.GetBackend(b.Name)
+		assert.Nil(t, rb, "backendSvcImpl.DeleteBackendCascade() failed to delete the backend")
+		rf1, _ := svc.GetFrontend(f1.Name)
+		assert.Equal(t, rf1.DefaultBackend, "", "backendSvcImpl.DeleteBackendCascade() failed to clear the referencing frontend")
+		rf2, _ := svc.GetFrontend(f2.Name)
+		assert.Equal(t, rf2.DefaultBackend, "other", "backendSvcImpl.DeleteBackendCascade() changed an unrelated frontend")
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: teardown,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that the backendSvcImpl.DeleteBackendCascade() function drops the routing rules to the
+// deleted backend, including those of a frontend that also uses it as its default backend.
+func Test_backendSvcImpl_DeleteBackendCascade_RoutingRules(t *testing.T) {
+	b := &Backend{Name: "api", Mode: "http"}
+	web := &Backend{Name: "web", Mode: "http"}
+	f1 := &Frontend{Name: "both", DefaultBackend: b.Name, RoutingRules: []Rule{
+		{Condition: "path_beg /api", Backend: b.Name},
+		{Condition: "path_beg /web", Backend: web.Name},
+	}}
+	f2 := &Frontend{Name: "rule-only", DefaultBackend: web.Name, RoutingRules: []Rule{{Condition: "path_beg /api", Backend: b.Name}}}
+
+	setup := func(svc DataSvc) {
+		for _, b := range []*Backend{b, web} {
+			assert.EnsureNil(t, svc.SaveBackend(b), "backendSvcImpl.Save() returned an unexpected error")
+		}
+		for _, f := range []*Frontend{f1, f2} {
+			assert.EnsureNil(t, svc.SaveFrontend(f), "frontendSvcImpl.Save() returned an unexpected error")
+		}
+	}
+	testAction := func(svc DataSvc) {
+		derr := svc.DeleteBackendCascade(b.Name)
+		assert.EnsureNil(t, derr, "backendSvcImpl.DeleteBackendCascade() returned an unexpected error: %v", derr)
+
+		rf1, _ := svc.GetFrontend(f1.Name)
+		assert.Equal(t, rf1.DefaultBackend, "", "backendSvcImpl.DeleteBackendCascade() failed to clear the default backend")
+		assert.Equal(t, rf1.RoutingRules, []Rule{{Condition: "path_beg /web", Backend: web.Name}}, "backendSvcImpl.DeleteBackendCascade() left unexpected routing rules")
+		rf2, _ := svc.GetFrontend(f2.Name)
+		assert.Equal(t, rf2.DefaultBackend, web.Name, "backendSvcImpl.DeleteBackendCascade() changed an unrelated default backend")
+		assert.Equal(t, len(rf2.RoutingRules), 0, "backendSvcImpl.DeleteBackendCascade() left a routing rule to the deleted backend")
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
+// Tests that the backendSvcImpl.DeleteBackendCascade() function rolls back the delete and the
+// frontend changes if the sync fails.
+func Test_backendSvcImpl_DeleteBackendCascade_SyncError(t *testing.T) {
+	b := bsData.OneBackend()
+	f := fsData.OneFrontend()
+	f.DefaultBackend = b.Name
+
+	fail := false
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		if fail {
+			return errors.New("test")
+		}
+		return nil
+	}
+
+	setup := func(svc DataSvc) {
+		assert.EnsureNil(t, svc.SaveBackend(b), "backendSvcImpl.Save() returned an unexpected error")
+		assert.EnsureNil(t, svc.SaveFrontend(f), "frontendSvcImpl.Save() returned an unexpected error")
+		fail = true
+	}
+	testAction := func(svc DataSvc) {
+		derr := svc.DeleteBackendCascade(b.Name)
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.DeleteBackendCascade() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrSync, fmt.Sprintf("backendSvcImpl.DeleteBackendCascade() returned an unexpected error type: '%v'", derr.Type.String()))
+	}
+	teardown := func(svc DataSvc) {
+		rb, _ := svc.GetBackend(b.Name)
+		assert.NotNil(t, rb, "backendSvcImpl.DeleteBackendCascade() failed to rollback the backend delete")
+		rf, _ := svc.GetFrontend(f.Name)
+		assert.Equal(t, rf.DefaultBackend, b.Name, "backendSvcImpl.DeleteBackendCascade() failed to rollback the frontend change")
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: teardown,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that the backendSvcImpl.DeleteBackendCascade() function returns an error for a non-existent backend.
+func Test_backendSvcImpl_DeleteBackendCascade_NonExistentBackend(t *testing.T) {
+	testAction := func(svc DataSvc) {
+		derr := svc.DeleteBackendCascade("missing")
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.DeleteBackendCascade() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrNotFound, fmt.Sprintf("backendSvcImpl.DeleteBackendCascade() returned an unexpected error type: '%v'", derr.Type.String()))
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
+// ----------------------------------------------
+// backendSvcImpl.SwapBackendMembers TESTS
+// ----------------------------------------------
+
+// Tests that the backendSvcImpl.SwapBackendMembers() function disables the old members in a first
+// sync, waits for them to drain, and replaces them with the new members in a second sync.
+func Test_backendSvcImpl_SwapBackendMembers(t *testing.T) {
+	b := bsData.OneBackend()
+	newMembers := bsData.OtherBackend().Members
+
+	events := []string{}
+	synced := Backends{}
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		events = append(events, "sync")
+		synced = append(synced, backends[0])
+		return nil
+	}
+
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "backendSvcImpl.Save() returned an unexpected error: %v", derr)
+		svc.(*dataSvcImpl).sleep = func(d time.Duration) {
+			assert.Equal(t, d, 30*time.Second, "backendSvcImpl.SwapBackendMembers() waited an unexpected duration")
+			events = append(events, "drain")
+		}
+		events, synced = []string{}, Backends{}
+	}
+	testAction := func(svc DataSvc) {
+		swapped, derr := svc.SwapBackendMembers(b.Name, newMembers)
+		assert.EnsureNil(t, derr, "backendSvcImpl.SwapBackendMembers() returned an unexpected error: %v", derr)
+		assert.Equal(t, swapped.Members, newMembers, "backendSvcImpl.SwapBackendMembers() returned unexpected members")
+
+		// assert the two-phase sequence
+		assert.EnsureEqual(t, events, []string{"sync", "drain", "sync"}, "backendSvcImpl.SwapBackendMembers() executed an unexpected sequence")
+		assert.EnsureEqual(t, len(synced[0].Members), len(b.Members), "backendSvcImpl.SwapBackendMembers() synced unexpected members in the drain phase")
+		for _, m := range synced[0].Members {
+			assert.True(t, m.Disabled, "backendSvcImpl.SwapBackendMembers() failed to disable member %s in the drain phase", m.Name)
+		}
+		assert.EnsureEqual(t, len(synced[1].Members), len(newMembers), "backendSvcImpl.SwapBackendMembers() synced unexpected members in the swap phase")
+		assert.Equal(t, synced[1].Members[0].Name, newMembers[0].Name, "backendSvcImpl.SwapBackendMembers() synced unexpected members in the swap phase")
+		assert.False(t, synced[1].Members[0].Disabled, "backendSvcImpl.SwapBackendMembers() synced a disabled new member")
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+		Config:   &Config{DrainWait: "30s"},
+	}.execute()
+}
+
+// Tests that the backendSvcImpl.SwapBackendMembers() function stops without draining if the first
+// sync fails.
+func Test_backendSvcImpl_SwapBackendMembers_SyncError(t *testing.T) {
+	b := bsData.OneBackend()
+
+	pass := 0
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		pass++
+		if pass == 2 {
+			return errors.New("test")
+		}
+		return nil
+	}
+
+	drained := false
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "backendSvcImpl.Save() returned an unexpected error: %v", derr)
+		svc.(*dataSvcImpl).sleep = func(time.Duration) { drained = true }
+	}
+	testAction := func(svc DataSvc) {
+		_, derr := svc.SwapBackendMembers(b.Name, bsData.OtherBackend().Members)
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.SwapBackendMembers() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrSync, fmt.Sprintf("backendSvcImpl.SwapBackendMembers() returned an unexpected error type: '%v'", derr.Type.String()))
+		assert.False(t, drained, "backendSvcImpl.SwapBackendMembers() waited for a drain after a failed sync")
+	}
+	teardown := func(svc DataSvc) {
+		// assert the old members were left enabled
+		r, _ := svc.GetBackend(b.Name)
+		for _, m := range r.Members {
+			assert.False(t, m.Disabled, "backendSvcImpl.SwapBackendMembers() failed to rollback the disabled member %s", m.Name)
+		}
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: teardown,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that the backendSvcImpl.SwapBackendMembers() function rejects an invalid set of new members
+// without disabling the existing members.
+func Test_backendSvcImpl_SwapBackendMembers_InvalidMembers(t *testing.T) {
+	b := bsData.OneBackend()
+	newMembers := bsData.OtherBackend().Members
+	newMembers = append(newMembers, newMembers[0])
+	newMembers[1].Name = "duplicate"
+
+	syncs := 0
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		syncs++
+		return nil
+	}
+
+	drained := false
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "backendSvcImpl.Save() returned an unexpected error: %v", derr)
+		svc.(*dataSvcImpl).sleep = func(time.Duration) { drained = true }
+		syncs = 0
+	}
+	testAction := func(svc DataSvc) {
+		_, derr := svc.SwapBackendMembers(b.Name, newMembers)
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.SwapBackendMembers() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrBadData, fmt.Sprintf("backendSvcImpl.SwapBackendMembers() returned an unexpected error type: '%v'", derr.Type.String()))
+		assert.Equal(t, syncs, 0, "backendSvcImpl.SwapBackendMembers() synced HAProxy for an invalid swap")
+		assert.False(t, drained, "backendSvcImpl.SwapBackendMembers() waited for a drain for an invalid swap")
+	}
+	teardown := func(svc DataSvc) {
+		r, _ := svc.GetBackend(b.Name)
+		assert.EnsureEqual(t, len(r.Members), len(b.Members), "backendSvcImpl.SwapBackendMembers() changed the members of an invalid swap")
+		for _, m := range r.Members {
+			assert.False(t, m.Disabled, "backendSvcImpl.SwapBackendMembers() disabled the member %s for an invalid swap", m.Name)
+		}
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: teardown,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that the backendSvcImpl.SwapBackendMembers() function restores the original members if the
+// second sync fails, rather than leaving the backend drained.
+func Test_backendSvcImpl_SwapBackendMembers_SwapSyncError(t *testing.T) {
+	b := bsData.OneBackend()
+
+	pass := 0
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		pass++
+		if pass == 3 {
+			return errors.New("test")
+		}
+		return nil
+	}
+
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "backendSvcImpl.Save() returned an unexpected error: %v", derr)
+		svc.(*dataSvcImpl).sleep = func(time.Duration) {}
+	}
+	testAction := func(svc DataSvc) {
+		_, derr := svc.SwapBackendMembers(b.Name, bsData.OtherBackend().Members)
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.SwapBackendMembers() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrSync, fmt.Sprintf("backendSvcImpl.SwapBackendMembers() returned an unexpected error type: '%v'", derr.Type.String()))
+	}
+	teardown := func(svc DataSvc) {
+		r, _ := svc.GetBackend(b.Name)
+		assert.EnsureEqual(t, len(r.Members), len(b.Members), "backendSvcImpl.SwapBackendMembers() failed to restore the original members")
+		for i, m := range r.Members {
+			assert.Equal(t, m.Name, b.Members[i].Name, "backendSvcImpl.SwapBackendMembers() failed to restore the original members")
+			assert.False(t, m.Disabled, "backendSvcImpl.SwapBackendMembers() left the member %s disabled", m.Name)
+		}
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: teardown,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that the backendSvcImpl.SwapBackendMembers() function keeps the changes made to the backend
+// while its members were draining.
+func Test_backendSvcImpl_SwapBackendMembers_ChangedDuringDrain(t *testing.T) {
+	b := bsData.OneBackend()
+	newMembers := bsData.OtherBackend().Members
+
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "backendSvcImpl.Save() returned an unexpected error: %v", derr)
+		svc.(*dataSvcImpl).sleep = func(time.Duration) {
+			r, _ := svc.GetBackend(b.Name)
+			r.Version = "2.0.0"
+			derr := svc.SaveBackend(r)
+			assert.EnsureNil(t, derr, "backendSvcImpl.Save() returned an unexpected error: %v", derr)
+		}
+	}
+	testAction := func(svc DataSvc) {
+		swapped, derr := svc.SwapBackendMembers(b.Name, newMembers)
+		assert.EnsureNil(t, derr, "backendSvcImpl.SwapBackendMembers() returned an unexpected error: %v", derr)
+		assert.Equal(t, swapped.Members, newMembers, "backendSvcImpl.SwapBackendMembers() returned unexpected members")
+
+		r, _ := svc.GetBackend(b.Name)
+		assert.Equal(t, r.Version, "2.0.0", "backendSvcImpl.SwapBackendMembers() overwrote a change made during the drain")
+		assert.Equal(t, r.Members, newMembers, "backendSvcImpl.SwapBackendMembers() failed to save the new members")
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+	}.execute()
+}
+
+// Tests that the backendSvcImpl.SwapBackendMembers() function returns an error for a non-existent backend.
+func Test_backendSvcImpl_SwapBackendMembers_NonExistentBackend(t *testing.T) {
+	testAction := func(svc DataSvc) {
+		_, derr := svc.SwapBackendMembers("missing", BackendMembers{})
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.SwapBackendMembers() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrNotFound, fmt.Sprintf("backendSvcImpl.SwapBackendMembers() returned an unexpected error type: '%v'", derr.Type.String()))
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
+// ----------------------------------------------
+// dataSvcImpl.WithTransaction TESTS
+// ----------------------------------------------
+
+// Tests that the writes made within a transaction are committed followed by a single sync.
+func Test_dataSvcImpl_WithTransaction(t *testing.T) {
+	b := bsData.OneBackend()
+	f := fsData.OneFrontend()
+
+	syncs := 0
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		syncs++
+		return nil
+	}
+
+	testAction := func(svc DataSvc) {
+		derr := svc.WithTransaction(func(tx DataSvc) error {
+			if derr := tx.SaveBackend(b); derr != nil {
+				return derr
+			}
+			if derr := tx.SaveFrontend(f); derr != nil {
+				return derr
+			}
+			return nil
+		})
+		assert.EnsureNil(t, derr, "dataSvcImpl.WithTransaction() returned an unexpected error: %v", derr)
+		assert.Equal(t, syncs, 1, "dataSvcImpl.WithTransaction() synced HAProxy an unexpected number of times")
+
+		// assert that both writes were committed
+		rb, derr := svc.GetBackend(b.Name)
+		assert.EnsureNil(t, derr, "dataSvcImpl.GetBackend() returned an unexpected error: %v", derr)
+		assert.NotNil(t, rb, "dataSvcImpl.WithTransaction() failed to commit the backend")
+		rf, derr := svc.GetFrontend(f.Name)
+		assert.EnsureNil(t, derr, "dataSvcImpl.GetFrontend() returned an unexpected error: %v", derr)
+		assert.NotNil(t, rf, "dataSvcImpl.WithTransaction() failed to commit the frontend")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that dataSvcImpl.SaveTemplate() stores the template and renders the HAProxy config with it.
+func Test_dataSvcImpl_SaveTemplate(t *testing.T) {
+	b := bsData.OneBackend()
+	stored := "{{range .Backends}}backend {{.Name}}\n{{end}}"
+	db := testHelpers.NewDatastoreMock()
+	ha := testHelpers.NewHAProxyMock()
+	var rendered string
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		if ha.Template() == nil {
+			return nil
+		}
+		var buf bytes.Buffer
+		err := ha.Template().Execute(&buf, struct{ Backends Backends }{backends})
+		rendered = buf.String()
+		return err
+	}
+
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+	}
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveTemplate(stored)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveTemplate() returned an unexpected error: %v", derr)
+		text, derr := svc.GetTemplate()
+		assert.EnsureNil(t, derr, "dataSvcImpl.GetTemplate() returned an unexpected error: %v", derr)
+		assert.Equal(t, text, stored, "dataSvcImpl.SaveTemplate() failed to store the template")
+		assert.Equal(t, rendered, "backend "+b.Name+"\n", "dataSvcImpl.SaveTemplate() did not render the HAProxy config with the template")
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  dataSvcMocks{DB: db, HA: ha},
+	}.execute()
+}
+
+// Tests that dataSvcImpl.SaveTemplate() rejects a template that can't be parsed.
+func Test_dataSvcImpl_SaveTemplate_Invalid(t *testing.T) {
+	db := testHelpers.NewDatastoreMock()
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveTemplate("{{range .Backends}")
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveTemplate() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrBadData, "dataSvcImpl.SaveTemplate() returned an unexpected error type: '%v'", derr.Type.String())
+		assert.Equal(t, db.Template, "", "dataSvcImpl.SaveTemplate() stored an invalid template")
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  dataSvcMocks{DB: db, HA: testHelpers.NewHAProxyMock()},
+	}.execute()
+}
+
+// Tests that dataSvcImpl.SaveTemplate() restores the previous template if the HAProxy sync fails.
+func Test_dataSvcImpl_SaveTemplate_Rollback(t *testing.T) {
+	db := testHelpers.NewDatastoreMock()
+	db.Template = "old"
+	ha := testHelpers.NewHAProxyMock()
+	old, _ := template.New("test").Parse("old")
+	ha.template = old
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		if ha.Template() != old {
+			return errors.New("test")
+		}
+		return nil
+	}
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveTemplate("new")
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveTemplate() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrSync, "dataSvcImpl.SaveTemplate() returned an unexpected error type: '%v'", derr.Type.String())
+		assert.Equal(t, db.Template, "old", "dataSvcImpl.SaveTemplate() failed to restore the stored template")
+		assert.Equal(t, ha.Template(), old, "dataSvcImpl.SaveTemplate() failed to restore the template in use")
+	}
+
+	dataSvcTestCase{
+		Action: testAction,
+		Mocks:  dataSvcMocks{DB: db, HA: ha},
+	}.execute()
+}
+
+// Tests that dataSvcImpl.SweepExpired() deletes only the expired frontends and backends, syncing
+// HAProxy once, and clears the default backend of remaining frontends that referenced them.
+func Test_dataSvcImpl_SweepExpired(t *testing.T) {
+	now := time.Now()
+	live := &Backend{Name: "live", Mode: "http"}
+	preview := &Backend{Name: "preview", Mode: "http", ExpiresAt: now.Add(-time.Minute)}
+	later := &Backend{Name: "later", Mode: "http", ExpiresAt: now.Add(time.Hour)}
+	app := &Frontend{Name: "app", DefaultBackend: "preview"}
+	previewFE := &Frontend{Name: "preview-fe", DefaultBackend: "preview", ExpiresAt: now}
+
+	syncs := 0
+	ha := testHelpers.NewHAProxyMock()
+	setup := func(svc DataSvc) {
+		for _, b := range []*Backend{live, preview, later} {
+			derr := svc.SaveBackend(b)
+			assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		}
+		for _, f := range []*Frontend{app, previewFE} {
+			derr := svc.SaveFrontend(f)
+			assert.EnsureNil(t, derr, "dataSvcImpl.SaveFrontend() returned an unexpected error: %v", derr)
+		}
+		ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+			syncs++
+			return nil
+		}
+	}
+	testAction := func(svc DataSvc) {
+		count, derr := svc.SweepExpired(now)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SweepExpired() returned an unexpected error: %v", derr)
+		assert.Equal(t, count, 2, "dataSvcImpl.SweepExpired() deleted an unexpected number of entities")
+		assert.Equal(t, syncs, 1, "dataSvcImpl.SweepExpired() synced HAProxy an unexpected number of times")
+
+		backends, _ := svc.GetAllBackends()
+		names := []string{}
+		for _, b := range backends {
+			names = append(names, b.Name)
+		}
+		assert.Equal(t, names, []string{"live", "later"}, "dataSvcImpl.SweepExpired() deleted unexpected backends")
+		frontends, _ := svc.GetAllFrontends()
+		assert.EnsureEqual(t, len(frontends), 1, "dataSvcImpl.SweepExpired() deleted unexpected frontends")
+		assert.Equal(t, frontends[0].Name, app.Name, "dataSvcImpl.SweepExpired() deleted an unexpired frontend")
+		assert.Equal(t, frontends[0].DefaultBackend, "", "dataSvcImpl.SweepExpired() did not clear the default backend")
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that dataSvcImpl.SweepExpired() deletes an expired backend that a remaining frontend both
+// routes to and uses as its default backend, dropping the frontend's routing rules to it.
+func Test_dataSvcImpl_SweepExpired_RoutingRules(t *testing.T) {
+	now := time.Now()
+	preview := &Backend{Name: "preview", Mode: "http", ExpiresAt: now.Add(-time.Minute)}
+	app := &Frontend{Name: "app", DefaultBackend: "preview", RoutingRules: []Rule{{Condition: "path_beg /preview", Backend: "preview"}}}
+
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(preview)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		derr = svc.SaveFrontend(app)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveFrontend() returned an unexpected error: %v", derr)
+	}
+	testAction := func(svc DataSvc) {
+		count, derr := svc.SweepExpired(now)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SweepExpired() returned an unexpected error: %v", derr)
+		assert.Equal(t, count, 1, "dataSvcImpl.SweepExpired() deleted an unexpected number of entities")
+
+		f, _ := svc.GetFrontend(app.Name)
+		assert.EnsureNotNil(t, f, "dataSvcImpl.SweepExpired() deleted an unexpired frontend")
+		assert.Equal(t, f.DefaultBackend, "", "dataSvcImpl.SweepExpired() did not clear the default backend")
+		assert.Equal(t, len(f.RoutingRules), 0, "dataSvcImpl.SweepExpired() left a routing rule to the deleted backend")
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
+// Tests that dataSvcImpl.SweepExpired() doesn't sync HAProxy when nothing has expired.
+func Test_dataSvcImpl_SweepExpired_NoneExpired(t *testing.T) {
+	now := time.Now()
+	syncs := 0
+	ha := testHelpers.NewHAProxyMock()
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(&Backend{Name: "later", ExpiresAt: now.Add(time.Second)})
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+			syncs++
+			return nil
+		}
+	}
+	testAction := func(svc DataSvc) {
+		count, derr := svc.SweepExpired(now)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SweepExpired() returned an unexpected error: %v", derr)
+		assert.Equal(t, count, 0, "dataSvcImpl.SweepExpired() deleted an unexpired backend")
+		assert.Equal(t, syncs, 0, "dataSvcImpl.SweepExpired() synced HAProxy when nothing expired")
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that an error part way through a transaction rolls back all of its writes without syncing.
+func Test_dataSvcImpl_WithTransaction_Rollback(t *testing.T) {
+	b := bsData.OneBackend()
+	other := bsData.OtherBackend()
+	f := fsData.OneFrontend()
+	originalMode := b.Mode
+
+	syncs := 0
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		syncs++
+		return nil
+	}
+
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		syncs = 0
+	}
+	testAction := func(svc DataSvc) {
+		derr := svc.WithTransaction(func(tx DataSvc) error {
+			updated := *b
+			updated.Mode = "tcp"
+			if derr := tx.SaveBackend(&updated); derr != nil {
+				return derr
+			}
+			if derr := tx.SaveBackend(other); derr != nil {
+				return derr
+			}
+			if derr := tx.SaveFrontend(f); derr != nil {
+				return derr
+			}
+			return NewErrorf(ErrBadData, "test")
+		})
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.WithTransaction() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrBadData, fmt.Sprintf("dataSvcImpl.WithTransaction() returned an unexpected error type: '%v'", derr.Type.String()))
+		assert.Equal(t, syncs, 0, "dataSvcImpl.WithTransaction() synced HAProxy despite the transaction failing")
+	}
+	teardown := func(svc DataSvc) {
+		// assert that every write was rolled back
+		rb, derr := svc.GetBackend(b.Name)
+		assert.EnsureNil(t, derr, "dataSvcImpl.GetBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, rb.Mode, originalMode, "dataSvcImpl.WithTransaction() failed to rollback the backend update")
+		ro, derr := svc.GetBackend(other.Name)
+		assert.EnsureNil(t, derr, "dataSvcImpl.GetBackend() returned an unexpected error: %v", derr)
+		assert.Nil(t, ro, "dataSvcImpl.WithTransaction() failed to rollback the backend create")
+		rf, derr := svc.GetFrontend(f.Name)
+		assert.EnsureNil(t, derr, "dataSvcImpl.GetFrontend() returned an unexpected error: %v", derr)
+		assert.Nil(t, rf, "dataSvcImpl.WithTransaction() failed to rollback the frontend create")
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: teardown,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that a failed sync at commit rolls back all of the writes made within a transaction.
+func Test_dataSvcImpl_WithTransaction_SyncError(t *testing.T) {
+	b := bsData.OneBackend()
+	f := fsData.OneFrontend()
+
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		return errors.New("test")
+	}
+
+	testAction := func(svc DataSvc) {
+		derr := svc.WithTransaction(func(tx DataSvc) error {
+			if derr := tx.SaveBackend(b); derr != nil {
+				return derr
+			}
+			if derr := tx.SaveFrontend(f); derr != nil {
+				return derr
+			}
+			return nil
+		})
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.WithTransaction() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrSync, fmt.Sprintf("dataSvcImpl.WithTransaction() returned an unexpected error type: '%v'", derr.Type.String()))
+	}
+	teardown := func(svc DataSvc) {
+		rb, _ := svc.GetBackend(b.Name)
+		assert.Nil(t, rb, "dataSvcImpl.WithTransaction() failed to rollback the backend create")
+		rf, _ := svc.GetFrontend(f.Name)
+		assert.Nil(t, rf, "dataSvcImpl.WithTransaction() failed to rollback the frontend create")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: teardown,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// // ----------------------------------------------
+// // backendSvcImpl.getBackendKey TESTS
+// // ----------------------------------------------
+
+// func Test_backendSvcImpl_getBackendKey(t *testing.T) {
+// 	// setup test
+// 	svc := &dataSvcImpl{}
+
+// 	testCases := []struct{ Name, Result string }{
+// 		{
+// 			Name:   "name-without_spaces",
+// 			Result: "name-without_spaces",
+// 		},
+// 		{
+// 			Name:   "",
+// 			Result: "",
+// 		},
+// 		{
+// 			Name:   "name with spaces",
+// 			Result: "name_with_spaces",
+// 		},
+// 	}
+
+// 	for _, testcase := range testCases {
+// 		backend := &Backend{Name: testcase.Name}
+// 		actual := svc.getBackendKey(backend)
+// 		assert.Equal(t, actual, testcase.Result, fmt.Sprintf("backendSvcImpl.getBackendKey() return an unexpected value with input '%v'", testcase.Name))
+// 	}
+// }
+
+// // This test generates 1000 random UTF-8 strings for the Backend name field
+// // and uses the generated strings to test the getBackendKey function.
+// func Test_backendSvcImpl_getBackendKey_QuickCheck(t *testing.T) {
+// 	// setup test
+// 	svc := &backendSvcImpl{}
+// 	count := 0
+
+// 	f := func(name string) bool {
+// 		count++
+
+// 		t.Logf("Test %d: Testing getBackendKey with name (%v)\n", count, name)
+
+// 		backend := &Backend{Name: name}
+// 		actual := svc.getBackendKey(backend)
+
+// 		return strings.Replace(name, " ", "_", -1) == actual
+// 	}
+// 	config := &quick.Config{MaxCount: 1000}
+// 	if err := quick.Check(f, config); err != nil {
+// 		t.Error(err)
+// 	}
 // }
 
-// // This test generates 1000 random UTF-8 strings for the Backend name field
-// // and uses the generated strings to test the getBackendKey function.
-// func Test_backendSvcImpl_getBackendKey_QuickCheck(t *testing.T) {
-// 	// setup test
-// 	svc := &backendSvcImpl{}
-// 	count := 0
+// Tests that the configured default mode is applied to backends and frontends saved without one.
+func Test_dataSvcImpl_Save_DefaultMode(t *testing.T) {
+	b := bsData.OneBackend()
+	b.Mode = ""
+	f := fsData.OneFrontend()
+	f.Mode = ""
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		derr = svc.SaveFrontend(f)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveFrontend() returned an unexpected error: %v", derr)
+
+		rb, _ := svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, rb, "dataSvcImpl.SaveBackend() failed to save the backend")
+		assert.Equal(t, rb.Mode, modeTCP, "dataSvcImpl.SaveBackend() failed to apply the default mode")
+		rf, _ := svc.GetFrontend(f.Name)
+		assert.EnsureNotNil(t, rf, "dataSvcImpl.SaveFrontend() failed to save the frontend")
+		assert.Equal(t, rf.Mode, modeTCP, "dataSvcImpl.SaveFrontend() failed to apply the default mode")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   &Config{DefaultMode: modeTCP},
+	}.execute()
+}
+
+// Tests that a backend saved without a mode is validated with the default mode, so that a health
+// check is accepted when the default mode is http and rejected when it's tcp.
+func Test_dataSvcImpl_Save_DefaultMode_HealthCheck(t *testing.T) {
+	for _, mode := range []string{modeHTTP, modeTCP} {
+		b := bsData.OneBackend()
+		b.Mode = ""
+		b.HealthCheck = "GET /health"
+
+		testAction := func(svc DataSvc) {
+			derr := svc.SaveBackend(b)
+			if mode == modeTCP {
+				assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveBackend() failed to return an expected error")
+				assert.Equal(t, derr.Type, ErrBadData, "dataSvcImpl.SaveBackend() returned an unexpected error type: '%v'", derr.Type)
+				return
+			}
+			assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+			rb, _ := svc.GetBackend(b.Name)
+			assert.EnsureNotNil(t, rb, "dataSvcImpl.SaveBackend() failed to save the backend")
+			assert.Equal(t, rb.Mode, modeHTTP, "dataSvcImpl.SaveBackend() failed to apply the default mode")
+		}
+
+		dataSvcTestCase{
+			Setup:    nil,
+			Action:   testAction,
+			Teardown: nil,
+			Mocks:    defaultMocks(),
+			Config:   &Config{DefaultMode: mode},
+		}.execute()
+	}
+}
+
+// Tests that the configured default mode does not replace an explicit mode.
+func Test_dataSvcImpl_Save_ExplicitMode(t *testing.T) {
+	b := bsData.OneBackend()
+	b.Mode = modeHealth
+	f := fsData.OneFrontend()
+	f.Mode = modeHTTP
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		derr = svc.SaveFrontend(f)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveFrontend() returned an unexpected error: %v", derr)
+
+		rb, _ := svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, rb, "dataSvcImpl.SaveBackend() failed to save the backend")
+		assert.Equal(t, rb.Mode, modeHealth, "dataSvcImpl.SaveBackend() replaced an explicit mode")
+		rf, _ := svc.GetFrontend(f.Name)
+		assert.EnsureNotNil(t, rf, "dataSvcImpl.SaveFrontend() failed to save the frontend")
+		assert.Equal(t, rf.Mode, modeHTTP, "dataSvcImpl.SaveFrontend() replaced an explicit mode")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   &Config{DefaultMode: modeTCP},
+	}.execute()
+}
+
+// Tests that the dataSvcImpl.HeartbeatMembers() function updates the existing members without
+// syncing HAProxy, and reports the heartbeats that don't match a member.
+func Test_dataSvcImpl_HeartbeatMembers(t *testing.T) {
+	b := bsData.OneBackendMultiMembers()
+	old := time.Now().Add(-time.Hour)
+	for i := range b.Members {
+		b.Members[i].LastKnown = old
+	}
+
+	synced := false
+	ha := testHelpers.NewHAProxyMock()
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+			synced = true
+			return nil
+		}
+	}
+	testAction := func(svc DataSvc) {
+		start := time.Now()
+		beats := []MemberHeartbeat{
+			{Backend: b.Name, Member: b.Members[0].Name},
+			{Backend: b.Name, Member: "missing-member"},
+			{Backend: "missing-backend", Member: b.Members[1].Name},
+		}
+		notFound, derr := svc.HeartbeatMembers(beats)
+		assert.EnsureNil(t, derr, "dataSvcImpl.HeartbeatMembers() returned an unexpected error: %v", derr)
+		assert.Equal(t, notFound, beats[1:], "dataSvcImpl.HeartbeatMembers() reported unexpected missing members")
+		assert.False(t, synced, "dataSvcImpl.HeartbeatMembers() synced HAProxy")
+
+		r, _ := svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.HeartbeatMembers() lost the backend")
+		assert.False(t, r.Members[0].LastKnown.Before(start), "dataSvcImpl.HeartbeatMembers() failed to update the member's LastKnown time")
+		assert.True(t, r.Members[1].LastKnown.Equal(old), "dataSvcImpl.HeartbeatMembers() updated a member without a heartbeat")
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that heartbeats, member metadata and member versions update the backend's modification
+// time, so that the backend is listed by a modifiedSince query.
+func Test_dataSvcImpl_MemberUpdates_ModifiedAt(t *testing.T) {
+	b := bsData.OneBackendMultiMembers()
+	b.ModifiedAt = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	db := testHelpers.NewDatastoreMock()
+
+	updates := map[string]func(svc DataSvc) *Error{
+		"HeartbeatMembers": func(svc DataSvc) *Error {
+			_, derr := svc.HeartbeatMembers([]MemberHeartbeat{{Backend: b.Name, Member: b.Members[0].Name}})
+			return derr
+		},
+		"SaveBackendMemberMeta": func(svc DataSvc) *Error {
+			return svc.SaveBackendMemberMeta(b.Name, b.Members[0].Name, map[string]string{"az": "us-east-1a"})
+		},
+		"SetBackendMemberVersions": func(svc DataSvc) *Error {
+			_, derr := svc.SetBackendMemberVersions(b.Name, "1.3.0", false)
+			return derr
+		},
+	}
+
+	testAction := func(svc DataSvc) {
+		for name, update := range updates {
+			// store the backend directly so that it keeps its old modification time
+			derr := db.SaveBackend(b)
+			assert.EnsureNil(t, derr, "DatastoreMock.SaveBackend() returned an unexpected error: %v", derr)
+
+			before := time.Now().UTC()
+			derr = update(svc)
+			assert.EnsureNil(t, derr, "dataSvcImpl.%s() returned an unexpected error: %v", name, derr)
+
+			r, _ := svc.GetBackend(b.Name)
+			assert.EnsureNotNil(t, r, "dataSvcImpl.%s() lost the backend", name)
+			assert.False(t, r.ModifiedAt.Before(before), "dataSvcImpl.%s() did not update the modification time: %v", name, r.ModifiedAt)
+		}
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: db, HA: testHelpers.NewHAProxyMock()},
+	}.execute()
+}
+
+// pausingDatastore is a Datastore that pauses after the first backend read made once it's armed, so
+// that a concurrent write can be attempted between the read and the write of a read-modify-write.
+type pausingDatastore struct {
+	Datastore
+	armed bool
+	read  chan struct{}
+}
+
+func (db *pausingDatastore) GetBackend(key string) (*Backend, *Error) {
+	b, derr := db.Datastore.GetBackend(key)
+	if db.armed {
+		db.armed = false
+		close(db.read)
+		time.Sleep(50 * time.Millisecond)
+	}
+	return b, derr
+}
+
+// asserts that the given backend member update, which reads and saves the whole backend, doesn't
+// revert the members saved by a concurrent backend save
+func testConcurrentMemberUpdate(t *testing.T, name string, update func(svc DataSvc, b *Backend) *Error) {
+	b := bsData.OneBackendMultiMembers()
+	newMembers := bsData.OtherBackend().Members
+	db := &pausingDatastore{Datastore: testHelpers.NewDatastoreMock(), read: make(chan struct{})}
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b.Copy())
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+
+		db.armed = true
+		done := make(chan *Error)
+		go func() { done <- update(svc, b) }()
+
+		// replace the members while the update holds the backend it read
+		<-db.read
+		saved := b.Copy()
+		saved.Members = newMembers
+		derr = svc.SaveBackend(saved)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		assert.EnsureNil(t, <-done, "dataSvcImpl.%s() returned an unexpected error", name)
+
+		r, _ := svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.%s() lost the backend", name)
+		assert.EnsureEqual(t, len(r.Members), len(newMembers), "dataSvcImpl.%s() reverted a concurrent save of the members", name)
+		assert.Equal(t, r.Members[0].Name, newMembers[0].Name, "dataSvcImpl.%s() reverted a concurrent save of the members", name)
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: db, HA: testHelpers.NewHAProxyMock()},
+	}.execute()
+}
+
+// Tests that a heartbeat doesn't revert the members of a backend saved while it's being made.
+func Test_dataSvcImpl_HeartbeatMembers_ConcurrentSave(t *testing.T) {
+	testConcurrentMemberUpdate(t, "HeartbeatMembers", func(svc DataSvc, b *Backend) *Error {
+		_, derr := svc.HeartbeatMembers([]MemberHeartbeat{{Backend: b.Name, Member: b.Members[0].Name}})
+		return derr
+	})
+}
+
+// Tests that saving member metadata doesn't revert the members of a backend saved while it's being
+// made.
+func Test_dataSvcImpl_SaveBackendMemberMeta_ConcurrentSave(t *testing.T) {
+	testConcurrentMemberUpdate(t, "SaveBackendMemberMeta", func(svc DataSvc, b *Backend) *Error {
+		return svc.SaveBackendMemberMeta(b.Name, b.Members[0].Name, map[string]string{"az": "us-east-1a"})
+	})
+}
+
+// Tests that setting member versions doesn't revert the members of a backend saved while they're
+// being set.
+func Test_dataSvcImpl_SetBackendMemberVersions_ConcurrentSave(t *testing.T) {
+	testConcurrentMemberUpdate(t, "SetBackendMemberVersions", func(svc DataSvc, b *Backend) *Error {
+		_, derr := svc.SetBackendMemberVersions(b.Name, "1.3.0", false)
+		return derr
+	})
+}
+
+// Tests that a backend saved with a spaced name can be retrieved and deleted by that name.
+func Test_backendSvcImpl_SpacedName(t *testing.T) {
+	b := bsData.OneBackend()
+	b.Name = "my test backend"
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "backendSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+
+		for _, name := range []string{"my test backend", "my_test_backend"} {
+			r, derr := svc.GetBackend(name)
+			assert.EnsureNil(t, derr, "backendSvcImpl.GetBackend() returned an unexpected error: %v", derr)
+			assert.EnsureNotNil(t, r, "backendSvcImpl.GetBackend() failed to find the backend by name '%s'", name)
+			assert.Equal(t, r.Name, "my_test_backend", "backendSvcImpl.GetBackend() returned an unexpected backend")
+		}
+
+		derr = svc.DeleteBackend("my test backend")
+		assert.EnsureNil(t, derr, "backendSvcImpl.DeleteBackend() returned an unexpected error: %v", derr)
+		r, _ := svc.GetBackend("my_test_backend")
+		assert.Nil(t, r, "backendSvcImpl.DeleteBackend() failed to delete the backend")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   &Config{NormalizeNames: true},
+	}.execute()
+}
+
+// Tests that a frontend saved with a spaced name can be retrieved and deleted by that name.
+func Test_frontendSvcImpl_SpacedName(t *testing.T) {
+	f := fsData.OneFrontend()
+	f.Name = "my test frontend"
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveFrontend(f)
+		assert.EnsureNil(t, derr, "frontendSvcImpl.SaveFrontend() returned an unexpected error: %v", derr)
+
+		for _, name := range []string{"my test frontend", "my_test_frontend"} {
+			r, derr := svc.GetFrontend(name)
+			assert.EnsureNil(t, derr, "frontendSvcImpl.GetFrontend() returned an unexpected error: %v", derr)
+			assert.EnsureNotNil(t, r, "frontendSvcImpl.GetFrontend() failed to find the frontend by name '%s'", name)
+			assert.Equal(t, r.Name, "my_test_frontend", "frontendSvcImpl.GetFrontend() returned an unexpected frontend")
+		}
+
+		derr = svc.DeleteFrontend("my test frontend")
+		assert.EnsureNil(t, derr, "frontendSvcImpl.DeleteFrontend() returned an unexpected error: %v", derr)
+		r, _ := svc.GetFrontend("my_test_frontend")
+		assert.Nil(t, r, "frontendSvcImpl.DeleteFrontend() failed to delete the frontend")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   &Config{NormalizeNames: true},
+	}.execute()
+}
+
+// Tests that names containing spaces are rejected, rather than rewritten, when names aren't normalized.
+func Test_dataSvcImpl_SpacedName_NotNormalized(t *testing.T) {
+	b := bsData.OneBackend()
+	b.Name = "my test backend"
+	f := fsData.OneFrontend()
+	f.Name = "my test frontend"
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNotNil(t, derr, "backendSvcImpl.SaveBackend() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrBadData, "backendSvcImpl.SaveBackend() returned an unexpected error type: '%v'", derr.Type)
+		assert.StringContains(t, derr.Error(), "must not contain spaces", "backendSvcImpl.SaveBackend() returned an unexpected error message")
+
+		derr = svc.SaveFrontend(f)
+		assert.EnsureNotNil(t, derr, "frontendSvcImpl.SaveFrontend() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrBadData, "frontendSvcImpl.SaveFrontend() returned an unexpected error type: '%v'", derr.Type)
+
+		// underscored names are stored and retrieved as given
+		b.Name = "my_test_backend"
+		derr = svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "backendSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		r, _ := svc.GetBackend("my_test_backend")
+		assert.NotNil(t, r, "backendSvcImpl.GetBackend() failed to find the backend")
+		r, _ = svc.GetBackend("my test backend")
+		assert.Nil(t, r, "backendSvcImpl.GetBackend() rewrote a spaced name")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   &Config{NormalizeNames: false},
+	}.execute()
+}
+
+// Tests that a cascading delete by a spaced name clears the frontends that reference it.
+func Test_backendSvcImpl_DeleteBackendCascade_SpacedName(t *testing.T) {
+	b := bsData.OneBackend()
+	b.Name = "my test backend"
+	f := fsData.OneFrontend()
+	f.DefaultBackend = "my test backend"
+
+	setup := func(svc DataSvc) {
+		assert.EnsureNil(t, svc.SaveBackend(b), "backendSvcImpl.SaveBackend() returned an unexpected error")
+		assert.EnsureNil(t, svc.SaveFrontend(f), "frontendSvcImpl.SaveFrontend() returned an unexpected error")
+	}
+	testAction := func(svc DataSvc) {
+		derr := svc.DeleteBackendCascade("my test backend")
+		assert.EnsureNil(t, derr, "backendSvcImpl.DeleteBackendCascade() returned an unexpected error: %v", derr)
+		r, _ := svc.GetFrontend(f.Name)
+		assert.EnsureNotNil(t, r, "backendSvcImpl.DeleteBackendCascade() removed the frontend")
+		assert.Equal(t, r.DefaultBackend, "", "backendSvcImpl.DeleteBackendCascade() failed to clear the frontend's default backend")
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   &Config{NormalizeNames: true},
+	}.execute()
+}
+
+// Tests that the dataSvcImpl.SaveBackendMemberMeta() function replaces only the member's metadata,
+// without syncing HAProxy.
+func Test_dataSvcImpl_SaveBackendMemberMeta(t *testing.T) {
+	b := bsData.OneBackendMultiMembers()
+	meta := map[string]string{"az": "us-east-1a"}
+
+	synced := false
+	ha := testHelpers.NewHAProxyMock()
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+			synced = true
+			return nil
+		}
+	}
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackendMemberMeta(b.Name, b.Members[1].Name, meta)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackendMemberMeta() returned an unexpected error: %v", derr)
+		assert.False(t, synced, "dataSvcImpl.SaveBackendMemberMeta() synced HAProxy")
+
+		r, _ := svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.SaveBackendMemberMeta() lost the backend")
+		assert.Equal(t, r.Members[1].Meta, meta, "dataSvcImpl.SaveBackendMemberMeta() failed to replace the metadata")
+		assert.Equal(t, r.Members[1].Host, b.Members[1].Host, "dataSvcImpl.SaveBackendMemberMeta() changed another field")
+		assert.Nil(t, r.Members[0].Meta, "dataSvcImpl.SaveBackendMemberMeta() changed another member")
+
+		derr = svc.SaveBackendMemberMeta(b.Name, "missing-member", meta)
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveBackendMemberMeta() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrNotFound, "dataSvcImpl.SaveBackendMemberMeta() returned an unexpected error type: '%v'", derr.Type)
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that mutating a backend returned by the service, or the metadata passed to it, doesn't
+// change the stored backend.
+func Test_dataSvcImpl_GetBackend_Independent(t *testing.T) {
+	b := bsData.OneBackendMultiMembers()
+	b.Meta = map[string]string{"team": "a"}
+	meta := map[string]string{"az": "us-east-1a"}
+
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		derr = svc.SaveBackendMemberMeta(b.Name, b.Members[0].Name, meta)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackendMemberMeta() returned an unexpected error: %v", derr)
+	}
+	testAction := func(svc DataSvc) {
+		r, _ := svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.GetBackend() failed to return the backend")
+		r.Meta["team"] = "b"
+		r.Members[0].Meta["az"] = "us-east-1b"
+		r.Members[1].Host = "10.0.0.1"
+		meta["az"] = "us-east-1c"
+
+		r, _ = svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.GetBackend() failed to return the backend")
+		assert.Equal(t, r.Meta["team"], "a", "dataSvcImpl.GetBackend() returned metadata shared with the stored backend")
+		assert.Equal(t, r.Members[0].Meta["az"], "us-east-1a", "dataSvcImpl.GetBackend() returned member metadata shared with the stored backend")
+		assert.Equal(t, r.Members[1].Host, b.Members[1].Host, "dataSvcImpl.GetBackend() returned members shared with the stored backend")
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
+// Tests that appending to the members of a backend returned by the service doesn't change the
+// stored backend.
+func Test_dataSvcImpl_GetAllBackends_IndependentMembers(t *testing.T) {
+	b := bsData.OneBackend()
+	extra := BackendMember{Name: "extra", Host: "10.0.0.2", Port: 8080}
+
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+	}
+	testAction := func(svc DataSvc) {
+		all, _ := svc.GetAllBackends()
+		assert.EnsureEqual(t, len(all), 1, "dataSvcImpl.GetAllBackends() returned an unexpected number of backends")
+		all[0].Members = append(all[0].Members, extra)
+		all[0].Members[0].Host = "10.0.0.1"
+
+		r, _ := svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.GetBackend() failed to return the backend")
+		r.Members = append(r.Members, extra, extra)
+
+		r, _ = svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.GetBackend() failed to return the backend")
+		assert.Equal(t, len(r.Members), len(b.Members), "dataSvcImpl.GetBackend() returned members shared with the stored backend")
+		assert.Equal(t, r.Members[0].Host, b.Members[0].Host, "dataSvcImpl.GetAllBackends() returned members shared with the stored backend")
+	}
+
+	dataSvcTestCase{
+		Setup:  setup,
+		Action: testAction,
+		Mocks:  defaultMocks(),
+	}.execute()
+}
+
+// Tests that the dataSvcImpl.SetBackendMemberVersions() function sets the version of every member,
+// and of the backend only when asked, without syncing HAProxy.
+func Test_dataSvcImpl_SetBackendMemberVersions(t *testing.T) {
+	b := bsData.OneBackendMultiMembers()
+
+	synced := false
+	ha := testHelpers.NewHAProxyMock()
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+			synced = true
+			return nil
+		}
+	}
+	testAction := func(svc DataSvc) {
+		updated, derr := svc.SetBackendMemberVersions(b.Name, "1.3.0", false)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SetBackendMemberVersions() returned an unexpected error: %v", derr)
+		assert.False(t, synced, "dataSvcImpl.SetBackendMemberVersions() synced HAProxy")
+		assert.Equal(t, updated.Version, b.Version, "dataSvcImpl.SetBackendMemberVersions() changed the backend version")
+
+		r, _ := svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.SetBackendMemberVersions() lost the backend")
+		assert.EnsureEqual(t, len(r.Members), len(b.Members), "dataSvcImpl.SetBackendMemberVersions() changed the members")
+		for _, m := range r.Members {
+			assert.Equal(t, m.Version, "1.3.0", "dataSvcImpl.SetBackendMemberVersions() failed to set the version of member %s", m.Name)
+		}
+		assert.Equal(t, r.Version, b.Version, "dataSvcImpl.SetBackendMemberVersions() changed the saved backend version")
+
+		updated, derr = svc.SetBackendMemberVersions(b.Name, "1.4.0", true)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SetBackendMemberVersions() returned an unexpected error: %v", derr)
+		assert.Equal(t, updated.Version, "1.4.0", "dataSvcImpl.SetBackendMemberVersions() failed to set the backend version")
+		r, _ = svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.SetBackendMemberVersions() lost the backend")
+		assert.Equal(t, r.Version, "1.4.0", "dataSvcImpl.SetBackendMemberVersions() failed to save the backend version")
+		for _, m := range r.Members {
+			assert.Equal(t, m.Version, "1.4.0", "dataSvcImpl.SetBackendMemberVersions() failed to set the version of member %s", m.Name)
+		}
+
+		_, derr = svc.SetBackendMemberVersions("missing", "1.3.0", false)
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.SetBackendMemberVersions() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrNotFound, "dataSvcImpl.SetBackendMemberVersions() returned an unexpected error type: '%v'", derr.Type)
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that a failed reload in strict sync mode reverts the data store and the config file.
+func Test_dataSvcImpl_StrictSync_ReloadError(t *testing.T) {
+	original := bsData.OneBackend()
+	updated := bsData.OneBackend()
+	updated.Balance = "leastconn"
+	added := bsData.OtherBackend()
+
+	var written Backends
+	ha := testHelpers.NewHAProxyMock()
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		written = backends
+		return nil
+	}
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(original)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		ha.reloadConfigAction = func() error { return errors.New("test") }
+	}
+	testAction := func(svc DataSvc) {
+		// an update is reverted to the original backend
+		derr := svc.SaveBackend(updated)
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveBackend() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrSync, "dataSvcImpl.SaveBackend() returned an unexpected error type: '%v'", derr.Type)
+		r, _ := svc.GetBackend(original.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.SaveBackend() deleted the original backend")
+		assert.Equal(t, r.Balance, original.Balance, "dataSvcImpl.SaveBackend() failed to revert the update")
+
+		// a create is reverted by removing the backend
+		derr = svc.SaveBackend(added)
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveBackend() failed to return an expected error")
+		r, _ = svc.GetBackend(added.Name)
+		assert.Nil(t, r, "dataSvcImpl.SaveBackend() failed to revert the create")
+
+		// the config file is rewritten from the reverted data
+		assert.EnsureEqual(t, len(written), 1, "dataSvcImpl.SaveBackend() failed to restore the config file")
+		assert.Equal(t, written[0].Name, original.Name, "dataSvcImpl.SaveBackend() restored an unexpected config file")
+		assert.Equal(t, written[0].Balance, original.Balance, "dataSvcImpl.SaveBackend() restored an unexpected config file")
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+		Config:   &Config{StrictSync: true},
+	}.execute()
+}
+
+// Tests that writes are refused while the HAProxy config file has drifted from the data store when
+// writes are blocked on drift, and allowed again once the config file is reconciled.
+func Test_dataSvcImpl_BlockWritesOnDrift(t *testing.T) {
+	b := bsData.OneBackend()
+
+	live := "# edited by hand\n"
+	written := false
+	ha := testHelpers.NewHAProxyMock()
+	ha.getConfigAction = func() (string, error) { return live, nil }
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		written = true
+		return nil
+	}
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveBackend() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrDrift, "dataSvcImpl.SaveBackend() returned an unexpected error type: '%v'", derr.Type)
+		assert.StringContains(t, derr.Error(), "reconcile", "dataSvcImpl.SaveBackend() returned an unexpected error message")
+		assert.False(t, written, "dataSvcImpl.SaveBackend() wrote the drifted config file")
+		r, _ := svc.GetBackend(b.Name)
+		assert.Nil(t, r, "dataSvcImpl.SaveBackend() saved the backend despite the drift")
+
+		// a sync reconciles the config file and is never blocked
+		derr = svc.Sync()
+		assert.EnsureNil(t, derr, "dataSvcImpl.Sync() returned an unexpected error: %v", derr)
+		assert.True(t, written, "dataSvcImpl.Sync() failed to write the config file")
+
+		live = ""
+		derr = svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		r, _ = svc.GetBackend(b.Name)
+		assert.NotNil(t, r, "dataSvcImpl.SaveBackend() failed to save the backend once reconciled")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+		Config:   &Config{BlockWritesOnDrift: true},
+	}.execute()
+}
+
+// Tests that writes are allowed despite drift when writes aren't blocked on drift.
+func Test_dataSvcImpl_Drift_NotBlocked(t *testing.T) {
+	b := bsData.OneBackend()
+
+	ha := testHelpers.NewHAProxyMock()
+	ha.getConfigAction = func() (string, error) { return "# edited by hand\n", nil }
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that the type of change that caused a sync is passed through to the HAProxy reload.
+func Test_dataSvcImpl_ChangeType(t *testing.T) {
+	b := bsData.OneBackend()
+	f := fsData.OneFrontend()
+
+	ha := testHelpers.NewHAProxyMock()
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		derr = svc.SaveFrontend(f)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveFrontend() returned an unexpected error: %v", derr)
+		derr = svc.WithTransaction(func(tx DataSvc) error {
+			if derr := tx.DeleteFrontend(f.Name); derr != nil {
+				return derr
+			}
+			return tx.DeleteBackend(b.Name)
+		})
+		assert.EnsureNil(t, derr, "dataSvcImpl.WithTransaction() returned an unexpected error: %v", derr)
+		derr = svc.Sync()
+		assert.EnsureNil(t, derr, "dataSvcImpl.Sync() returned an unexpected error: %v", derr)
+
+		expected := []changeType{changeBackend, changeFrontend, changeAll, changeAll}
+		assert.Equal(t, ha.reloadChanges, expected, "dataSvcImpl reloaded HAProxy for unexpected changes")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that a failed reload outside of strict sync mode keeps the change in the data store.
+func Test_dataSvcImpl_ReloadError_KeepsChange(t *testing.T) {
+	b := bsData.OneBackend()
+
+	ha := testHelpers.NewHAProxyMock()
+	ha.reloadConfigAction = func() error { return errors.New("test") }
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveBackend() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrSync, "dataSvcImpl.SaveBackend() returned an unexpected error type: '%v'", derr.Type)
+		r, _ := svc.GetBackend(b.Name)
+		assert.NotNil(t, r, "dataSvcImpl.SaveBackend() reverted the change outside of strict sync mode")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that the post-reload command is executed after a successful reload.
+func Test_dataSvcImpl_PostReloadCommand(t *testing.T) {
+	b := bsData.OneBackend()
+	dir, err := ioutil.TempDir("", "conduit-post-reload")
+	assert.EnsureNil(t, err, "ioutil.TempDir() returned an unexpected error: %v", err)
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "reloaded")
 
-// 	f := func(name string) bool {
-// 		count++
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		_, err := os.Stat(marker)
+		assert.Nil(t, err, "dataSvcImpl.SaveBackend() did not execute the post-reload command")
+	}
 
-// 		t.Logf("Test %d: Testing getBackendKey with name (%v)\n", count, name)
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   &Config{PostReloadCommand: "touch " + marker},
+	}.execute()
+}
 
-// 		backend := &Backend{Name: name}
-// 		actual := svc.getBackendKey(backend)
+// Tests that the post-reload command is not executed when the reload fails.
+func Test_dataSvcImpl_PostReloadCommand_ReloadError(t *testing.T) {
+	b := bsData.OneBackend()
+	dir, err := ioutil.TempDir("", "conduit-post-reload")
+	assert.EnsureNil(t, err, "ioutil.TempDir() returned an unexpected error: %v", err)
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "reloaded")
 
-// 		return strings.Replace(name, " ", "_", -1) == actual
-// 	}
-// 	config := &quick.Config{MaxCount: 1000}
-// 	if err := quick.Check(f, config); err != nil {
-// 		t.Error(err)
-// 	}
-// }
+	ha := testHelpers.NewHAProxyMock()
+	ha.reloadConfigAction = func() error { return errors.New("test") }
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveBackend() failed to return an expected error")
+		_, err := os.Stat(marker)
+		assert.True(t, os.IsNotExist(err), "dataSvcImpl.SaveBackend() executed the post-reload command after a failed reload")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+		Config:   &Config{PostReloadCommand: "touch " + marker},
+	}.execute()
+}
+
+// Tests that a failing post-reload command doesn't fail the change.
+func Test_dataSvcImpl_PostReloadCommand_Fails(t *testing.T) {
+	b := bsData.OneBackend()
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.Nil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   &Config{PostReloadCommand: "exit 1"},
+	}.execute()
+}
+
+// Tests that the reads made through a dataSvcImpl snapshot are consistent while a concurrent write
+// is made through the service.
+func Test_dataSvcImpl_Snapshot(t *testing.T) {
+	dbPath := testHelpers.DBPath(t)
+	defer os.RemoveAll(dbPath)
+	ldb := testHelpers.LevelDB(t, dbPath)
+	defer ldb.Close()
+
+	svc := NewDataSvc(&levelDBDatastore{db: ldb}, testHelpers.NewHAProxyMock(), &Config{})
+	b := bsData.OneBackend()
+	f := fsData.OneFrontend()
+	f.DefaultBackend = b.Name
+	assert.EnsureNil(t, svc.SaveBackend(b), "dataSvcImpl.SaveBackend() returned an unexpected error")
+	assert.EnsureNil(t, svc.SaveFrontend(f), "dataSvcImpl.SaveFrontend() returned an unexpected error")
+
+	snap, release, derr := svc.Snapshot()
+	assert.EnsureNil(t, derr, "dataSvcImpl.Snapshot() returned an unexpected error: %v", derr)
+	defer release()
+
+	frontends, derr := snap.GetAllFrontends()
+	assert.EnsureNil(t, derr, "dataSvcImpl.GetAllFrontends() returned an unexpected error: %v", derr)
+
+	// a write made between the reads of the request
+	done := make(chan *Error)
+	go func() {
+		done <- svc.WithTransaction(func(tx DataSvc) error {
+			b2 := bsData.OtherBackend()
+			if derr := tx.SaveBackend(b2); derr != nil {
+				return derr
+			}
+			f.DefaultBackend = b2.Name
+			return tx.SaveFrontend(f)
+		})
+	}()
+	assert.EnsureNil(t, <-done, "dataSvcImpl.WithTransaction() returned an unexpected error")
+
+	backends, derr := snap.GetAllBackends()
+	assert.EnsureNil(t, derr, "dataSvcImpl.GetAllBackends() returned an unexpected error: %v", derr)
+	assert.EnsureEqual(t, len(frontends), 1, "dataSvcImpl.Snapshot() returned an unexpected number of frontends")
+	assert.EnsureEqual(t, len(backends), 1, "dataSvcImpl.Snapshot() returned backends saved after the snapshot")
+	assert.Equal(t, frontends[0].DefaultBackend, backends[0].Name, "dataSvcImpl.Snapshot() returned inconsistent reads")
+
+	// the live service sees the write
+	backends, _ = svc.GetAllBackends()
+	assert.Equal(t, len(backends), 2, "dataSvcImpl.GetAllBackends() failed to return the backend saved after the snapshot")
+
+	derr = snap.SaveBackend(bsData.OneBackend())
+	assert.NotNil(t, derr, "dataSvcImpl.SaveBackend() wrote through a snapshot")
+}
+
+// Tests that a save that renders the same HAProxy config as the last one applied doesn't reload
+// HAProxy when reloads are deduped.
+func Test_dataSvcImpl_DedupeReloads(t *testing.T) {
+	b := bsData.OneBackend()
+	ha := testHelpers.NewHAProxyMock()
+	ha.SetTemplate(template.Must(template.New("test").Parse(`{{range .Backends}}{{.Name}} {{.Balance}}{{end}}`)))
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, len(ha.reloadStrategies), 1, "dataSvcImpl.SaveBackend() failed to reload HAProxy")
+
+		// the same backend renders an identical config
+		derr = svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, len(ha.reloadStrategies), 1, "dataSvcImpl.SaveBackend() reloaded HAProxy for an unchanged config")
+
+		b2 := *b
+		b2.Balance = "leastconn"
+		derr = svc.SaveBackend(&b2)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, len(ha.reloadStrategies), 2, "dataSvcImpl.SaveBackend() failed to reload HAProxy for a changed config")
+
+		// an explicit sync is never skipped
+		derr = svc.Sync()
+		assert.EnsureNil(t, derr, "dataSvcImpl.Sync() returned an unexpected error: %v", derr)
+		assert.Equal(t, len(ha.reloadStrategies), 3, "dataSvcImpl.Sync() failed to reload HAProxy")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+		Config:   &Config{DedupeReloads: true},
+	}.execute()
+}
+
+// Tests that a save after a failed reload reloads HAProxy again, even if the config is unchanged.
+func Test_dataSvcImpl_DedupeReloads_ReloadError(t *testing.T) {
+	b := bsData.OneBackend()
+	ha := testHelpers.NewHAProxyMock()
+	ha.SetTemplate(template.Must(template.New("test").Parse(`{{range .Backends}}{{.Name}} {{.Balance}}{{end}}`)))
+
+	testAction := func(svc DataSvc) {
+		ha.reloadConfigAction = func() error { return errors.New("test") }
+		derr := svc.SaveBackend(b)
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveBackend() failed to return an expected error")
+
+		ha.reloadConfigAction = nil
+		derr = svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, len(ha.reloadStrategies), 2, "dataSvcImpl.SaveBackend() failed to retry the failed reload")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+		Config:   &Config{DedupeReloads: true},
+	}.execute()
+}
+
+// Tests that every save reloads HAProxy when reloads aren't deduped.
+func Test_dataSvcImpl_DedupeReloads_Disabled(t *testing.T) {
+	b := bsData.OneBackend()
+	ha := testHelpers.NewHAProxyMock()
+	ha.SetTemplate(template.Must(template.New("test").Parse(`{{range .Backends}}{{.Name}} {{.Balance}}{{end}}`)))
+
+	testAction := func(svc DataSvc) {
+		svc.SaveBackend(b)
+		svc.SaveBackend(b)
+		assert.Equal(t, len(ha.reloadStrategies), 2, "dataSvcImpl.SaveBackend() skipped a reload")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+		Config:   nil,
+	}.execute()
+}
+
+// Tests that the dataSvcImpl.CloneBackend() function saves a deep copy of a backend under a new name
+// and syncs HAProxy.
+func Test_dataSvcImpl_CloneBackend(t *testing.T) {
+	b := bsData.OneBackendMultiMembers()
+
+	synced := false
+	ha := testHelpers.NewHAProxyMock()
+	setup := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+			synced = true
+			return nil
+		}
+	}
+	testAction := func(svc DataSvc) {
+		c, derr := svc.CloneBackend(b.Name, "clone-1.3.0", "1.3.0")
+		assert.EnsureNil(t, derr, "dataSvcImpl.CloneBackend() returned an unexpected error: %v", derr)
+		assert.True(t, synced, "dataSvcImpl.CloneBackend() failed to sync HAProxy")
+		assert.Equal(t, c.Name, "clone-1.3.0", "dataSvcImpl.CloneBackend() returned an unexpected name")
+
+		r, _ := svc.GetBackend("clone-1.3.0")
+		assert.EnsureNotNil(t, r, "dataSvcImpl.CloneBackend() failed to save the clone")
+		assert.Equal(t, r.Version, "1.3.0", "dataSvcImpl.CloneBackend() failed to set the version of the clone")
+		assert.Equal(t, r.Balance, b.Balance, "dataSvcImpl.CloneBackend() failed to copy the balance")
+		assert.EnsureEqual(t, len(r.Members), len(b.Members), "dataSvcImpl.CloneBackend() failed to copy the members")
+		for i, m := range r.Members {
+			assert.Equal(t, m.Host, b.Members[i].Host, "dataSvcImpl.CloneBackend() failed to copy member %s", m.Name)
+			assert.Equal(t, m.Version, "1.3.0", "dataSvcImpl.CloneBackend() failed to set the version of member %s", m.Name)
+		}
+
+		// the source is unchanged
+		s, _ := svc.GetBackend(b.Name)
+		assert.EnsureNotNil(t, s, "dataSvcImpl.CloneBackend() lost the source backend")
+		assert.Equal(t, s.Version, "1.2.5", "dataSvcImpl.CloneBackend() changed the source version")
+		for _, m := range s.Members {
+			assert.Equal(t, m.Version, "1.2.5", "dataSvcImpl.CloneBackend() changed the version of source member %s", m.Name)
+		}
+
+		// without a version, the source's is kept
+		c, derr = svc.CloneBackend(b.Name, "clone-same", "")
+		assert.EnsureNil(t, derr, "dataSvcImpl.CloneBackend() returned an unexpected error: %v", derr)
+		assert.Equal(t, c.Version, "1.2.5", "dataSvcImpl.CloneBackend() changed the version without one given")
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+		Config:   nil,
+	}.execute()
+}
+
+// Tests that the dataSvcImpl.CloneBackend() function refuses to replace an existing backend.
+func Test_dataSvcImpl_CloneBackend_Conflict(t *testing.T) {
+	b1 := bsData.OneBackend()
+	b2 := bsData.OtherBackend()
+
+	setup := func(svc DataSvc) {
+		svc.SaveBackend(b1)
+		svc.SaveBackend(b2)
+	}
+	testAction := func(svc DataSvc) {
+		_, derr := svc.CloneBackend(b1.Name, b2.Name, "")
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.CloneBackend() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrConflict, "dataSvcImpl.CloneBackend() returned an unexpected error type: '%v'", derr.Type)
+
+		r, _ := svc.GetBackend(b2.Name)
+		assert.EnsureNotNil(t, r, "dataSvcImpl.CloneBackend() lost the existing backend")
+		assert.Equal(t, r.Host, b2.Host, "dataSvcImpl.CloneBackend() replaced the existing backend")
+	}
+
+	dataSvcTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   nil,
+	}.execute()
+}
+
+// Tests that the dataSvcImpl.CloneBackend() function returns an error when the source doesn't exist.
+func Test_dataSvcImpl_CloneBackend_SourceMissing(t *testing.T) {
+	testAction := func(svc DataSvc) {
+		_, derr := svc.CloneBackend("missing", "clone", "")
+		assert.EnsureNotNil(t, derr, "dataSvcImpl.CloneBackend() failed to return an expected error")
+		assert.Equal(t, derr.Type, ErrNotFound, "dataSvcImpl.CloneBackend() returned an unexpected error type: '%v'", derr.Type)
+
+		r, _ := svc.GetBackend("clone")
+		assert.Nil(t, r, "dataSvcImpl.CloneBackend() saved a clone of a missing backend")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   nil,
+	}.execute()
+}
+
+// Tests that the config file is verified after each reload when configured, and that a file changed
+// by another process is detected.
+func Test_dataSvcImpl_VerifyConfig(t *testing.T) {
+	for _, tamper := range []bool{false, true} {
+		b := bsData.OneBackend()
+		written := ""
+		ha := testHelpers.NewHAProxyMock()
+		ha.SetTemplate(template.Must(template.New("test").Parse(`{{range .Backends}}backend {{.Name}}{{end}}`)))
+		ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+			written, _ = ha.RenderConfig(frontends, backends)
+			return nil
+		}
+		ha.reloadConfigAction = func() error {
+			if tamper {
+				written += "\nbackend intruder"
+			}
+			return nil
+		}
+		ha.getConfigAction = func() (string, error) {
+			return written, nil
+		}
+
+		testAction := func(svc DataSvc) {
+			derr := svc.SaveBackend(b)
+			if !tamper {
+				assert.Nil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+				return
+			}
+			assert.EnsureNotNil(t, derr, "dataSvcImpl.SaveBackend() failed to detect the changed config file")
+			assert.Equal(t, derr.Type, ErrDrift, "dataSvcImpl.SaveBackend() returned an unexpected error type: '%v'", derr.Type)
+		}
+
+		dataSvcTestCase{
+			Setup:    nil,
+			Action:   testAction,
+			Teardown: nil,
+			Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+			Config:   &Config{VerifyConfig: true},
+		}.execute()
+	}
+}
+
+// Tests that the config file is verified against the config that was written rather than the data
+// store, so that a change committed by another write during the reload isn't reported as drift.
+func Test_dataSvcImpl_VerifyConfig_ConcurrentWrite(t *testing.T) {
+	b := bsData.OneBackend()
+	db := testHelpers.NewDatastoreMock()
+	written := ""
+	ha := testHelpers.NewHAProxyMock()
+	ha.SetTemplate(template.Must(template.New("test").Parse(`{{range .Backends}}backend {{.Name}}{{end}}`)))
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		written, _ = ha.RenderConfig(frontends, backends)
+		return nil
+	}
+	ha.reloadConfigAction = func() error {
+		// another write commits to the data store while HAProxy is reloading
+		db.SaveBackend(bsData.OtherBackend())
+		return nil
+	}
+	ha.getConfigAction = func() (string, error) {
+		return written, nil
+	}
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.Nil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: db, HA: ha},
+		Config:   &Config{VerifyConfig: true},
+	}.execute()
+}
+
+// Tests that the config file isn't read back after a reload unless configured.
+func Test_dataSvcImpl_VerifyConfig_Disabled(t *testing.T) {
+	b := bsData.OneBackend()
+	read := false
+	ha := testHelpers.NewHAProxyMock()
+	ha.getConfigAction = func() (string, error) {
+		read = true
+		return "tampered", nil
+	}
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.Nil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		assert.False(t, read, "dataSvcImpl.SaveBackend() read the config file back")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+		Config:   nil,
+	}.execute()
+}
+
+// Tests that saving a backend beyond the soft limit succeeds but is warned about.
+func Test_dataSvcImpl_BackendSoftLimit(t *testing.T) {
+	b1 := bsData.OneBackend()
+	b2 := bsData.OtherBackend()
+
+	testAction := func(svc DataSvc) {
+		output := testHelpers.CaptureLog(func() {
+			derr := svc.SaveBackend(b1)
+			assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		})
+		assert.NotStringContains(t, output, "soft limit", "dataSvcImpl.SaveBackend() warned about a backend within the soft limit")
+		assert.Equal(t, svc.BackendLimitWarning(), "", "dataSvcImpl.BackendLimitWarning() returned a warning within the soft limit")
+
+		output = testHelpers.CaptureLog(func() {
+			derr := svc.SaveBackend(b2)
+			assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() refused a backend beyond the soft limit: %v", derr)
+		})
+		assert.StringContains(t, output, "[WARN] there are 2 backends, more than the soft limit of 1", "dataSvcImpl.SaveBackend() failed to warn about a backend beyond the soft limit")
+		assert.Equal(t, svc.BackendLimitWarning(), "there are 2 backends, more than the soft limit of 1", "dataSvcImpl.BackendLimitWarning() returned an unexpected warning")
+
+		r, _ := svc.GetBackend(b2.Name)
+		assert.NotNil(t, r, "dataSvcImpl.SaveBackend() failed to save a backend beyond the soft limit")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   &Config{BackendSoftLimit: 1},
+	}.execute()
+}
+
+// Tests that saving a backend with a single member succeeds but logs a warning when single-member
+// backends are warned about, and that a backend with several members is saved silently.
+func Test_dataSvcImpl_WarnSingleMemberBackends(t *testing.T) {
+	b1 := bsData.OneBackend()
+	b2 := bsData.OneBackendMultiMembers()
+
+	testAction := func(svc DataSvc) {
+		output := testHelpers.CaptureLog(func() {
+			derr := svc.SaveBackend(b1)
+			assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() refused a single-member backend: %v", derr)
+		})
+		expMsg := fmt.Sprintf("the backend %s has a single member and no redundancy", b1.Name)
+		assert.StringContains(t, output, "[WARN] "+expMsg, "dataSvcImpl.SaveBackend() failed to warn about a single-member backend")
+		assert.Equal(t, svc.SingleMemberWarning(b1), expMsg, "dataSvcImpl.SingleMemberWarning() returned an unexpected warning")
+
+		output = testHelpers.CaptureLog(func() {
+			derr := svc.SaveBackend(b2)
+			assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		})
+		assert.NotStringContains(t, output, "single member", "dataSvcImpl.SaveBackend() warned about a multi-member backend")
+		assert.Equal(t, svc.SingleMemberWarning(b2), "", "dataSvcImpl.SingleMemberWarning() returned a warning for a multi-member backend")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+		Config:   &Config{WarnSingleMemberBackends: true},
+	}.execute()
+}
+
+// Tests that the rise and fall thresholds of a saved backend's members are written to the HAProxy
+// config.
+func Test_dataSvcImpl_SaveBackend_RiseFall(t *testing.T) {
+	b := bsData.OneBackendMultiMembers()
+	b.Members[0].Rise = 3
+	b.Members[0].Fall = 2
+	written := ""
+	ha := testHelpers.NewHAProxyMock()
+	ha.SetTemplate(template.Must(template.New("test").Parse(defaultTemplate)))
+	ha.writeConfigAction = func(frontends Frontends, backends Backends) error {
+		written, _ = ha.RenderConfig(frontends, backends)
+		return nil
+	}
+
+	testAction := func(svc DataSvc) {
+		derr := svc.SaveBackend(b)
+		assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		assert.StringContains(t, written, "server backend/test002/10.180.2.1 10.180.2.1:8080 check inter 2000 rise 3 fall 2\n",
+			"dataSvcImpl.SaveBackend() did not write the rise and fall of a member")
+		assert.StringContains(t, written, "server backend/test002/10.180.2.2 10.180.2.2:8080 check inter 2000\n",
+			"dataSvcImpl.SaveBackend() wrote unexpected options for a member without rise and fall")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    dataSvcMocks{DB: testHelpers.NewDatastoreMock(), HA: ha},
+	}.execute()
+}
+
+// Tests that single-member backends aren't warned about by default.
+func Test_dataSvcImpl_WarnSingleMemberBackends_Disabled(t *testing.T) {
+	b := bsData.OneBackend()
+
+	testAction := func(svc DataSvc) {
+		output := testHelpers.CaptureLog(func() {
+			derr := svc.SaveBackend(b)
+			assert.EnsureNil(t, derr, "dataSvcImpl.SaveBackend() returned an unexpected error: %v", derr)
+		})
+		assert.NotStringContains(t, output, "single member", "dataSvcImpl.SaveBackend() warned about a single-member backend while disabled")
+		assert.Equal(t, svc.SingleMemberWarning(b), "", "dataSvcImpl.SingleMemberWarning() returned a warning while disabled")
+	}
+
+	dataSvcTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+		Mocks:    defaultMocks(),
+	}.execute()
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
 	"time"
 )
 
@@ -15,15 +20,48 @@ type Frontend struct {
 	Mode           string            `json:"mode"`           // http
 	KeepAlive      string            `json:"keepalive"`      // default|close|server-close
 	Option         string            `json:"option"`         // httplog
+	MaxConn        int               `json:"maxconn"`        // maximum concurrent connections, or 0 for HAProxy's default
 	Rules          []string          `json:"rules"`
+	RoutingRules   []Rule            `json:"routingRules"`
+	HTTPRequests   []string          `json:"httpRequests"` // http-request rules, written in order
+	ExpiresAt      time.Time         `json:"expiresAt"`    // when the frontend is deleted by the expiry sweep, or zero for never
+	ModifiedAt     time.Time         `json:"modifiedAt"`
 	Meta           map[string]string `json:"meta"`
 }
 
+// Rule routes the requests of a frontend that match an HAProxy ACL condition (e.g.
+// "path_beg /api") to a backend.
+type Rule struct {
+	Condition string `json:"condition"`
+	Backend   string `json:"backend"`
+}
+
 // String returns the string representation of a frontend.
 func (f *Frontend) String() string {
 	return f.Name
 }
 
+// Expired returns true if the frontend has an expiry time that isn't after the given time.
+func (f *Frontend) Expired(now time.Time) bool {
+	return !f.ExpiresAt.IsZero() && !f.ExpiresAt.After(now)
+}
+
+// Copy returns a deep copy of this frontend, which shares no rules or metadata with it.
+func (f *Frontend) Copy() *Frontend {
+	c := *f
+	if f.Rules != nil {
+		c.Rules = append([]string{}, f.Rules...)
+	}
+	if f.RoutingRules != nil {
+		c.RoutingRules = append([]Rule{}, f.RoutingRules...)
+	}
+	if f.HTTPRequests != nil {
+		c.HTTPRequests = append([]string{}, f.HTTPRequests...)
+	}
+	c.Meta = copyMeta(f.Meta)
+	return &c
+}
+
 // ToHAProxyFrontend will convert this instance to an haproxy-client.Frontend object.
 func (f *Frontend) ToHAProxyFrontend() *Frontend {
 	return &Frontend{
@@ -33,7 +71,10 @@ func (f *Frontend) ToHAProxyFrontend() *Frontend {
 		Mode:           f.Mode,
 		KeepAlive:      f.KeepAlive,
 		Option:         f.Option,
+		MaxConn:        f.MaxConn,
 		Rules:          f.Rules,
+		RoutingRules:   f.RoutingRules,
+		HTTPRequests:   f.HTTPRequests,
 	}
 }
 
@@ -52,6 +93,17 @@ func (f Frontends) ToInterfaces() []interface{} {
 	return ifs
 }
 
+// ModifiedSince returns the frontends that were last saved after the given time.
+func (f Frontends) ModifiedSince(t time.Time) Frontends {
+	x := Frontends{}
+	for _, frontend := range f {
+		if frontend.ModifiedAt.After(t) {
+			x = append(x, frontend)
+		}
+	}
+	return x
+}
+
 // ToHAProxyFrontends will convert this instance to an haproxy-client.Frontends object.
 func (f Frontends) ToHAProxyFrontends() Frontends {
 	x := []*Frontend{}
@@ -64,15 +116,21 @@ func (f Frontends) ToHAProxyFrontends() Frontends {
 
 // Backend is the HAProxy backend data structure, serializable to JSON.
 type Backend struct {
-	ID        string            `json:"-"`
-	ProxyType string            `json:"-"`
-	Name      string            `json:"name"`
-	Version   string            `json:"version"` // TODO: proper formatting?
-	Balance   string            `json:"balance"`
-	Host      string            `json:"host"`
-	Mode      string            `json:"mode"`
-	Members   BackendMembers    `json:"members"`
-	Meta      map[string]string `json:"meta"`
+	ID             string            `json:"-"`
+	ProxyType      string            `json:"-"`
+	Name           string            `json:"name"`
+	Version        string            `json:"version"` // TODO: proper formatting?
+	Balance        string            `json:"balance"`
+	Host           string            `json:"host"`
+	Mode           string            `json:"mode"`
+	Resolvers      string            `json:"resolvers"`      // name of the HAProxy resolvers section used to resolve member hosts
+	HealthCheck    string            `json:"healthCheck"`    // HTTP health check request, e.g. "GET /health", or empty for connect checks
+	ReloadStrategy string            `json:"reloadStrategy"` // overrides the configured reload strategy when this backend changes
+	DefaultPort    int               `json:"defaultPort"`    // port of the members saved without one, or 0 for none
+	ExpiresAt      time.Time         `json:"expiresAt"`      // when the backend is deleted by the expiry sweep, or zero for never
+	ModifiedAt     time.Time         `json:"modifiedAt"`
+	Members        BackendMembers    `json:"members"`
+	Meta           map[string]string `json:"meta"`
 }
 
 // String returns the string representation of a backend.
@@ -80,14 +138,29 @@ func (b *Backend) String() string {
 	return b.Name
 }
 
+// Expired returns true if the backend has an expiry time that isn't after the given time.
+func (b *Backend) Expired(now time.Time) bool {
+	return !b.ExpiresAt.IsZero() && !b.ExpiresAt.After(now)
+}
+
+// Copy returns a deep copy of this backend, which shares no members or metadata with it.
+func (b *Backend) Copy() *Backend {
+	c := *b
+	c.Members = b.Members.Copy()
+	c.Meta = copyMeta(b.Meta)
+	return &c
+}
+
 // ToHAProxyBackend will convert this instance to an haproxy-client.Backend object.
 func (b *Backend) ToHAProxyBackend() *Backend {
 	return &Backend{
-		Name:    b.Name,
-		Balance: b.Balance,
-		Host:    b.Host,
-		Mode:    b.Mode,
-		Members: b.Members.ToHAProxyBackendMembers(),
+		Name:        b.Name,
+		Balance:     b.Balance,
+		Host:        b.Host,
+		Mode:        b.Mode,
+		Resolvers:   b.Resolvers,
+		HealthCheck: b.HealthCheck,
+		Members:     b.Members.ToHAProxyBackendMembers(),
 	}
 }
 
@@ -106,6 +179,50 @@ func (b Backends) ToInterfaces() []interface{} {
 	return ifs
 }
 
+// ModifiedSince returns the backends that were last saved after the given time.
+func (b Backends) ModifiedSince(t time.Time) Backends {
+	x := Backends{}
+	for _, backend := range b {
+		if backend.ModifiedAt.After(t) {
+			x = append(x, backend)
+		}
+	}
+	return x
+}
+
+// Empty returns the backends that have no members.
+func (b Backends) Empty() Backends {
+	x := Backends{}
+	for _, backend := range b {
+		if len(backend.Members) == 0 {
+			x = append(x, backend)
+		}
+	}
+	return x
+}
+
+// WithoutDisabledMembers returns copies of the backends with their disabled members removed; the
+// backends themselves are left unchanged.
+func (b Backends) WithoutDisabledMembers() Backends {
+	x := Backends{}
+	for _, backend := range b {
+		members := BackendMembers{}
+		for _, m := range backend.Members {
+			if !m.Disabled {
+				members = append(members, m)
+			}
+		}
+		if len(members) == len(backend.Members) {
+			x = append(x, backend)
+			continue
+		}
+		c := *backend
+		c.Members = members
+		x = append(x, &c)
+	}
+	return x
+}
+
 // ToHAProxyBackends will convert this instance to an haproxy-client.Backends object.
 func (b Backends) ToHAProxyBackends() Backends {
 	x := []*Backend{}
@@ -123,6 +240,9 @@ type BackendMember struct {
 	Host      string            `json:"host"`
 	Port      int               `json:"port"`
 	LastKnown time.Time         `json:"lastKnown"`
+	Disabled  bool              `json:"disabled"`
+	Rise      int               `json:"rise"` // consecutive successful checks to be considered up, or 0 for HAProxy's default
+	Fall      int               `json:"fall"` // consecutive failed checks to be considered down, or 0 for HAProxy's default
 	Meta      map[string]string `json:"meta"`
 }
 
@@ -131,6 +251,65 @@ func (m *BackendMember) String() string {
 	return fmt.Sprintf("%s-%s", m.Name, m.Version)
 }
 
+// UnmarshalJSON decodes a backend member from JSON, accepting a port encoded as a whole-number float
+// (e.g. 8080.0) as some clients send; a port with a fractional part is rejected with an ErrBadData
+// error. The lastKnown time may be either an RFC3339 string or a number of seconds since the Unix
+// epoch.
+func (m *BackendMember) UnmarshalJSON(b []byte) error {
+	type member BackendMember
+	aux := struct {
+		*member
+		Port      json.Number     `json:"port"`
+		LastKnown json.RawMessage `json:"lastKnown"`
+	}{member: (*member)(m)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	if err := m.unmarshalLastKnown(aux.LastKnown); err != nil {
+		return err
+	}
+	if aux.Port == "" {
+		return nil
+	}
+
+	if i, err := aux.Port.Int64(); err == nil && i >= math.MinInt32 && i <= math.MaxInt32 {
+		m.Port = int(i)
+		return nil
+	}
+	if f, err := aux.Port.Float64(); err == nil && f == math.Trunc(f) && f >= math.MinInt32 && f <= math.MaxInt32 {
+		m.Port = int(f)
+		return nil
+	}
+	return NewError(ErrBadData, fieldErrorf("port", "the port '%s' of member '%s' is invalid - must be a whole number",
+		aux.Port, m.Name))
+}
+
+// decodes the given lastKnown value, which is either an RFC3339 string or a number of seconds since
+// the Unix epoch; epoch times are decoded in UTC
+func (m *BackendMember) unmarshalLastKnown(raw json.RawMessage) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if raw[0] == '"' {
+		return json.Unmarshal(raw, &m.LastKnown)
+	}
+
+	var secs float64
+	if err := json.Unmarshal(raw, &secs); err != nil {
+		return NewError(ErrBadData, fieldErrorf("lastKnown", "the lastKnown time '%s' of member '%s' is invalid - must be an RFC3339 string or a number of seconds",
+			raw, m.Name))
+	}
+	whole, frac := math.Modf(secs)
+	m.LastKnown = time.Unix(int64(whole), int64(frac*1e9)).UTC()
+	return nil
+}
+
+// Address returns the host:port address of a backend member; IPv6 hosts are enclosed in square
+// brackets (e.g. "[::1]:8080").
+func (m BackendMember) Address() string {
+	return net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+}
+
 // ToHAProxyBackendMember will convert this instance to an haproxy-client.BackendMember object.
 func (m *BackendMember) ToHAProxyBackendMember() *BackendMember {
 	return &BackendMember{
@@ -138,12 +317,37 @@ func (m *BackendMember) ToHAProxyBackendMember() *BackendMember {
 		Host:      m.Host,
 		Port:      m.Port,
 		LastKnown: m.LastKnown,
+		Disabled:  m.Disabled,
+		Rise:      m.Rise,
+		Fall:      m.Fall,
 	}
 }
 
 // BackendMembers represents an array of BackendMember instances.
 type BackendMembers []BackendMember
 
+// ByName returns the members keyed by name.
+func (m BackendMembers) ByName() map[string]BackendMember {
+	byName := make(map[string]BackendMember, len(m))
+	for _, v := range m {
+		byName[v.Name] = v
+	}
+	return byName
+}
+
+// Copy returns a deep copy of the members, which shares no metadata with them.
+func (m BackendMembers) Copy() BackendMembers {
+	if m == nil {
+		return nil
+	}
+	c := make(BackendMembers, len(m))
+	for i, v := range m {
+		v.Meta = copyMeta(v.Meta)
+		c[i] = v
+	}
+	return c
+}
+
 // ToInterfaces converts a BackendMembers instance to an array of empty interfaces.
 func (m BackendMembers) ToInterfaces() []interface{} {
 	if len(m) == 0 {
@@ -157,15 +361,29 @@ func (m BackendMembers) ToInterfaces() []interface{} {
 }
 
 // ToHAProxyBackendMembers will convert this instance to an haproxy-client.BackendMembers object.
+// The members are sorted by name so that the rendered config is stable.
 func (m BackendMembers) ToHAProxyBackendMembers() BackendMembers {
 	x := []BackendMember{}
 	for _, member := range m {
 		x = append(x, *member.ToHAProxyBackendMember())
 	}
 	values := BackendMembers(x)
+	values.SortByName()
 	return values
 }
 
+// SortByName sorts the members in place by name, preserving the order of members with the same name.
+func (m BackendMembers) SortByName() {
+	sort.Stable(membersByName(m))
+}
+
+// membersByName implements sort.Interface to order BackendMembers by name.
+type membersByName BackendMembers
+
+func (m membersByName) Len() int           { return len(m) }
+func (m membersByName) Less(i, j int) bool { return m[i].Name < m[j].Name }
+func (m membersByName) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+
 // Datastore interface defines methods to manipulate backend data.
 type Datastore interface {
 	GetAllFrontends() (Frontends, *Error)
@@ -177,4 +395,30 @@ type Datastore interface {
 	GetBackend(key string) (*Backend, *Error)
 	SaveBackend(b *Backend) *Error
 	DeleteBackend(key string) *Error
+
+	GetRaw(proxyType, key string) (*RawRecord, *Error)
+
+	GetTemplate() (string, *Error)
+	SaveTemplate(text string) *Error
+}
+
+// RawRecord is a frontend or backend exactly as it is stored in the data store, along with the ID
+// and ProxyType fields that are never returned as part of the record itself.
+type RawRecord struct {
+	ID        string          `json:"id"`
+	ProxyType string          `json:"proxyType"`
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// returns a copy of the given metadata, or nil if it is nil
+func copyMeta(meta map[string]string) map[string]string {
+	if meta == nil {
+		return nil
+	}
+	c := make(map[string]string, len(meta))
+	for k, v := range meta {
+		c[k] = v
+	}
+	return c
 }
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 )
 
 // ----------------------------------------------
@@ -15,6 +17,34 @@ func Test_Backend_String(t *testing.T) {
 	assert.Equal(t, b.String(), b.Name, "Backend.String() returned unexpected result")
 }
 
+// Tests that the Backend.Copy() function returns a copy that shares no members or metadata with the
+// original.
+func Test_Backend_Copy(t *testing.T) {
+	b := &Backend{
+		Name:    "test-app",
+		Meta:    map[string]string{"team": "a"},
+		Members: BackendMembers{{Name: "m1", Host: "10.0.0.1", Port: 8080, Meta: map[string]string{"az": "1a"}}},
+	}
+	c := b.Copy()
+	assert.Equal(t, c, b, "Backend.Copy() returned an unexpected copy")
+
+	c.Meta["team"] = "b"
+	c.Members[0].Meta["az"] = "1b"
+	c.Members[0].Port = 9090
+	assert.Equal(t, b.Meta["team"], "a", "Backend.Copy() shared the metadata with the original")
+	assert.Equal(t, b.Members[0].Meta["az"], "1a", "Backend.Copy() shared the member metadata with the original")
+	assert.Equal(t, b.Members[0].Port, 8080, "Backend.Copy() shared the members with the original")
+}
+
+// Tests that the Backend.Expired() function only reports backends with a past expiry time.
+func Test_Backend_Expired(t *testing.T) {
+	now := time.Now()
+	assert.False(t, (&Backend{}).Expired(now), "Backend.Expired() reported a backend without an expiry time")
+	assert.False(t, (&Backend{ExpiresAt: now.Add(time.Minute)}).Expired(now), "Backend.Expired() reported a backend expiring later")
+	assert.True(t, (&Backend{ExpiresAt: now}).Expired(now), "Backend.Expired() did not report a backend expiring now")
+	assert.True(t, (&Backend{ExpiresAt: now.Add(-time.Minute)}).Expired(now), "Backend.Expired() did not report an expired backend")
+}
+
 // Tests that the Backends.ToInterfaces() function behaves correctly.
 func Test_Backends_ToInterfaces(t *testing.T) {
 	backends := Backends{&Backend{Name: "first"}, &Backend{Name: "second"}}
@@ -40,6 +70,69 @@ func Test_BackendMember_String(t *testing.T) {
 	assert.Equal(t, m.String(), expected, "BackendMember.String() returned unexpected result")
 }
 
+// Tests that the BackendMember.Address() function brackets IPv6 hosts.
+func Test_BackendMember_Address(t *testing.T) {
+	testCases := []struct{ Host, Result string }{
+		{Host: "10.2.2.10", Result: "10.2.2.10:8080"},
+		{Host: "app.example.com", Result: "app.example.com:8080"},
+		{Host: "::1", Result: "[::1]:8080"},
+		{Host: "2001:db8::10", Result: "[2001:db8::10]:8080"},
+	}
+	for _, tc := range testCases {
+		m := BackendMember{Host: tc.Host, Port: 8080}
+		assert.Equal(t, m.Address(), tc.Result, "BackendMember.Address() returned unexpected result for host '%s'", tc.Host)
+	}
+}
+
+// Tests that the BackendMember.UnmarshalJSON() function accepts ports encoded as whole-number floats.
+func Test_BackendMember_UnmarshalJSON_Port(t *testing.T) {
+	for _, port := range []string{"8080", "8080.0"} {
+		m := BackendMember{}
+		err := json.Unmarshal([]byte(`{"name":"m1","host":"10.2.2.10","port":`+port+`}`), &m)
+		assert.EnsureNil(t, err, "BackendMember.UnmarshalJSON() returned an unexpected error for port %s: %v", port, err)
+		assert.Equal(t, m, BackendMember{Name: "m1", Host: "10.2.2.10", Port: 8080},
+			"BackendMember.UnmarshalJSON() returned unexpected result for port %s", port)
+	}
+}
+
+// Tests that the BackendMember.UnmarshalJSON() function rejects ports that aren't whole numbers.
+func Test_BackendMember_UnmarshalJSON_FractionalPort(t *testing.T) {
+	m := BackendMember{}
+	err := json.Unmarshal([]byte(`{"name":"m1","port":8080.5}`), &m)
+	assert.EnsureNotNil(t, err, "BackendMember.UnmarshalJSON() failed to reject a fractional port")
+
+	derr, ok := err.(*Error)
+	assert.EnsureTrue(t, ok, "BackendMember.UnmarshalJSON() returned an unexpected error: %v", err)
+	assert.Equal(t, derr.Type, ErrBadData, "BackendMember.UnmarshalJSON() returned an unexpected error type")
+	fe, ok := derr.error.(*FieldError)
+	assert.EnsureTrue(t, ok, "BackendMember.UnmarshalJSON() returned an error that is not a FieldError: %v", derr)
+	assert.Equal(t, fe.Field, "port", "BackendMember.UnmarshalJSON() returned an error for an unexpected field")
+}
+
+// Tests that the BackendMember.UnmarshalJSON() function decodes the same lastKnown time from an
+// RFC3339 string and from epoch seconds.
+func Test_BackendMember_UnmarshalJSON_LastKnown(t *testing.T) {
+	exp := time.Date(2016, time.January, 2, 3, 4, 5, 0, time.UTC)
+	for _, lastKnown := range []string{`"2016-01-02T03:04:05Z"`, `"2016-01-02T04:04:05+01:00"`, "1451703845", "1451703845.0"} {
+		m := BackendMember{}
+		err := json.Unmarshal([]byte(`{"name":"m1","lastKnown":`+lastKnown+`}`), &m)
+		assert.EnsureNil(t, err, "BackendMember.UnmarshalJSON() returned an unexpected error for lastKnown %s: %v", lastKnown, err)
+		assert.True(t, m.LastKnown.Equal(exp), "BackendMember.UnmarshalJSON() returned unexpected lastKnown %v for %s", m.LastKnown, lastKnown)
+	}
+}
+
+// Tests that the BackendMember.UnmarshalJSON() function rejects a lastKnown time that is neither a
+// string nor a number.
+func Test_BackendMember_UnmarshalJSON_InvalidLastKnown(t *testing.T) {
+	m := BackendMember{}
+	err := json.Unmarshal([]byte(`{"name":"m1","lastKnown":true}`), &m)
+	assert.EnsureNotNil(t, err, "BackendMember.UnmarshalJSON() failed to reject an invalid lastKnown")
+
+	derr, ok := err.(*Error)
+	assert.EnsureTrue(t, ok, "BackendMember.UnmarshalJSON() returned an unexpected error: %v", err)
+	assert.Equal(t, derr.Type, ErrBadData, "BackendMember.UnmarshalJSON() returned an unexpected error type")
+}
+
 // Tests that the BackendMembers.ToInterfaces() function behaves correctly.
 func Test_BackendMembers_ToInterfaces(t *testing.T) {
 	members := BackendMembers{BackendMember{Name: "first"}, BackendMember{Name: "second"}}
@@ -64,6 +157,29 @@ func Test_Frontend_String(t *testing.T) {
 	assert.EnsureEqual(t, f.String(), f.Name, "Frontend.String() returned an unexpected result")
 }
 
+// Tests that the Frontend.Copy() function returns a copy that shares no rules or metadata with the
+// original.
+func Test_Frontend_Copy(t *testing.T) {
+	f := &Frontend{
+		Name:         "test-fe",
+		Rules:        []string{"acl a path_beg /a"},
+		RoutingRules: []Rule{{Condition: "path_beg /api", Backend: "api"}},
+		HTTPRequests: []string{"set-header X-Team a"},
+		Meta:         map[string]string{"team": "a"},
+	}
+	c := f.Copy()
+	assert.Equal(t, c, f, "Frontend.Copy() returned an unexpected copy")
+
+	c.Meta["team"] = "b"
+	c.Rules[0] = "acl b path_beg /b"
+	c.RoutingRules[0].Backend = "other"
+	c.HTTPRequests[0] = "set-header X-Team b"
+	assert.Equal(t, f.Meta["team"], "a", "Frontend.Copy() shared the metadata with the original")
+	assert.Equal(t, f.Rules[0], "acl a path_beg /a", "Frontend.Copy() shared the rules with the original")
+	assert.Equal(t, f.RoutingRules[0].Backend, "api", "Frontend.Copy() shared the routing rules with the original")
+	assert.Equal(t, f.HTTPRequests[0], "set-header X-Team a", "Frontend.Copy() shared the http-request rules with the original")
+}
+
 // Tests that the Frontends.ToInterfaces() function behaves correctly.
 func Test_Frontends_ToInterfaces(t *testing.T) {
 	frontends := Frontends{&Frontend{Name: "first"}, &Frontend{Name: "second"}}
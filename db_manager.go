@@ -1,40 +1,158 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"sync"
+
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	ldbutil "github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // DBManager interface defines methods for working with a database.
 type DBManager interface {
 	Close() error
 	NewDatastore() Datastore
+	Compact() error
+	Recover() error
 }
 
 type dbOpener func(dbPath string, o *opt.Options) (*leveldb.DB, error)
 type dbRecoverer func(dbPath string, o *opt.Options) (*leveldb.DB, error)
 
+// levelDBManager opens the database once and shares the handle among all the datastores it
+// creates. A *leveldb.DB is safe for concurrent use by multiple goroutines, but LevelDB holds an
+// exclusive lock on its files, so the same database can't be opened a second time while the handle
+// is open. The guard lets operations already in progress on the shared handle complete before it is
+// closed or replaced, and rejects operations that begin after it's closed.
 type levelDBManager struct {
+	namespace string
+	guard     *dbGuard
+
+	// the path of the database and the functions it's opened and recovered with
+	dbPath    string
+	opener    dbOpener
+	recoverer dbRecoverer
+}
+
+// dbGuard tracks the operations in progress on a shared database handle, so that the handle isn't
+// closed or replaced while they are running.
+type dbGuard struct {
+	mu     sync.RWMutex
+	closed bool
+
+	// the shared database handle, which is replaced when the database is recovered
 	db *leveldb.DB
 }
 
+// registers the start of an operation; false is returned if the database has been closed
+func (g *dbGuard) enter() bool {
+	g.mu.RLock()
+	if g.closed {
+		g.mu.RUnlock()
+		return false
+	}
+	return true
+}
+
+// registers the end of an operation
+func (g *dbGuard) exit() {
+	g.mu.RUnlock()
+}
+
+// waits for the operations in progress to complete and rejects any further operations; false is
+// returned if the guard was already closed
+func (g *dbGuard) close() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return false
+	}
+	g.closed = true
+	return true
+}
+
+// waits for the operations in progress to complete and replaces the database handle with the one
+// returned by fn, which is passed the current handle; operations that begin in the meantime wait
+// for it to return. The error returned by fn is returned; if fn returns no handle, the guard is
+// closed, so that later operations fail rather than use the closed handle.
+func (g *dbGuard) swap(fn func(db *leveldb.DB) (*leveldb.DB, error)) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return errors.New("the database has been closed")
+	}
+	db, err := fn(g.db)
+	if db == nil {
+		g.closed = true
+	}
+	g.db = db
+	return err
+}
+
 // NewDBManager will return a new DBManager instance.
 func NewDBManager(config *Config) (DBManager, error) {
-	db, err := openLevelDBFromFile(leveldb.OpenFile, leveldb.RecoverFile, config.DBPath, nil)
+	return newLevelDBManager(leveldb.OpenFile, leveldb.RecoverFile, config)
+}
+
+// returns a levelDBManager for the database of the given config, which is opened with the given
+// opener, or the given recoverer if it's corrupt
+func newLevelDBManager(opener dbOpener, recoverer dbRecoverer, config *Config) (*levelDBManager, error) {
+	db, err := openLevelDBFromFile(opener, recoverer, config.DBPath, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &levelDBManager{db: db}, nil
+	return &levelDBManager{
+		namespace: config.KeyNamespace,
+		guard:     &dbGuard{db: db},
+		dbPath:    config.DBPath,
+		opener:    opener,
+		recoverer: recoverer,
+	}, nil
 }
 
-// Close will close the database.
+// Close will wait for the operations in progress on the database to complete, and then close it.
 func (m *levelDBManager) Close() error {
-	return m.db.Close()
+	if !m.guard.close() {
+		return nil
+	}
+	return m.guard.db.Close()
 }
 
-// NewDatastore will return a new Datastore instance.
+// NewDatastore will return a new Datastore instance that reads and writes keys within the
+// manager's namespace. Datastores are safe for concurrent use, and any number of them may be in use
+// at once, since they all share the manager's database handle.
 func (m *levelDBManager) NewDatastore() Datastore {
-	return &levelDBDatastore{db: m.db}
+	return &levelDBDatastore{namespace: m.namespace, guard: m.guard}
+}
+
+// Compact will compact the entire database, discarding deleted and overwritten data to reclaim
+// disk space.
+func (m *levelDBManager) Compact() error {
+	if !m.guard.enter() {
+		return errors.New("the database has been closed")
+	}
+	defer m.guard.exit()
+	return m.guard.db.CompactRange(ldbutil.Range{})
+}
+
+// Recover will wait for the operations in progress on the database to complete, close it, and
+// reopen it through the recovery path, rebuilding its manifest from the data files; operations that
+// begin in the meantime wait for it to be reopened. If it can't be recovered, it's reopened as
+// before, and if that fails too, the database is left closed and later operations fail.
+func (m *levelDBManager) Recover() error {
+	return m.guard.swap(func(db *leveldb.DB) (*leveldb.DB, error) {
+		if err := db.Close(); err != nil {
+			return nil, err
+		}
+		recovered, err := attemptRecovery(m.recoverer, m.dbPath, nil)
+		if err != nil {
+			reopened, _ := openLevelDBFromFile(m.opener, m.recoverer, m.dbPath, nil)
+			return reopened, fmt.Errorf("the database could not be recovered: %v", err)
+		}
+		return recovered, nil
+	})
 }
 
 // OpenDBFromFile will attempt to open (or create) a connection to the database
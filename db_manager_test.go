@@ -3,12 +3,14 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"math/rand"
 	"os"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -67,103 +69,180 @@ func Test_levelDBManager_NewDatastore(t *testing.T) {
 }
 
 // ----------------------------------------------
-// openLevelDBFromFile TESTS
+// levelDBManager.Compact TESTS
 // ----------------------------------------------
 
-func Test_openLevelDBFromFile(t *testing.T) {
+func Test_levelDBManager_Compact(t *testing.T) {
 	// create db file for testing
 	dbPath := testHelpers.DBPath(t)
 	defer os.Remove(dbPath)
-	openDB, recoverDB := getMockRecoverableOpenerAndRecoverer()
-
-	// validate the function
-	_, err := openLevelDBFromFile(openDB, recoverDB, dbPath, nil)
-	assert.EnsureNil(t, err, "openLevelDBFromFile() returned an unexpected error: %v", err)
-}
-
-func Test_openLevelDBFromFile_Concurrently(t *testing.T) {
-
-	t.Skip("Skipping test. This test will deadlock.")
+	conf := &Config{DBPath: dbPath}
 
-	type Entry struct {
-		ID   int    `json:"id"`
-		Data string `json:"data"`
-	}
+	// create DBManager
+	dbMgr, err := NewDBManager(conf)
+	defer closeDB(dbMgr)
+	assert.EnsureNil(t, err, "NewDBManager() returned an unexpected error: %#v", err)
 
-	max := 3
-	ch := make(chan Entry, max)
+	// compact a database containing deleted data
+	db := dbMgr.NewDatastore()
+	b := &Backend{Name: "compacted"}
+	derr := db.SaveBackend(b)
+	assert.EnsureNil(t, derr, "levelDBDatastore.SaveBackend() returned an unexpected error: %v", derr)
+	derr = db.DeleteBackend(b.Name)
+	assert.EnsureNil(t, derr, "levelDBDatastore.DeleteBackend() returned an unexpected error: %v", derr)
+
+	err = dbMgr.Compact()
+	assert.Nil(t, err, "levelDBManager.Compact() returned an unexpected error: %v", err)
+}
 
-	name, err := ioutil.TempDir("", "conduit_test_db")
-	defer os.Remove(name)
+// ----------------------------------------------
+// levelDBManager.Recover TESTS
+// ----------------------------------------------
 
-	if err != nil {
-		t.Fatalf("Unexpected error creating the temp database: %s", err.Error())
+// Tests that levelDBManager.Recover() reopens the database through the recovery path, and that a
+// datastore created beforehand can still read and write it afterward.
+func Test_levelDBManager_Recover(t *testing.T) {
+	// create db file for testing
+	dbPath := testHelpers.DBPath(t)
+	defer os.Remove(dbPath)
+	openDB, recoverDB := getMockRecoverableOpenerAndRecoverer()
+	recoveries := 0
+	countingRecoverDB := func(dbPath string, o *opt.Options) (*leveldb.DB, error) {
+		recoveries++
+		return recoverDB(dbPath, o)
 	}
 
-	tester := func(dbPath string, index int) {
-
-		log.Printf("[GoRoutine %d] Opening database at: %v\n", index, dbPath)
-
-		db, err := openLevelDBFromFile(leveldb.OpenFile, leveldb.RecoverFile, name, nil)
-
-		if err != nil {
-			t.Fatalf("Unexpected error opening the database file: %v", err.Error())
-		}
+	dbMgr, err := newLevelDBManager(openDB, countingRecoverDB, &Config{DBPath: dbPath})
+	defer closeDB(dbMgr)
+	assert.EnsureNil(t, err, "newLevelDBManager() returned an unexpected error: %v", err)
+	db := dbMgr.NewDatastore()
+	derr := db.SaveBackend(&Backend{Name: "before"})
+	assert.EnsureNil(t, derr, "levelDBDatastore.SaveBackend() returned an unexpected error: %v", derr)
+
+	// recover the database
+	recoveries = 0
+	err = dbMgr.Recover()
+	assert.EnsureNil(t, err, "levelDBManager.Recover() returned an unexpected error: %v", err)
+	assert.Equal(t, recoveries, 1, "levelDBManager.Recover() did not use the recovery path")
+
+	// validate that the database is usable afterward
+	b, derr := db.GetBackend("before")
+	assert.EnsureNil(t, derr, "levelDBDatastore.GetBackend() returned an unexpected error after recovery: %v", derr)
+	assert.EnsureNotNil(t, b, "levelDBDatastore.GetBackend() lost a backend saved before recovery")
+	derr = db.SaveBackend(&Backend{Name: "after"})
+	assert.EnsureNil(t, derr, "levelDBDatastore.SaveBackend() returned an unexpected error after recovery: %v", derr)
+}
 
-		log.Printf("[GoRoutine %d] Opened database at: %v\n", index, dbPath)
+// Tests that levelDBManager.Recover() returns an error, and leaves the database closed, when the
+// database can neither be recovered nor reopened.
+func Test_levelDBManager_Recover_Error(t *testing.T) {
+	// create db file for testing
+	dbPath := testHelpers.DBPath(t)
+	defer os.Remove(dbPath)
+	openDB, recoverDB := getMockRecoverableOpenerAndRecoverer()
+	_, corruptRecoverDB := getMockCorruptOpenerAndRecoverer()
 
-		data := strconv.FormatInt(rand.Int63(), 10)
+	dbMgr, err := newLevelDBManager(openDB, recoverDB, &Config{DBPath: dbPath})
+	defer closeDB(dbMgr)
+	assert.EnsureNil(t, err, "newLevelDBManager() returned an unexpected error: %v", err)
+	dbMgr.recoverer = corruptRecoverDB
 
-		log.Printf("[GoRoutine %d] Setting data field to: %v\n", index, data)
+	err = dbMgr.Recover()
+	assert.EnsureNotNil(t, err, "levelDBManager.Recover() failed to return an error")
+	assert.StringContains(t, err.Error(), "could not be recovered", "levelDBManager.Recover() returned an unexpected error")
 
-		dbEntry, err := json.Marshal(Entry{ID: index, Data: data})
+	_, derr := dbMgr.NewDatastore().GetBackend("any")
+	assert.EnsureNotNil(t, derr, "levelDBDatastore.GetBackend() used the database after a failed recovery")
+	assert.Equal(t, derr.Type, ErrDB, "levelDBDatastore.GetBackend() returned an unexpected error type")
+}
 
-		log.Printf("[GoRoutine %d] Generated data: %s\n", index, string(dbEntry))
+// ----------------------------------------------
+// openLevelDBFromFile TESTS
+// ----------------------------------------------
 
-		if err != nil {
-			t.Fatalf("Unexpected error converting entry to a []byte: %v", err.Error)
-		}
+func Test_openLevelDBFromFile(t *testing.T) {
+	// create db file for testing
+	dbPath := testHelpers.DBPath(t)
+	defer os.Remove(dbPath)
+	openDB, recoverDB := getMockRecoverableOpenerAndRecoverer()
 
-		err = db.Put([]byte(string(index)), dbEntry, nil)
-		if err != nil {
-			t.Fatalf("Unexpected error writing to the database: %v", err.Error())
-		}
+	// validate the function
+	_, err := openLevelDBFromFile(openDB, recoverDB, dbPath, nil)
+	assert.EnsureNil(t, err, "openLevelDBFromFile() returned an unexpected error: %v", err)
+}
 
-		waitSeconds := rand.Intn(10)
+// Tests that datastores created by the levelDBManager.NewDatastore() function can read and write
+// concurrently through the shared database handle.
+func Test_levelDBManager_NewDatastore_Concurrently(t *testing.T) {
+	// create db file for testing
+	dbPath := testHelpers.DBPath(t)
+	defer os.Remove(dbPath)
+	conf := &Config{DBPath: dbPath}
 
-		log.Printf("[GoRoutine %d] Sleeping for %d seconds...\n", index, waitSeconds)
+	// create DBManager
+	dbMgr, err := NewDBManager(conf)
+	defer closeDB(dbMgr)
+	assert.EnsureNil(t, err, "NewDBManager() returned an unexpected error: %#v", err)
 
-		select {
-		case <-time.After(time.Duration(waitSeconds) * time.Second):
-			found, err := db.Get([]byte(string(index)), nil)
-			if err != nil {
-				t.Fatalf("Unexpected error reading from the database: %v", err.Error())
+	// each goroutine writes and reads back its own backend through its own datastore
+	max := 10
+	errs := make(chan error, max)
+	var wg sync.WaitGroup
+	for i := 0; i < max; i++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			db := dbMgr.NewDatastore()
+			b := &Backend{Name: fmt.Sprintf("backend-%d", index), Version: strconv.Itoa(index)}
+			if derr := db.SaveBackend(b); derr != nil {
+				errs <- derr
+				return
 			}
-
-			log.Printf("[GoRoutine %d] Retrieved data: %v\n", index, string(found))
-
-			result := &Entry{}
-			err = json.Unmarshal(found, result)
-			if err != nil {
-				t.Fatalf("Unexpected error converting retrieved result to an &entry{}: %v", err.Error())
+			found, derr := db.GetBackend(b.Name)
+			if derr != nil {
+				errs <- derr
+				return
 			}
+			if found == nil || found.Version != b.Version {
+				errs <- fmt.Errorf("backend %s was not read back as written: %v", b.Name, found)
+				return
+			}
+			if _, derr := db.GetAllBackends(); derr != nil {
+				errs <- derr
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent datastore operation failed: %v", err)
+	}
 
-			db.Close()
+	backends, derr := dbMgr.NewDatastore().GetAllBackends()
+	assert.EnsureNil(t, derr, "levelDBDatastore.GetAllBackends() returned an unexpected error: %v", derr)
+	assert.Equal(t, len(backends), max, "levelDBDatastore.GetAllBackends() returned an unexpected number of values")
+}
 
-			ch <- *result
-		}
-	}
+// Tests that the datastores created by a levelDBManager reject operations once it has been closed.
+func Test_levelDBManager_Close(t *testing.T) {
+	// create db file for testing
+	dbPath := testHelpers.DBPath(t)
+	defer os.Remove(dbPath)
+	conf := &Config{DBPath: dbPath}
 
-	for i := 0; i < max; i++ {
-		go tester(name, i)
-	}
+	// create DBManager
+	dbMgr, err := NewDBManager(conf)
+	assert.EnsureNil(t, err, "NewDBManager() returned an unexpected error: %#v", err)
+	db := dbMgr.NewDatastore()
 
-	count := 0
-	for count < max {
-		r := <-ch
-		log.Printf("[GoRoutine %d] Exited with data: %v\n", r.ID, r.Data)
-		count++
-	}
+	err = dbMgr.Close()
+	assert.EnsureNil(t, err, "levelDBManager.Close() returned an unexpected error: %v", err)
+	err = dbMgr.Close()
+	assert.Nil(t, err, "levelDBManager.Close() returned an unexpected error when closed twice: %v", err)
+
+	_, derr := db.GetAllBackends()
+	assert.EnsureNotNil(t, derr, "levelDBDatastore.GetAllBackends() failed to return an error after close")
+	assert.Equal(t, derr.Type, ErrDB, "levelDBDatastore.GetAllBackends() returned an unexpected error type")
 }
 
 func Test_openLevelDBFromFile_AsSingleton(t *testing.T) {
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// number of unchanged lines shown around each change in a unified diff
+const diffContextLines = 3
+
+// diffLine is a single line of a line-based diff: ' ' for an unchanged line, '-' for a line that
+// was removed, and '+' for a line that was added.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the line-based unified diff that turns the from text into the to text, or an
+// empty string if the texts are identical.
+func unifiedDiff(fromName, toName, from, to string) string {
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	// find the ranges of lines to show, merging changes whose context would overlap
+	type hunk struct{ start, end int }
+	hunks := []hunk{}
+	for i, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		start, end := i-diffContextLines, i+diffContextLines+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+			continue
+		}
+		hunks = append(hunks, hunk{start, end})
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", fromName, toName)
+	fromLine, toLine, next := 0, 0, 0
+	for _, h := range hunks {
+		// count the lines of each text that precede the hunk
+		for ; next < h.start; next++ {
+			fromLine, toLine = advanceDiffLine(lines[next], fromLine, toLine)
+		}
+		fromCount, toCount := 0, 0
+		for _, l := range lines[h.start:h.end] {
+			fromCount, toCount = advanceDiffLine(l, fromCount, toCount)
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", diffRange(fromLine, fromCount), diffRange(toLine, toCount))
+		for _, l := range lines[h.start:h.end] {
+			buf.WriteByte(l.op)
+			buf.WriteString(l.text)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String()
+}
+
+// returns the line-level edits that turn a into b, based on their longest common subsequence;
+// removed lines are listed before the lines that replace them
+func diffLines(a, b []string) []diffLine {
+	// the common prefix and suffix are unchanged, so only the lines between them are compared
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	am, bm := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	// lcs[i][j] is the length of the longest common subsequence of am[i:] and bm[j:]
+	lcs := make([][]int, len(am)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bm)+1)
+	}
+	for i := len(am) - 1; i >= 0; i-- {
+		for j := len(bm) - 1; j >= 0; j-- {
+			switch {
+			case am[i] == bm[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]diffLine, 0, len(a)+len(b)-pre-suf)
+	for _, l := range a[:pre] {
+		lines = append(lines, diffLine{' ', l})
+	}
+	i, j := 0, 0
+	for i < len(am) || j < len(bm) {
+		switch {
+		case i < len(am) && j < len(bm) && am[i] == bm[j]:
+			lines = append(lines, diffLine{' ', am[i]})
+			i++
+			j++
+		case i == len(am) || (j < len(bm) && lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, diffLine{'+', bm[j]})
+			j++
+		default:
+			lines = append(lines, diffLine{'-', am[i]})
+			i++
+		}
+	}
+	for _, l := range a[len(a)-suf:] {
+		lines = append(lines, diffLine{' ', l})
+	}
+	return lines
+}
+
+// splits the given text into lines, ignoring the newline that ends the last line
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// advances the from and to line counts past the given diff line
+func advanceDiffLine(l diffLine, from, to int) (int, int) {
+	if l.op != '+' {
+		from++
+	}
+	if l.op != '-' {
+		to++
+	}
+	return from, to
+}
+
+// formats the range of a unified diff hunk header from the number of lines that precede the hunk
+// and the number of lines within it
+func diffRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
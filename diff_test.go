@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+// Tests that the unifiedDiff() function produces the expected hunks.
+func Test_unifiedDiff(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		From    string
+		To      string
+		ExpDiff string
+	}{
+		{
+			Name:    "Identical",
+			From:    "a\nb\n",
+			To:      "a\nb\n",
+			ExpDiff: "",
+		},
+		{
+			Name:    "Changed",
+			From:    "a\nb\nc\n",
+			To:      "a\nx\nc\n",
+			ExpDiff: "--- from\n+++ to\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			Name:    "AddedToEmpty",
+			From:    "",
+			To:      "a\nb\n",
+			ExpDiff: "--- from\n+++ to\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			Name:    "SeparateHunks",
+			From:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			To:      "x\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			ExpDiff: "--- from\n+++ to\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -7,4 +7,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		diff := unifiedDiff("from", "to", testCase.From, testCase.To)
+		assert.Equal(t, diff, testCase.ExpDiff, "unifiedDiff() returned an unexpected diff in test '%s'", testCase.Name)
+	}
+}
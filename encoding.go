@@ -1,7 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+const (
+	// fieldStyleCamel leaves JSON keys in the camelCase style of the struct tags.
+	fieldStyleCamel = "camelCase"
+	// fieldStyleSnake rewrites JSON keys to snake_case.
+	fieldStyleSnake = "snake_case"
 )
 
 // Encoder is an interface that defines the functions that an encoder should provide.
@@ -12,7 +23,15 @@ type Encoder interface {
 }
 
 // JSONEncoder extends the Encoder interface and provides JSON encoding.
-type JSONEncoder struct{}
+type JSONEncoder struct {
+	// PreserveUnknownFields causes Decode to capture any top-level JSON keys that don't map to a
+	// field of the target struct into the struct's Meta map, rather than discarding them.
+	PreserveUnknownFields bool
+
+	// FieldStyle is the naming style of the JSON keys that are encoded and decoded, either
+	// camelCase (the default) or snake_case; the keys of meta maps are never rewritten.
+	FieldStyle string
+}
 
 // Encode attempts to encode any struct into a JSON string.
 func (e JSONEncoder) Encode(v interface{}) string {
@@ -21,7 +40,7 @@ func (e JSONEncoder) Encode(v interface{}) string {
 	if err != nil {
 		panic(err)
 	}
-	return string(b)
+	return e.styleKeys(b)
 }
 
 // EncodeMulti converts any collection of structs into a JSON string (array).
@@ -35,14 +54,177 @@ func (e JSONEncoder) EncodeMulti(v ...interface{}) string {
 	if err != nil {
 		panic(err)
 	}
-	return string(b)
+	return e.styleKeys(b)
 }
 
 // Decode loads the specified struct with the given JSON byte array.
 func (e JSONEncoder) Decode(b []byte, i interface{}) error {
-	err := json.Unmarshal(b, i)
+	data := b
+	if e.FieldStyle == fieldStyleSnake {
+		var err error
+		if data, err = e.renameKeys(b, snakeToCamel); err != nil {
+			return err
+		}
+	}
+	err := json.Unmarshal(data, i)
 	if err != nil {
 		return err
 	}
+	if e.PreserveUnknownFields {
+		return e.decodeUnknownFields(b, i)
+	}
 	return nil
 }
+
+// captures the top-level JSON keys that don't map to a field of the given struct into its
+// Meta map; string values are stored as-is and any other values are stored as raw JSON
+func (e JSONEncoder) decodeUnknownFields(b []byte, i interface{}) error {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	v = v.Elem()
+	meta := v.FieldByName("Meta")
+	if !meta.IsValid() || meta.Type() != reflect.TypeOf(map[string]string{}) {
+		return nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	known := e.jsonFieldNames(v.Type())
+	for key, raw := range fields {
+		name := key
+		if e.FieldStyle == fieldStyleSnake {
+			name = snakeToCamel(key)
+		}
+		if known[strings.ToLower(name)] {
+			continue
+		}
+		if meta.IsNil() {
+			meta.Set(reflect.ValueOf(map[string]string{}))
+		}
+		var str string
+		if err := json.Unmarshal(raw, &str); err != nil {
+			str = string(raw)
+		}
+		meta.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(str))
+	}
+	return nil
+}
+
+// returns the lowercased JSON key names that map to the fields of the given struct type
+func (e JSONEncoder) jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		// encoding/json matches keys to fields case-insensitively
+		names[strings.ToLower(name)] = true
+	}
+	return names
+}
+
+// rewrites the keys of the given encoded JSON to the configured field style
+func (e JSONEncoder) styleKeys(b []byte) string {
+	if e.FieldStyle != fieldStyleSnake {
+		return string(b)
+	}
+	b, err := e.renameKeys(b, camelToSnake)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// rewrites the object keys of the given JSON value with the rename function, preserving the order
+// of the keys; the keys of "meta" objects hold user data and are left unchanged
+func (e JSONEncoder) renameKeys(raw []byte, rename func(string) string) ([]byte, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || (raw[0] != '{' && raw[0] != '[') {
+		return raw, nil
+	}
+	isObject := raw[0] == '{'
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte(raw[0])
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key := ""
+		if isObject {
+			t, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ = t.(string)
+			k, _ := json.Marshal(rename(key))
+			buf.Write(k)
+			buf.WriteByte(':')
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		if !isObject || strings.ToLower(key) != "meta" {
+			b, err := e.renameKeys(v, rename)
+			if err != nil {
+				return nil, err
+			}
+			v = b
+		}
+		buf.Write(v)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if isObject {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+	return buf.Bytes(), nil
+}
+
+// converts a camelCase name to snake_case, e.g. "defaultBackend" to "default_backend"
+func camelToSnake(s string) string {
+	runes := []rune(s)
+	var buf bytes.Buffer
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// start a new word at a lower-to-upper boundary, or at the last capital of an acronym
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				buf.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// converts a snake_case name to camelCase, e.g. "default_backend" to "defaultBackend"
+func snakeToCamel(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -78,3 +78,93 @@ func Test_JSONEncoder_Decode(t *testing.T) {
 	_ = enc.Decode([]byte(str), &actual)
 	assert.Equal(t, actual, expected, "JSONEncoder.Decode() returned unexpected value")
 }
+
+// Tests that the JSONEncoder.Decode() function captures unknown fields into Meta when enabled.
+func Test_JSONEncoder_Decode_PreserveUnknownFields(t *testing.T) {
+	str := `{"name":"app","mode":"http","owner":"team-a","replicas":3,"meta":{"env":"prod"}}`
+
+	b := &Backend{}
+	enc := JSONEncoder{PreserveUnknownFields: true}
+	err := enc.Decode([]byte(str), b)
+	assert.EnsureNil(t, err, "JSONEncoder.Decode() returned an unexpected error: %v", err)
+
+	expMeta := map[string]string{"env": "prod", "owner": "team-a", "replicas": "3"}
+	assert.Equal(t, b.Name, "app", "JSONEncoder.Decode() returned unexpected value")
+	assert.Equal(t, b.Meta, expMeta, "JSONEncoder.Decode() did not capture unknown fields into Meta")
+}
+
+// Tests that the JSONEncoder.Decode() function discards unknown fields by default.
+func Test_JSONEncoder_Decode_DiscardUnknownFields(t *testing.T) {
+	str := `{"name":"app","owner":"team-a"}`
+
+	b := &Backend{}
+	enc := JSONEncoder{}
+	err := enc.Decode([]byte(str), b)
+	assert.EnsureNil(t, err, "JSONEncoder.Decode() returned an unexpected error: %v", err)
+	assert.Nil(t, b.Meta, "JSONEncoder.Decode() captured unknown fields when not enabled")
+}
+
+// ----------------------------------------------
+// JSONEncoder.FieldStyle TESTS
+// ----------------------------------------------
+
+// Tests that the JSONEncoder encodes keys in the configured field style.
+func Test_JSONEncoder_Encode_FieldStyle(t *testing.T) {
+	f := &Frontend{Name: "app", DefaultBackend: "live", Meta: map[string]string{"ownerTeam": "web"}}
+	testCases := []struct {
+		Style    string
+		Expected string
+	}{
+		{
+			Style:    "",
+			Expected: `{"name":"app","bind":"","defaultBackend":"live","mode":"","keepalive":"","option":"","maxconn":0,"rules":null,"routingRules":null,"httpRequests":null,"expiresAt":"0001-01-01T00:00:00Z","modifiedAt":"0001-01-01T00:00:00Z","meta":{"ownerTeam":"web"}}`,
+		},
+		{
+			Style:    fieldStyleCamel,
+			Expected: `{"name":"app","bind":"","defaultBackend":"live","mode":"","keepalive":"","option":"","maxconn":0,"rules":null,"routingRules":null,"httpRequests":null,"expiresAt":"0001-01-01T00:00:00Z","modifiedAt":"0001-01-01T00:00:00Z","meta":{"ownerTeam":"web"}}`,
+		},
+		{
+			Style:    fieldStyleSnake,
+			Expected: `{"name":"app","bind":"","default_backend":"live","mode":"","keepalive":"","option":"","maxconn":0,"rules":null,"routing_rules":null,"http_requests":null,"expires_at":"0001-01-01T00:00:00Z","modified_at":"0001-01-01T00:00:00Z","meta":{"ownerTeam":"web"}}`,
+		},
+	}
+	for _, tc := range testCases {
+		enc := JSONEncoder{FieldStyle: tc.Style}
+		assert.Equal(t, enc.Encode(f), tc.Expected, "JSONEncoder.Encode() returned unexpected keys for style '%s'", tc.Style)
+		assert.Equal(t, enc.EncodeMulti(f), "["+tc.Expected+"]", "JSONEncoder.EncodeMulti() returned unexpected keys for style '%s'", tc.Style)
+	}
+}
+
+// Tests that nested keys are rewritten in the snake_case field style.
+func Test_JSONEncoder_Encode_SnakeCaseNested(t *testing.T) {
+	m := BackendMember{Name: "node1", Host: "10.0.0.1", Port: 80}
+	enc := JSONEncoder{FieldStyle: fieldStyleSnake}
+	actual := enc.Encode(&Backend{Name: "app", Members: BackendMembers{m}})
+	assert.StringContains(t, actual, `"last_known":`, "JSONEncoder.Encode() did not rewrite nested keys")
+	assert.StringContains(t, actual, `"reload_strategy":`, "JSONEncoder.Encode() did not rewrite keys")
+}
+
+// Tests that the JSONEncoder decodes keys in the snake_case field style.
+func Test_JSONEncoder_Decode_SnakeCase(t *testing.T) {
+	enc := JSONEncoder{FieldStyle: fieldStyleSnake, PreserveUnknownFields: true}
+	f := &Frontend{}
+	err := enc.Decode([]byte(`{"name":"app","default_backend":"live","owner_team":"web"}`), f)
+	assert.EnsureNil(t, err, "JSONEncoder.Decode() returned an unexpected error: %v", err)
+	assert.Equal(t, f.DefaultBackend, "live", "JSONEncoder.Decode() did not read a snake_case key")
+	assert.Equal(t, f.Meta, map[string]string{"owner_team": "web"}, "JSONEncoder.Decode() rewrote an unknown key")
+}
+
+// Tests the conversions between camelCase and snake_case names.
+func Test_camelToSnake(t *testing.T) {
+	testCases := []struct{ Camel, Snake string }{
+		{Camel: "name", Snake: "name"},
+		{Camel: "defaultBackend", Snake: "default_backend"},
+		{Camel: "lastKnown", Snake: "last_known"},
+		{Camel: "httpRequests", Snake: "http_requests"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, camelToSnake(tc.Camel), tc.Snake, "camelToSnake() returned unexpected result for '%s'", tc.Camel)
+		assert.Equal(t, snakeToCamel(tc.Snake), tc.Camel, "snakeToCamel() returned unexpected result for '%s'", tc.Snake)
+	}
+	assert.Equal(t, camelToSnake("HTTPRequests"), "http_requests", "camelToSnake() returned unexpected result for an acronym")
+}
@@ -19,6 +19,9 @@ const (
 	ErrOutOfSync
 	// ErrDB indicates that a problem occurred reading or writing to the database.
 	ErrDB
+	// ErrDrift indicates that the haproxy config file has been changed outside of Conduit and the
+	// requested action has been refused.
+	ErrDrift
 	// ErrUnknown indicates that an unknown error has occurred.
 	ErrUnknown
 )
@@ -38,6 +41,8 @@ func (t ErrorType) String() string {
 		return "ErrOutOfSync"
 	case ErrDB:
 		return "ErrDB"
+	case ErrDrift:
+		return "ErrDrift"
 	case ErrUnknown:
 		return "ErrUnknown"
 	}
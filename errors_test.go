@@ -17,6 +17,7 @@ func Test_ErrorType_String(t *testing.T) {
 	assert.Equal(t, ErrSync.String(), "ErrSync", "ErrorType.String() returned an unexpected value")
 	assert.Equal(t, ErrOutOfSync.String(), "ErrOutOfSync", "ErrorType.String() returned an unexpected value")
 	assert.Equal(t, ErrDB.String(), "ErrDB", "ErrorType.String() returned an unexpected value")
+	assert.Equal(t, ErrDrift.String(), "ErrDrift", "ErrorType.String() returned an unexpected value")
 	assert.Equal(t, ErrUnknown.String(), "ErrUnknown", "ErrorType.String() returned an unexpected value")
 
 	var ErrTest ErrorType = 99
@@ -2,21 +2,40 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
 	"net/http"
 )
 
 // GetFrontends returns a list of HAProxy frontends.
-func GetFrontends(w http.ResponseWriter, enc Encoder, svc DataSvc) {
+func GetFrontends(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc) {
+	opts, e := util{}.parseListOptions(r)
+	if e != nil {
+		util{}.badRequest(w, enc, e.Error())
+		return
+	}
+
 	f, err := svc.GetAllFrontends()
 	if err != nil {
 		panic(err)
 	}
-	util{}.writeResponse(w, http.StatusOK, enc.EncodeMulti(f.ToInterfaces()...))
+	if !opts.ModifiedSince.IsZero() {
+		f = f.ModifiedSince(opts.ModifiedSince)
+	}
+	util{}.writeList(w, enc, f.ToInterfaces(), opts)
 }
 
-// GetFrontend returns the requested HAProxy frontend.
-func GetFrontend(w http.ResponseWriter, enc Encoder, svc DataSvc, params Params) {
+// GetFrontend returns the requested HAProxy frontend; with ?raw=true, its stored form is returned
+// instead.
+func GetFrontend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc, params Params) {
+	raw, e := util{}.parseRaw(r)
+	if e != nil {
+		util{}.badRequest(w, enc, e.Error())
+		return
+	}
+	if raw {
+		util{}.writeRaw(w, enc, svc, "frontend", params["name"])
+		return
+	}
+
 	data, err := svc.GetFrontend(params["name"])
 	if err != nil {
 		panic(err)
@@ -25,7 +44,20 @@ func GetFrontend(w http.ResponseWriter, enc Encoder, svc DataSvc, params Params)
 		util{}.notFound(w, enc, fmt.Sprintf("the frontend with name %s does not exist", params["name"]))
 		return
 	}
-	util{}.writeResponse(w, http.StatusOK, enc.Encode(data))
+	util{}.writeResponse(w, http.StatusOK, enc.Encode(util{}.project(enc, data, util{}.parseFields(r))))
+}
+
+// HeadFrontend reports whether the requested HAProxy frontend exists without returning its body.
+func HeadFrontend(w http.ResponseWriter, svc DataSvc, params Params) {
+	data, err := svc.GetFrontend(params["name"])
+	if err != nil {
+		panic(err)
+	}
+	if data == nil {
+		util{}.writeResponse(w, http.StatusNotFound, "")
+		return
+	}
+	util{}.writeResponse(w, http.StatusOK, "")
 }
 
 // PutFrontend creates or updates an HAProxy frontend.
@@ -33,7 +65,7 @@ func PutFrontend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSv
 	f := &Frontend{}
 	e := loadFrontendFromRequest(r, enc, f)
 	if e != nil {
-		util{}.badRequest(w, enc, "the frontend data is invalid")
+		util{}.badRequest(w, enc, e.Message)
 		return
 	}
 
@@ -51,18 +83,25 @@ func PutFrontend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSv
 
 	err = svc.SaveFrontend(f)
 	if err != nil {
-		switch err.Type {
-		case ErrBadData:
-			util{}.badRequest(w, enc, err.Error())
-			return
-		default:
-			panic(err)
-		}
+		util{}.writeClientError(w, enc, err)
+		return
 	}
 
 	util{}.writeResponse(w, status, enc.Encode(f))
 }
 
+// PostFrontendsValidate checks a proposed HAProxy frontend against the validation rules without
+// saving it; the response lists every field error, with a 400 status if there are any.
+func PostFrontendsValidate(w http.ResponseWriter, r *http.Request, enc Encoder) {
+	f := &Frontend{}
+	if e := loadFrontendFromRequest(r, enc, f); e != nil {
+		util{}.badRequest(w, enc, e.Message)
+		return
+	}
+	status, body := validationResponse(enc, ValidateFrontend(f))
+	util{}.writeResponse(w, status, body)
+}
+
 // PostFrontend performs a partial update of an existing HAProxy frontend.
 func PostFrontend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataSvc, params Params) {
 	name := params["name"]
@@ -77,19 +116,14 @@ func PostFrontend(w http.ResponseWriter, r *http.Request, enc Encoder, svc DataS
 
 	e := loadFrontendFromRequest(r, enc, f)
 	if e != nil {
-		util{}.badRequest(w, enc, "the frontend data is invalid")
+		util{}.badRequest(w, enc, e.Message)
 		return
 	}
 
 	err = svc.SaveFrontend(f)
 	if err != nil {
-		switch err.Type {
-		case ErrBadData:
-			util{}.badRequest(w, enc, err.Error())
-			return
-		default:
-			panic(err)
-		}
+		util{}.writeClientError(w, enc, err)
+		return
 	}
 
 	util{}.writeResponse(w, http.StatusOK, enc.Encode(f))
@@ -100,13 +134,11 @@ func DeleteFrontend(w http.ResponseWriter, enc Encoder, svc DataSvc, params Para
 	key := params["name"]
 	err := svc.DeleteFrontend(key)
 	if err != nil {
-		switch err.Type {
-		case ErrNotFound:
-			util{}.notFound(w, enc, fmt.Sprintf("the frontend with name %s does not exist", key))
-			return
-		default:
-			panic(err)
+		if err.Type == ErrNotFound {
+			err = NewErrorf(ErrNotFound, "the frontend with name %s does not exist", key)
 		}
+		util{}.writeClientError(w, enc, err)
+		return
 	}
 	util{}.writeResponse(w, http.StatusNoContent, "")
 }
@@ -114,11 +146,14 @@ func DeleteFrontend(w http.ResponseWriter, enc Encoder, svc DataSvc, params Para
 // parse request body into a Frontend instance
 func loadFrontendFromRequest(r *http.Request, enc Encoder, f *Frontend) *ErrorResponse {
 	// TODO: Don't use ReadAll()... reading a terabyte of data in one go would be bad
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		panic(err)
+	body, derr := util{}.readBody(r)
+	if derr != nil {
+		if derr.Type == ErrBadData {
+			return NewErrorResponse(http.StatusBadRequest, derr.Error())
+		}
+		panic(derr)
 	}
-	err = enc.Decode(body, f)
+	err := enc.Decode(body, f)
 	if err != nil {
 		return NewErrorResponse(http.StatusBadRequest, fmt.Sprintf("the frontend data is not valid"))
 	}
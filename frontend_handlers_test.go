@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 type frontendHandlersTestCase struct {
@@ -21,6 +22,7 @@ func (c frontendHandlersTestCase) execute() {
 		Params:    make(map[string]string),
 		ResWriter: httptest.NewRecorder(),
 	}
+	mocks.Request, _ = http.NewRequest("GET", "/frontends", nil)
 
 	// perform setup
 	if c.Setup != nil {
@@ -61,7 +63,7 @@ func Test_GetFrontends(t *testing.T) {
 
 	testAction := func(m *frontendHandlersMocks) {
 		// retrieve and validate data
-		GetFrontends(m.ResWriter, m.Enc, m.Svc)
+		GetFrontends(m.ResWriter, m.Request, m.Enc, m.Svc)
 		expBody := m.Enc.EncodeMulti(f1, f2)
 		assert.Equal(t, m.ResWriter.Body.String(), expBody, "GetFrontends() returned an unexpected body")
 	}
@@ -73,6 +75,21 @@ func Test_GetFrontends(t *testing.T) {
 	}.execute()
 }
 
+func Test_GetFrontends_Empty(t *testing.T) {
+	testAction := func(m *frontendHandlersMocks) {
+		// retrieve and validate data
+		GetFrontends(m.ResWriter, m.Request, m.Enc, m.Svc)
+		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "GetFrontends() returned unexpected status code")
+		assert.Equal(t, m.ResWriter.Body.String(), "[]", "GetFrontends() returned an unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
 func Test_GetFrontends_SvcError(t *testing.T) {
 	setup := func(m *frontendHandlersMocks) {
 		m.Svc.GetAllError = NewErrorf(ErrUnknown, "")
@@ -80,7 +97,7 @@ func Test_GetFrontends_SvcError(t *testing.T) {
 
 	testAction := func(m *frontendHandlersMocks) {
 		// execute function to test, check for panic
-		f := func() { GetFrontends(m.ResWriter, m.Enc, m.Svc) }
+		f := func() { GetFrontends(m.ResWriter, m.Request, m.Enc, m.Svc) }
 		assert.Panic(t, f, "GetFrontends() failed to panic when expected")
 	}
 
@@ -91,10 +108,122 @@ func Test_GetFrontends_SvcError(t *testing.T) {
 	}.execute()
 }
 
+func Test_GetFrontends_Envelope(t *testing.T) {
+	f1 := fData.OneFrontend()
+	f2 := fData.OtherFrontend()
+
+	setup := func(m *frontendHandlersMocks) {
+		m.Svc.SaveFrontend(f1)
+		m.Svc.SaveFrontend(f2)
+		m.Request, _ = http.NewRequest("GET", "/frontends?envelope=true&limit=1&offset=1", nil)
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		// retrieve and validate data
+		GetFrontends(m.ResWriter, m.Request, m.Enc, m.Svc)
+		expBody := m.Enc.Encode(&ListEnvelope{Data: []interface{}{f2}, Total: 2, Limit: 1, Offset: 1})
+		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "GetFrontends() returned unexpected status code")
+		assert.Equal(t, m.ResWriter.Body.String(), expBody, "GetFrontends() returned an unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetFrontends_EnvelopeEmpty(t *testing.T) {
+	setup := func(m *frontendHandlersMocks) {
+		m.Request, _ = http.NewRequest("GET", "/frontends?envelope=true", nil)
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		// retrieve and validate data
+		GetFrontends(m.ResWriter, m.Request, m.Enc, m.Svc)
+		expBody := `{"data":[],"total":0,"limit":0,"offset":0}`
+		assert.Equal(t, m.ResWriter.Body.String(), expBody, "GetFrontends() returned an unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetFrontends_InvalidListParams(t *testing.T) {
+	setup := func(m *frontendHandlersMocks) {
+		m.Request, _ = http.NewRequest("GET", "/frontends?limit=-1", nil)
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		GetFrontends(m.ResWriter, m.Request, m.Enc, m.Svc)
+		expCode := http.StatusBadRequest
+		expBody := fmt.Sprintf(`"code":%d`, expCode)
+		assert.Equal(t, m.ResWriter.Code, expCode, "GetFrontends() returned unexpected status code")
+		assert.StringContains(t, m.ResWriter.Body.String(), expBody, "GetFrontends() returned unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
 // ----------------------------------------------
 // GetFrontend TESTS
 // ----------------------------------------------
 
+// Tests that the GetFrontends() handler lists only the frontends modified after the modifiedSince
+// time.
+func Test_GetFrontends_ModifiedSince(t *testing.T) {
+	since := time.Date(2015, 6, 12, 14, 0, 0, 0, time.UTC)
+	setup := func(m *frontendHandlersMocks) {
+		f1 := fData.OneFrontend()
+		f1.ModifiedAt = since
+		f2 := fData.OtherFrontend()
+		f2.ModifiedAt = since.Add(time.Second)
+		m.Svc.SaveFrontend(f1)
+		m.Svc.SaveFrontend(f2)
+		m.Request, _ = http.NewRequest("GET", "/frontends?fields=name&modifiedSince=2015-06-12T14:00:00Z", nil)
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		GetFrontends(m.ResWriter, m.Request, m.Enc, m.Svc)
+		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "GetFrontends() returned unexpected status code")
+		assert.Equal(t, m.ResWriter.Body.String(), `[{"name":"second_frontend"}]`, "GetFrontends() returned an unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetFrontends_Fields(t *testing.T) {
+	setup := func(m *frontendHandlersMocks) {
+		m.Svc.SaveFrontend(fData.OneFrontend())
+		m.Svc.SaveFrontend(fData.OtherFrontend())
+		m.Request, _ = http.NewRequest("GET", "/frontends?fields=name,unknown&envelope=true", nil)
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		// retrieve and validate data
+		GetFrontends(m.ResWriter, m.Request, m.Enc, m.Svc)
+		expBody := `{"data":[{"name":"first_frontend"},{"name":"second_frontend"}],"total":2,"limit":0,"offset":0}`
+		assert.Equal(t, m.ResWriter.Body.String(), expBody, "GetFrontends() returned an unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
 func Test_GetFrontend(t *testing.T) {
 	f := fData.OneFrontend()
 
@@ -105,7 +234,7 @@ func Test_GetFrontend(t *testing.T) {
 
 	testAction := func(m *frontendHandlersMocks) {
 		// retrieve and validate data
-		GetFrontend(m.ResWriter, m.Enc, m.Svc, m.Params)
+		GetFrontend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
 		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "GetFrontend() returned unexpected status code")
 		assert.Equal(t, m.ResWriter.Body.String(), m.Enc.Encode(f), "GetFrontend() returned unexpected body")
 	}
@@ -117,6 +246,73 @@ func Test_GetFrontend(t *testing.T) {
 	}.execute()
 }
 
+func Test_GetFrontend_Raw(t *testing.T) {
+	f := fData.OneFrontend()
+
+	setup := func(m *frontendHandlersMocks) {
+		m.Svc.SaveFrontend(f)
+		m.Params["name"] = f.Name
+		m.Request, _ = http.NewRequest("GET", "/frontends/"+f.Name+"?raw=true", nil)
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		GetFrontend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "GetFrontend() returned unexpected status code")
+		body := m.ResWriter.Body.String()
+		assert.StringContains(t, body, `"id":"frontend/`+f.Name+`"`, "GetFrontend() did not return the ID")
+		assert.StringContains(t, body, `"proxyType":"frontend"`, "GetFrontend() did not return the ProxyType")
+		assert.StringContains(t, body, `"value":{"name":"`+f.Name+`"`, "GetFrontend() did not return the stored value")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetFrontend_RawDoesNotExist(t *testing.T) {
+	setup := func(m *frontendHandlersMocks) {
+		m.Params["name"] = "12345"
+		m.Request, _ = http.NewRequest("GET", "/frontends/12345?raw=true", nil)
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		GetFrontend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		assert.Equal(t, m.ResWriter.Code, http.StatusNotFound, "GetFrontend() returned unexpected status code")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_GetFrontend_Fields(t *testing.T) {
+	f := fData.OneFrontend()
+
+	setup := func(m *frontendHandlersMocks) {
+		m.Svc.SaveFrontend(f)
+		m.Params["name"] = f.Name
+		m.Request, _ = http.NewRequest("GET", "/frontends/"+f.Name+"?fields=name,bind,unknown", nil)
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		// retrieve and validate data
+		GetFrontend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+		expBody := `{"name":"first_frontend","bind":"*:80"}`
+		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "GetFrontend() returned unexpected status code")
+		assert.Equal(t, m.ResWriter.Body.String(), expBody, "GetFrontend() returned unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
 func Test_GetFrontend_DoesNotExist(t *testing.T) {
 	setup := func(m *frontendHandlersMocks) {
 		m.Params["name"] = "12345"
@@ -124,7 +320,7 @@ func Test_GetFrontend_DoesNotExist(t *testing.T) {
 
 	testAction := func(m *frontendHandlersMocks) {
 		// retrieve and validate data
-		GetFrontend(m.ResWriter, m.Enc, m.Svc, m.Params)
+		GetFrontend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
 		expCode := http.StatusNotFound
 		expBody := fmt.Sprintf(`"code":%d`, expCode)
 		assert.Equal(t, m.ResWriter.Code, expCode, "GetFrontend() returned unexpected status code")
@@ -146,7 +342,7 @@ func Test_GetFrontend_SvcError(t *testing.T) {
 
 	testAction := func(m *frontendHandlersMocks) {
 		// execute function to test, check for panic
-		f := func() { GetFrontend(m.ResWriter, m.Enc, m.Svc, m.Params) }
+		f := func() { GetFrontend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params) }
 		assert.Panic(t, f, "GetFrontends() failed to panic when expected")
 	}
 
@@ -157,6 +353,68 @@ func Test_GetFrontend_SvcError(t *testing.T) {
 	}.execute()
 }
 
+// ----------------------------------------------
+// HeadFrontend TESTS
+// ----------------------------------------------
+
+func Test_HeadFrontend(t *testing.T) {
+	x := fData.OneFrontend()
+
+	setup := func(m *frontendHandlersMocks) {
+		m.Svc.SaveFrontend(x)
+		m.Params["name"] = x.Name
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		HeadFrontend(m.ResWriter, m.Svc, m.Params)
+		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "HeadFrontend() returned unexpected status code")
+		assert.Equal(t, m.ResWriter.Body.Len(), 0, "HeadFrontend() returned an unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_HeadFrontend_DoesNotExist(t *testing.T) {
+	setup := func(m *frontendHandlersMocks) {
+		m.Params["name"] = "12345"
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		HeadFrontend(m.ResWriter, m.Svc, m.Params)
+		assert.Equal(t, m.ResWriter.Code, http.StatusNotFound, "HeadFrontend() returned unexpected status code")
+		assert.Equal(t, m.ResWriter.Body.Len(), 0, "HeadFrontend() returned an unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_HeadFrontend_SvcError(t *testing.T) {
+	setup := func(m *frontendHandlersMocks) {
+		m.Svc.GetError = NewErrorf(ErrUnknown, "")
+		m.Params["name"] = "12345"
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		// execute function to test, check for panic
+		f := func() { HeadFrontend(m.ResWriter, m.Svc, m.Params) }
+		assert.Panic(t, f, "HeadFrontend() failed to panic when expected")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
 // ----------------------------------------------
 // PutFrontend TESTS
 // ----------------------------------------------
@@ -388,6 +646,31 @@ func Test_PostFrontend_WithInvalidJSON(t *testing.T) {
 	}.execute()
 }
 
+func Test_PostFrontend_EmptyBody(t *testing.T) {
+	f := fData.OneFrontend()
+
+	setup := func(m *frontendHandlersMocks) {
+		m.Svc.SaveFrontend(f)
+		m.Params["name"] = f.Name
+		m.Request, _ = http.NewRequest("POST", "/frontends", strings.NewReader("  \n"))
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		// execute function to test
+		PostFrontend(m.ResWriter, m.Request, m.Enc, m.Svc, m.Params)
+
+		// assert return values
+		assert.Equal(t, m.ResWriter.Code, http.StatusBadRequest, "PostFrontend() returned unexpected status code")
+		assert.StringContains(t, m.ResWriter.Body.String(), "the request body is empty", "PostFrontend() returned unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
 func Test_PostFrontend_SvcNotFoundError(t *testing.T) {
 	f := fData.OneFrontend()
 
@@ -511,6 +794,85 @@ func Test_PostFrontend_SvcOutOfSyncError(t *testing.T) {
 	}.execute()
 }
 
+// ----------------------------------------------
+// PostFrontendsValidate TESTS
+// ----------------------------------------------
+
+func Test_PostFrontendsValidate(t *testing.T) {
+	f := fData.OneFrontend()
+
+	setup := func(m *frontendHandlersMocks) {
+		m.Request, _ = http.NewRequest("POST", "/frontends/validate", strings.NewReader(m.Enc.Encode(f)))
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		// execute function to test
+		PostFrontendsValidate(m.ResWriter, m.Request, m.Enc)
+
+		// assert return values
+		assert.Equal(t, m.ResWriter.Code, http.StatusOK, "PostFrontendsValidate() returned unexpected status code")
+		assert.Equal(t, m.ResWriter.Body.String(), `{"errors":[]}`, "PostFrontendsValidate() returned unexpected body")
+		saved, _ := m.Svc.GetFrontend(f.Name)
+		assert.Nil(t, saved, "PostFrontendsValidate() saved the frontend")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostFrontendsValidate_MultipleErrors(t *testing.T) {
+	setup := func(m *frontendHandlersMocks) {
+		m.Request, _ = http.NewRequest("POST", "/frontends/validate", strings.NewReader(`{"bind":"a b:80","maxconn":-1}`))
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		// execute function to test
+		PostFrontendsValidate(m.ResWriter, m.Request, m.Enc)
+
+		// assert return values
+		assert.Equal(t, m.ResWriter.Code, http.StatusBadRequest, "PostFrontendsValidate() returned unexpected status code")
+		res := struct {
+			Errors []FieldError `json:"errors"`
+		}{}
+		m.Enc.Decode(m.ResWriter.Body.Bytes(), &res)
+		fields := []string{}
+		for _, e := range res.Errors {
+			fields = append(fields, e.Field)
+		}
+		assert.Equal(t, fields, []string{"name", "bind", "maxconn"}, "PostFrontendsValidate() returned unexpected field errors")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
+func Test_PostFrontendsValidate_WithInvalidJSON(t *testing.T) {
+	setup := func(m *frontendHandlersMocks) {
+		m.Request, _ = http.NewRequest("POST", "/frontends/validate", strings.NewReader(`{"test:true}`))
+	}
+
+	testAction := func(m *frontendHandlersMocks) {
+		// execute function to test
+		PostFrontendsValidate(m.ResWriter, m.Request, m.Enc)
+
+		// assert return values
+		assert.Equal(t, m.ResWriter.Code, http.StatusBadRequest, "PostFrontendsValidate() returned unexpected status code")
+		assert.StringContains(t, m.ResWriter.Body.String(), "the frontend data is not valid", "PostFrontendsValidate() returned unexpected body")
+	}
+
+	frontendHandlersTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}.execute()
+}
+
 // ----------------------------------------------
 // DeleteFrontend TESTS
 // ----------------------------------------------
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"net/http"
+)
+
+// HandlerFunc is an HTTP handler that returns the status code and body of its response rather than
+// writing them, or an error to respond with instead.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) (int, string, *Error)
+
+// Handle adapts the given HandlerFunc to an http.HandlerFunc that writes its response; a returned
+// error is encoded as an ErrorResponse with the status code of its type, and server errors are
+// logged.
+func Handle(enc Encoder, h HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, body, err := h(w, r)
+		if err != nil {
+			if statusOf(err) >= http.StatusInternalServerError {
+				log.Printf("[ERROR] %s %s failed: %v", r.Method, r.URL.Path, err)
+			}
+			util{}.writeError(w, enc, err)
+			return
+		}
+		util{}.writeResponse(w, status, body)
+	}
+}
+
+// returns the status code of the response to the given error, based on its type; every ErrorType
+// should be listed here, so that a new type doesn't silently become a server error
+func statusOf(err *Error) int {
+	switch err.Type {
+	case ErrBadData:
+		return http.StatusBadRequest
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrConflict, ErrDrift:
+		return http.StatusConflict
+	case ErrSync, ErrOutOfSync, ErrDB, ErrUnknown:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests that the Handle() adapter writes the returned status code and body.
+func Test_Handle(t *testing.T) {
+	rw := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/backends", nil)
+
+	Handle(JSONEncoder{}, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+		return http.StatusCreated, "created!", nil
+	})(rw, r)
+	assert.Equal(t, rw.Code, http.StatusCreated, "Handle() wrote an unexpected status code")
+	assert.Equal(t, rw.Body.String(), "created!", "Handle() wrote an unexpected body")
+}
+
+// Tests that the Handle() adapter writes a returned error with the status code of its type.
+func Test_Handle_Error(t *testing.T) {
+	testCases := map[ErrorType]int{
+		ErrBadData:  http.StatusBadRequest,
+		ErrNotFound: http.StatusNotFound,
+		ErrConflict: http.StatusConflict,
+		ErrDrift:    http.StatusConflict,
+		ErrSync:     http.StatusInternalServerError,
+		ErrDB:       http.StatusInternalServerError,
+		ErrUnknown:  http.StatusInternalServerError,
+	}
+	for errType, expCode := range testCases {
+		rw := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/backends", nil)
+
+		Handle(JSONEncoder{}, func(w http.ResponseWriter, r *http.Request) (int, string, *Error) {
+			return http.StatusOK, "ignored", NewErrorf(errType, "failed!")
+		})(rw, r)
+		expBody := fmt.Sprintf(`{"code":%d,"message":"failed!"}`, expCode)
+		assert.Equal(t, rw.Code, expCode, "Handle() wrote an unexpected status code for %v", errType)
+		assert.Equal(t, rw.Body.String(), expBody, "Handle() wrote an unexpected body for %v", errType)
+	}
+}
+
+// Tests that the statusOf() function maps every ErrorType to its intended status code.
+func Test_statusOf(t *testing.T) {
+	testCases := map[ErrorType]int{
+		ErrConflict:  http.StatusConflict,
+		ErrNotFound:  http.StatusNotFound,
+		ErrBadData:   http.StatusBadRequest,
+		ErrSync:      http.StatusInternalServerError,
+		ErrOutOfSync: http.StatusInternalServerError,
+		ErrDB:        http.StatusInternalServerError,
+		ErrDrift:     http.StatusConflict,
+		ErrUnknown:   http.StatusInternalServerError,
+	}
+	for errType := ErrConflict; errType <= ErrUnknown; errType++ {
+		expCode, ok := testCases[errType]
+		assert.EnsureTrue(t, ok, "Test_statusOf() has no expected status code for %v", errType)
+		assert.Equal(t, statusOf(NewErrorf(errType, "failed!")), expCode, "statusOf() returned an unexpected status code for %v", errType)
+	}
+}
@@ -2,36 +2,74 @@ package main
 
 import (
 	"bytes"
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 )
 
 const (
+	// reloadStrategyCommand reloads HAProxy by executing the configured reload command.
+	reloadStrategyCommand = "command"
+	// reloadStrategySocket reloads HAProxy by issuing a reload over its master/admin socket.
+	reloadStrategySocket = "socket"
+
+	// modeHTTP, modeTCP, and modeHealth are the proxy modes supported by HAProxy.
+	modeHTTP   = "http"
+	modeTCP    = "tcp"
+	modeHealth = "health"
+
+	socketTimeout = 10 * time.Second
+
+	// livenessTimeout is how long to wait for a connection to HAProxy when checking that it's running.
+	livenessTimeout = 2 * time.Second
+
+	// configHeaderPrefix begins the comment line written at the top of the config file when the
+	// config header is enabled.
+	configHeaderPrefix = "# Generated by Thalassa Conduit"
+
+	// configBackupTimeFormat is the sortable UTC timestamp appended to the names of config backups.
+	configBackupTimeFormat = "20060102T150405.000000000Z"
+
 	defaultTemplate = `global
   maxconn 256
 
   defaults
     timeout connect 5000ms
-
+{{range .Sections}}{{if .Mode}}
+  defaults
+    mode {{.Mode}}{{range .Defaults}}
+    {{.}}{{end}}
+{{end}}
 {{range .Frontends}}
   frontend {{.Name}}{{if .Bind}}
     bind {{.Bind}}{{end}}{{if .Mode}}
     mode {{.Mode}}{{end}}{{if .DefaultBackend}}
     default_backend {{.DefaultBackend}}{{end}}{{if .Option}}
-    option {{.Option}}{{end}}
+    option {{.Option}}{{end}}{{if .MaxConn}}
+    maxconn {{.MaxConn}}{{end}}{{range .HTTPRequests}}
+    http-request {{.}}{{end}}{{range $i, $r := .RoutingRules}}
+    acl rule_{{$i}} {{$r.Condition}}
+    use_backend {{$r.Backend}} if rule_{{$i}}{{end}}
 {{end}}
-{{range .Backends}}
+{{range .Backends}}{{$resolvers := .Resolvers}}
   backend {{.Name}}{{if .Mode}}
     mode {{.Mode}}{{end}}{{if .Balance}}
-    balance {{.Balance}}{{end}}{{range .Members}}
-    server {{.Name}} {{.Host}}:{{.Port}} check inter 2000{{end}}
+    balance {{.Balance}}{{end}}{{if .HealthCheck}}
+    option httpchk {{.HealthCheck}}{{end}}{{range .Members}}
+    server {{.Name}} {{.Address}} check inter 2000{{if .Rise}} rise {{.Rise}}{{end}}{{if .Fall}} fall {{.Fall}}{{end}}{{if .Disabled}} disabled{{end}}{{if $resolvers}} resolvers {{$resolvers}}{{end}}{{end}}
 {{end}}
-`
+{{end}}`
 )
 
 // HAProxy represents an HAProxy installation
@@ -41,36 +79,59 @@ type HAProxy interface {
 	GetConfig() (string, error)
 	GetFrontends() (Frontends, error)
 	GetBackends() (Backends, error)
+	RenderConfig(frontends Frontends, backends Backends) (string, error)
 	WriteConfig(frontends Frontends, backends Backends) error
 	ReloadConfig() error
+	ReloadConfigUsing(strategy string) error
+	IsRunning() (bool, error)
+	GetStats() ([]map[string]string, error)
 }
 
 type haProxyImpl struct {
-	configPath string
-	template   *template.Template
-	reloadCmd  string
+	configPath     string
+	templateMu     sync.RWMutex // guards template, which is replaced while configs are rendered
+	template       *template.Template
+	reloadCmd      string
+	reloadArgv     []string
+	reloadStrategy string
+	socketPath     string
+	statsPath      string
+	backupCount    int
+	header         bool
+	modeDefaults   map[string][]string
 }
 
 // NewHAProxy returns a new populated instance of an HAProxy struct.
-func NewHAProxy(configPath string, configTemplate *template.Template, reloadCmd string) HAProxy {
+func NewHAProxy(config *Config, configTemplate *template.Template) HAProxy {
 	if configTemplate == nil {
 		configTemplate, _ = template.New("test").Parse(string(defaultTemplate))
 	}
 	return &haProxyImpl{
-		configPath: configPath,
-		template:   configTemplate,
-		reloadCmd:  reloadCmd,
+		configPath:     config.HAConfigPath,
+		template:       configTemplate,
+		reloadCmd:      config.HAReloadCommand,
+		reloadArgv:     config.HAReloadArgv,
+		reloadStrategy: config.ReloadStrategy,
+		socketPath:     config.HASocketPath,
+		statsPath:      config.HAStatsSocketPath,
+		backupCount:    config.ConfigBackupCount,
+		header:         config.ConfigHeader,
+		modeDefaults:   config.ModeDefaults,
 	}
 }
 
 // Template returns the HAProxy config template.
 func (h *haProxyImpl) Template() *template.Template {
+	h.templateMu.RLock()
+	defer h.templateMu.RUnlock()
 	return h.template
 }
 
 // SetTemplate sets the value of the HAProxy config template.
 func (h *haProxyImpl) SetTemplate(t *template.Template) {
+	h.templateMu.Lock()
 	h.template = t
+	h.templateMu.Unlock()
 }
 
 // GetConfig returns the contents of the HAProxy config file associated with this HAProxy instance.
@@ -82,6 +143,24 @@ func (h *haProxyImpl) GetConfig() (string, error) {
 	return string(c), nil
 }
 
+// ConfigParseError is returned when a line of the HAProxy config file can't be parsed; it carries the
+// line's number and text, so that the problem can be found in a large config.
+type ConfigParseError struct {
+	// the 1-based number of the line that could not be parsed
+	Line int
+
+	// the text of the line, without surrounding whitespace
+	Text string
+
+	// a description of what could not be read from the line
+	Msg string
+}
+
+// Error returns the description of the error, including the number and text of the line.
+func (e *ConfigParseError) Error() string {
+	return fmt.Sprintf("haproxy config file is invalid - %s at line %d: %s", e.Msg, e.Line, e.Text)
+}
+
 // GetFrontends returns the Frontends in the HAProxy config file associated with this HAProxy instance.
 func (h *haProxyImpl) GetFrontends() (Frontends, error) {
 	s, err := h.GetConfig()
@@ -97,6 +176,7 @@ func (h *haProxyImpl) GetFrontends() (Frontends, error) {
 		if strings.HasPrefix(l, "frontend ") && len(l) > 9 {
 			f := &Frontend{}
 			f.Name = l[9:]
+			acls := map[string]string{}
 			index := i + 1
 			subline := lines[index]
 			// loop through lines until an empty line or the end of the file is reached
@@ -109,6 +189,24 @@ func (h *haProxyImpl) GetFrontends() (Frontends, error) {
 					f.DefaultBackend = subline[16:]
 				} else if strings.HasPrefix(subline, "option ") && len(subline) > 7 {
 					f.Option = subline[7:]
+				} else if strings.HasPrefix(subline, "maxconn ") && len(subline) > 8 {
+					maxConn, err := strconv.Atoi(subline[8:])
+					if err != nil {
+						return nil, &ConfigParseError{Line: index + 1, Text: subline, Msg: "could not read maxconn for frontend " + f.Name}
+					}
+					f.MaxConn = maxConn
+				} else if strings.HasPrefix(subline, "acl ") {
+					// the conditions of routing rules are named by their acl lines
+					if parts := strings.SplitN(subline[4:], " ", 2); len(parts) == 2 {
+						acls[parts[0]] = parts[1]
+					}
+				} else if strings.HasPrefix(subline, "http-request ") && len(subline) > 13 {
+					f.HTTPRequests = append(f.HTTPRequests, subline[13:])
+				} else if strings.HasPrefix(subline, "use_backend ") {
+					parts := strings.SplitN(subline[12:], " if ", 2)
+					if cond, ok := acls[parts[len(parts)-1]]; ok && len(parts) == 2 {
+						f.RoutingRules = append(f.RoutingRules, Rule{Condition: cond, Backend: parts[0]})
+					}
 				}
 				index++
 				// if no more lines then it's EOF, so break
@@ -147,27 +245,47 @@ func (h *haProxyImpl) GetBackends() (Backends, error) {
 					b.Balance = subline[8:]
 				} else if strings.HasPrefix(subline, "mode ") && len(subline) > 5 {
 					b.Mode = subline[5:]
+				} else if strings.HasPrefix(subline, "option httpchk ") && len(subline) > 15 {
+					b.HealthCheck = subline[15:]
 				} else if strings.HasPrefix(subline, "server ") && len(subline) > 7 {
 					// each backend member is on a single line - parse data from the line to populate
 					// a BackendMember instance
-					parts := strings.Split(subline[7:], " ")
-					if len(parts) != 5 {
-						return nil, fmt.Errorf("haproxy config file is invalid - could not read members for backend %s", b.Name)
+					parts := strings.Fields(subline[7:])
+					if len(parts) < 2 {
+						return nil, &ConfigParseError{Line: index + 1, Text: subline, Msg: "could not read members for backend " + b.Name}
 					}
-					j := strings.Index(parts[1], ":")
-					if j < 0 {
-						return nil, fmt.Errorf("haproxy config file is invalid - could not read members for backend %s", b.Name)
+					// IPv6 hosts are enclosed in square brackets, e.g. [::1]:8080
+					host, p, err := net.SplitHostPort(parts[1])
+					if err != nil {
+						return nil, &ConfigParseError{Line: index + 1, Text: subline, Msg: "could not read members for backend " + b.Name}
 					}
-					host := parts[1][:j]
-					port, err := strconv.Atoi(parts[1][j+1:])
+					port, err := strconv.Atoi(p)
 					if err != nil {
-						return nil, fmt.Errorf("haproxy config file is invalid - could not read members for backend %s", b.Name)
+						return nil, &ConfigParseError{Line: index + 1, Text: subline, Msg: "could not read members for backend " + b.Name}
+					}
+					// server options follow the address in any order, e.g. "check inter 2000 rise 3 fall 2
+					// disabled resolvers mydns"; options that aren't modeled are skipped
+					member := BackendMember{Name: parts[0], Host: host, Port: port}
+					for k := 2; k < len(parts); k++ {
+						switch {
+						case parts[k] == "disabled":
+							member.Disabled = true
+						case parts[k] == "resolvers" && k+1 < len(parts):
+							b.Resolvers = parts[k+1]
+						case (parts[k] == "rise" || parts[k] == "fall") && k+1 < len(parts):
+							n, err := strconv.Atoi(parts[k+1])
+							if err != nil {
+								return nil, &ConfigParseError{Line: index + 1, Text: subline, Msg: "could not read " + parts[k] + " for backend " + b.Name}
+							}
+							if parts[k] == "rise" {
+								member.Rise = n
+							} else {
+								member.Fall = n
+							}
+							k++
+						}
 					}
-					m = append(m, BackendMember{
-						Name: parts[0],
-						Host: host,
-						Port: port,
-					})
+					m = append(m, member)
 				}
 				index++
 				// if no more lines then it's EOF, so break
@@ -183,39 +301,372 @@ func (h *haProxyImpl) GetBackends() (Backends, error) {
 	return backends, nil
 }
 
+// RenderConfig returns the HAProxy config created from the config template with the given frontends
+// and backends, without writing it to the config file.
+func (h *haProxyImpl) RenderConfig(frontends Frontends, backends Backends) (string, error) {
+	var buffer bytes.Buffer
+	tmpl := h.Template()
+	if err := tmpl.Execute(&buffer, newTemplateData(frontends, backends, h.modeDefaults)); err != nil {
+		if entity := h.failingEntity(tmpl, frontends, backends); entity != "" {
+			return "", fmt.Errorf("error rendering the HAProxy config template for %s: %v", entity, err)
+		}
+		return "", fmt.Errorf("error rendering the HAProxy config template: %v", err)
+	}
+	return buffer.String(), nil
+}
+
+// templateData is the data the config template is executed with.
+type templateData struct {
+	Frontends Frontends
+	Backends  Backends
+
+	// the frontends and backends grouped by the defaults section they follow; the first section has
+	// no mode and holds those of the modes without configured defaults
+	Sections []proxySection
+}
+
+// proxySection is a group of frontends and backends that follow the defaults section of their mode
+// in the config.
+type proxySection struct {
+	Mode      string
+	Defaults  []string
+	Frontends Frontends
+	Backends  Backends
+}
+
+// returns the data to execute the config template with for the given frontends and backends, which
+// are grouped into a section for each mode with defaults, sorted by mode
+func newTemplateData(frontends Frontends, backends Backends, modeDefaults map[string][]string) templateData {
+	data := templateData{Frontends: frontends, Backends: backends}
+	modes := make([]string, 0, len(modeDefaults))
+	for mode := range modeDefaults {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+
+	sections := map[string]*proxySection{"": {}}
+	for _, mode := range modes {
+		sections[mode] = &proxySection{Mode: mode, Defaults: modeDefaults[mode]}
+	}
+	sectionOf := func(mode string) *proxySection {
+		if s, ok := sections[mode]; ok {
+			return s
+		}
+		return sections[""]
+	}
+	for _, f := range frontends {
+		s := sectionOf(f.Mode)
+		s.Frontends = append(s.Frontends, f)
+	}
+	for _, b := range backends {
+		s := sectionOf(b.Mode)
+		s.Backends = append(s.Backends, b)
+	}
+
+	data.Sections = append(data.Sections, *sections[""])
+	for _, mode := range modes {
+		data.Sections = append(data.Sections, *sections[mode])
+	}
+	return data
+}
+
+// renders the given config template with each frontend and backend on its own to find the first one
+// the template can't be executed with, e.g. because the template references a field it doesn't have;
+// an empty string is returned if the template fails regardless of the frontends and backends
+func (h *haProxyImpl) failingEntity(tmpl *template.Template, frontends Frontends, backends Backends) string {
+	render := func(f Frontends, b Backends) error {
+		return tmpl.Execute(ioutil.Discard, newTemplateData(f, b, h.modeDefaults))
+	}
+	if render(Frontends{}, Backends{}) != nil {
+		return ""
+	}
+	for _, f := range frontends {
+		if render(Frontends{f}, Backends{}) != nil {
+			return fmt.Sprintf("frontend '%s'", f.Name)
+		}
+	}
+	for _, b := range backends {
+		if render(Frontends{}, Backends{b}) != nil {
+			return fmt.Sprintf("backend '%s'", b.Name)
+		}
+	}
+	return ""
+}
+
 // WriteConfig replaces the existing HAProxy config file with a new one created from the config template
 // with the given frontends and backends.
 func (h *haProxyImpl) WriteConfig(frontends Frontends, backends Backends) error {
-	data := struct {
-		Frontends Frontends
-		Backends  Backends
-	}{
-		frontends,
-		backends,
+	config, err := h.RenderConfig(frontends, backends)
+	if err != nil {
+		return err
+	}
+	if h.header {
+		config = fmt.Sprintf("%s %s at %s\n", configHeaderPrefix, version, time.Now().UTC().Format(time.RFC3339)) + config
+	}
+	if err := h.backupConfig(); err != nil {
+		return err
 	}
+	if err := ioutil.WriteFile(h.configPath, []byte(config), 0644); err != nil {
+		return err
+	}
+	return nil
+}
 
-	var buffer bytes.Buffer
-	if err := h.template.Execute(&buffer, data); err != nil {
+// returns the given config without the header comment written at the top of the config file, if
+// it has one, so that it can be compared with a rendered config
+func stripConfigHeader(config string) string {
+	if !strings.HasPrefix(config, configHeaderPrefix) {
+		return config
+	}
+	if i := strings.Index(config, "\n"); i >= 0 {
+		return config[i+1:]
+	}
+	return ""
+}
+
+// copies the current config file to a timestamped file in the backups directory alongside it, and
+// removes the oldest backups so that only the configured number are kept; nothing is backed up if
+// backups are disabled or the config file doesn't exist yet
+func (h *haProxyImpl) backupConfig() error {
+	if h.backupCount <= 0 {
+		return nil
+	}
+	current, err := ioutil.ReadFile(h.configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
 		return err
 	}
-	if err := ioutil.WriteFile(h.configPath, []byte(buffer.String()), 0644); err != nil {
+
+	dir := filepath.Join(filepath.Dir(h.configPath), "backups")
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
+	prefix := filepath.Base(h.configPath) + "."
+	name := prefix + time.Now().UTC().Format(configBackupTimeFormat)
+	if err := ioutil.WriteFile(filepath.Join(dir, name), current, 0644); err != nil {
+		return err
+	}
+
+	// the timestamps sort chronologically, so the oldest backups are listed first
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	backups := []string{}
+	for _, f := range files {
+		if !f.IsDir() && strings.HasPrefix(f.Name(), prefix) {
+			backups = append(backups, f.Name())
+		}
+	}
+	for len(backups) > h.backupCount {
+		if err := os.Remove(filepath.Join(dir, backups[0])); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
 	return nil
 }
 
-// ReloadConfig executes a command to tell HAProxy to reload it's config file.
+// ReloadConfig tells HAProxy to reload its config file, either by executing the reload command
+// or by issuing a reload over the master/admin socket, depending on the configured strategy.
 func (h *haProxyImpl) ReloadConfig() error {
-	cmdStr := h.reloadCmd
-	if cmdStr == "" {
-		cmdStr = "service haproxy reload"
+	return h.ReloadConfigUsing("")
+}
+
+// ReloadConfigUsing tells HAProxy to reload its config file using the given reload strategy; an
+// empty strategy uses the configured one.
+func (h *haProxyImpl) ReloadConfigUsing(strategy string) error {
+	if strategy == "" {
+		strategy = h.reloadStrategy
+	}
+	if strategy == reloadStrategySocket {
+		return h.reloadViaSocket()
+	}
+	return h.reloadViaCommand()
+}
+
+// executes the reload command to tell HAProxy to reload its config file; the reload argv, if set,
+// is executed directly rather than by the shell, so that its arguments are passed as-is
+func (h *haProxyImpl) reloadViaCommand() error {
+	var cmd *exec.Cmd
+	if len(h.reloadArgv) > 0 {
+		cmd = exec.Command(h.reloadArgv[0], h.reloadArgv[1:]...)
+	} else {
+		cmdStr := h.reloadCmd
+		if cmdStr == "" {
+			cmdStr = "service haproxy reload"
+		}
+		cmd = exec.Command("/bin/sh", "-c", cmdStr)
 	}
-	cmd := exec.Command("/bin/sh", "-c", cmdStr)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
+// connects to the HAProxy master/admin socket and issues a reload command
+func (h *haProxyImpl) reloadViaSocket() error {
+	conn, err := net.DialTimeout("unix", h.socketPath, socketTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(socketTimeout))
+
+	if _, err := conn.Write([]byte("reload\n")); err != nil {
+		return err
+	}
+
+	// HAProxy closes the connection once the command has been processed
+	if _, err := ioutil.ReadAll(conn); err != nil {
+		return fmt.Errorf("error reading reload response from HAProxy socket: %v", err)
+	}
+	return nil
+}
+
+// IsRunning determines if HAProxy is running by connecting to its master/admin socket, if one is
+// configured, or otherwise to the addresses bound by the frontends in its config file.
+func (h *haProxyImpl) IsRunning() (bool, error) {
+	if h.socketPath != "" {
+		conn, err := net.DialTimeout("unix", h.socketPath, livenessTimeout)
+		if err != nil {
+			return false, nil
+		}
+		conn.Close()
+		return true, nil
+	}
+
+	frontends, err := h.GetFrontends()
+	if err != nil {
+		return false, err
+	}
+	addrs := []string{}
+	for _, f := range frontends {
+		if f.Bind != "" {
+			addrs = append(addrs, strings.Split(f.Bind, ",")...)
+		}
+	}
+	if len(addrs) == 0 {
+		return false, errors.New("there is no HAProxy socket or frontend bind address to check")
+	}
+
+	for _, addr := range addrs {
+		conn, err := net.DialTimeout("tcp", dialAddress(addr), livenessTimeout)
+		if err == nil {
+			conn.Close()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetStats issues a "show stat" command over the HAProxy stats socket, or the master/admin socket if
+// no stats socket is configured, and returns a map of the CSV column names to values for each
+// proxy and server.
+func (h *haProxyImpl) GetStats() ([]map[string]string, error) {
+	sockPath := h.statsPath
+	if sockPath == "" {
+		sockPath = h.socketPath
+	}
+	if sockPath == "" {
+		return nil, errors.New("there is no HAProxy stats socket configured")
+	}
+
+	conn, err := net.DialTimeout("unix", sockPath, socketTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(socketTimeout))
+
+	if _, err := conn.Write([]byte("show stat\n")); err != nil {
+		return nil, err
+	}
+
+	// HAProxy closes the connection once the command has been processed
+	data, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stats response from HAProxy socket: %v", err)
+	}
+	return parseStats(string(data))
+}
+
+// parses the CSV output of the HAProxy "show stat" command, whose header line is prefixed with
+// "# " and whose lines each end with a trailing comma
+func parseStats(data string) ([]map[string]string, error) {
+	data = strings.TrimPrefix(strings.TrimSpace(data), "# ")
+	r := csv.NewReader(strings.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing stats response from HAProxy socket: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("the HAProxy stats response is empty")
+	}
+
+	header := records[0]
+	stats := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			if name == "" || i >= len(record) {
+				continue
+			}
+			row[name] = record[i]
+		}
+		stats = append(stats, row)
+	}
+	return stats, nil
+}
+
+// returns the address to connect to for the given frontend bind address; addresses that listen on
+// all interfaces are connected to on the loopback interface, and port ranges on their first port
+func dialAddress(bind string) string {
+	bind = strings.TrimSpace(bind)
+	host, port := "", bind
+	if i := strings.LastIndex(bind, ":"); i >= 0 {
+		host, port = bind[:i], bind[i+1:]
+	}
+	if host == "" || host == "*" {
+		host = "127.0.0.1"
+	}
+	port = strings.SplitN(port, "-", 2)[0]
+	return net.JoinHostPort(strings.Trim(host, "[]"), port)
+}
+
+// haproxySections are the keywords that begin a section of an HAProxy config file.
+var haproxySections = map[string]bool{
+	"global": true, "defaults": true, "frontend": true, "backend": true, "listen": true,
+	"resolvers": true, "userlist": true, "peers": true, "mailers": true, "program": true,
+	"cache": true, "ring": true, "http-errors": true,
+}
+
+// backendBlock returns the lines of the given HAProxy config that make up the section of the
+// backend with the given name, from its "backend" line up to the next empty line or section, or an
+// empty string if the config contains no such backend.
+func backendBlock(config, name string) string {
+	block := []string{}
+	for _, l := range strings.Split(config, "\n") {
+		fields := strings.Fields(l)
+		if len(block) == 0 {
+			if len(fields) == 2 && fields[0] == "backend" && fields[1] == name {
+				block = append(block, l)
+			}
+			continue
+		}
+		if len(fields) == 0 || haproxySections[fields[0]] {
+			break
+		}
+		block = append(block, l)
+	}
+	if len(block) == 0 {
+		return ""
+	}
+	return strings.Join(block, "\n") + "\n"
+}
+
 // parses the contents of the haproxy config file into a slice of string values, each of
 // which is a line of text in the file that has been trimmed of whitespace
 func (h *haProxyImpl) parseConfigText(s string) []string {
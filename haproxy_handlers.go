@@ -16,6 +16,70 @@ func GetHAProxyConfig(w http.ResponseWriter, enc Encoder, h HAProxy) {
 	util{}.writeResponse(w, http.StatusOK, config)
 }
 
+// DownloadHAProxyConfig returns the current contents of the haproxy.cfg file as an attachment, so
+// that browsers save it as a file rather than displaying it
+func DownloadHAProxyConfig(w http.ResponseWriter, enc Encoder, h HAProxy) {
+	config, err := h.GetConfig()
+	if err != nil {
+		util{}.writeResponse(w, http.StatusInternalServerError,
+			enc.Encode(NewErrorResponse(http.StatusInternalServerError, "error loading haproxy.cfg file")))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Content-Disposition", "attachment; filename=haproxy.cfg")
+	util{}.writeResponse(w, http.StatusOK, config)
+}
+
+// GetHAProxyDiff returns a unified diff between the haproxy.cfg file and the config that would be
+// rendered from the stored frontends and backends; the body is empty if they match
+func GetHAProxyDiff(w http.ResponseWriter, enc Encoder, svc DataSvc, h HAProxy) {
+	live, err := h.GetConfig()
+	if err != nil {
+		util{}.writeResponse(w, http.StatusInternalServerError,
+			enc.Encode(NewErrorResponse(http.StatusInternalServerError, "error loading haproxy.cfg file")))
+		return
+	}
+
+	f, derr := svc.GetAllFrontends()
+	if derr != nil {
+		panic(derr)
+	}
+	b, derr := svc.GetAllBackends()
+	if derr != nil {
+		panic(derr)
+	}
+	rendered, err := h.RenderConfig(f.ToHAProxyFrontends(), b.ToHAProxyBackends())
+	if err != nil {
+		util{}.writeResponse(w, http.StatusInternalServerError,
+			enc.Encode(NewErrorResponse(http.StatusInternalServerError, "error rendering the haproxy config template")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	util{}.writeResponse(w, http.StatusOK, unifiedDiff("haproxy.cfg", "rendered", stripConfigHeader(live), rendered))
+}
+
+// HAProxyHealthResponse represents the serializable result of an HAProxy liveness check.
+type HAProxyHealthResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// GetHAProxyHealth reports whether the HAProxy process is running, returning a 503 if it isn't or
+// if its state can't be determined
+func GetHAProxyHealth(w http.ResponseWriter, enc Encoder, h HAProxy) {
+	running, err := h.IsRunning()
+	switch {
+	case err != nil:
+		util{}.writeResponse(w, http.StatusServiceUnavailable,
+			enc.Encode(&HAProxyHealthResponse{Status: "unknown", Error: err.Error()}))
+	case !running:
+		util{}.writeResponse(w, http.StatusServiceUnavailable, enc.Encode(&HAProxyHealthResponse{Status: "stopped"}))
+	default:
+		util{}.writeResponse(w, http.StatusOK, enc.Encode(&HAProxyHealthResponse{Status: "running"}))
+	}
+}
+
 // ReloadHAProxy reloads the HAProxy service
 func ReloadHAProxy(w http.ResponseWriter, enc Encoder, h HAProxy) {
 	if err := h.ReloadConfig(); err != nil {
@@ -26,3 +90,44 @@ func ReloadHAProxy(w http.ResponseWriter, enc Encoder, h HAProxy) {
 	w.Header().Set("Content-Type", "text/plain")
 	util{}.writeResponse(w, http.StatusOK, "HAProxy successfully reloaded")
 }
+
+// GetReloadQueue returns the status of the HAProxy reload queue
+func GetReloadQueue(w http.ResponseWriter, enc Encoder, q *ReloadQueue) {
+	util{}.writeResponse(w, http.StatusOK, enc.Encode(q.Status()))
+}
+
+// GetHAProxyStats returns the runtime statistics of HAProxy's proxies and servers, read from its
+// stats socket.
+func GetHAProxyStats(enc Encoder, h HAProxy) (int, string, *Error) {
+	stats, err := h.GetStats()
+	if err != nil {
+		return 0, "", NewErrorf(ErrUnknown, "error reading the HAProxy stats: %v", err)
+	}
+	return http.StatusOK, enc.Encode(stats), nil
+}
+
+// GetHAProxyTemplate returns the HAProxy config template stored in the data store.
+func GetHAProxyTemplate(w http.ResponseWriter, svc DataSvc) (int, string, *Error) {
+	text, derr := svc.GetTemplate()
+	if derr != nil {
+		return 0, "", derr
+	}
+	if text == "" {
+		return 0, "", NewErrorf(ErrNotFound, "no haproxy config template is stored")
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	return http.StatusOK, text, nil
+}
+
+// PutHAProxyTemplate stores the HAProxy config template in the data store, where it takes
+// precedence over the template file, and rewrites the HAProxy config with it.
+func PutHAProxyTemplate(r *http.Request, svc DataSvc) (int, string, *Error) {
+	body, derr := util{}.readBody(r)
+	if derr != nil {
+		return 0, "", derr
+	}
+	if derr = svc.SaveTemplate(string(body)); derr != nil {
+		return 0, "", derr
+	}
+	return http.StatusNoContent, "", nil
+}
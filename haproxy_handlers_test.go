@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"text/template"
 )
 
 // ----------------------------------------------
@@ -47,6 +49,193 @@ func Test_GetHAProxyConfig_ErrorReadingConfig(t *testing.T) {
 	assert.StringContains(t, w.Body.String(), expBody, "GetHAProxyConfig() returned unexpected body")
 }
 
+// ----------------------------------------------
+// DownloadHAProxyConfig TESTS
+// ----------------------------------------------
+
+// Tests that the DownloadHAProxyConfig() handler returns the config as an attachment.
+func Test_DownloadHAProxyConfig(t *testing.T) {
+	// setup objects and mocks
+	configStr := "test config"
+	w := httptest.NewRecorder()
+	enc := JSONEncoder{}
+	h := testHelpers.NewHAProxyMock()
+	h.config = configStr
+
+	// execute function to test
+	DownloadHAProxyConfig(w, enc, h)
+
+	// assert return values
+	assert.Equal(t, w.Header().Get("Content-Type"), "text/plain", "DownloadHAProxyConfig() response has unexpected content type")
+	assert.Equal(t, w.Header().Get("Content-Disposition"), "attachment; filename=haproxy.cfg", "DownloadHAProxyConfig() response has unexpected content disposition")
+	assert.Equal(t, w.Code, 200, "DownloadHAProxyConfig() returned unexpected status code")
+	assert.Equal(t, w.Body.String(), configStr, "DownloadHAProxyConfig() returned unexpected body")
+}
+
+// Tests that the DownloadHAProxyConfig() handler returns a 500 error when the config can't be read.
+func Test_DownloadHAProxyConfig_ErrorReadingConfig(t *testing.T) {
+	// setup objects and mocks
+	w := httptest.NewRecorder()
+	enc := JSONEncoder{}
+	h := testHelpers.NewHAProxyMock()
+	h.getConfigAction = func() (string, error) { return "", errors.New("error") }
+
+	// execute function to test
+	DownloadHAProxyConfig(w, enc, h)
+
+	// assert return values
+	expCode := 500
+	expBody := fmt.Sprintf(`"code":%d`, expCode)
+	assert.Equal(t, w.Code, expCode, "DownloadHAProxyConfig() returned unexpected status code")
+	assert.Equal(t, w.Header().Get("Content-Disposition"), "", "DownloadHAProxyConfig() set a content disposition on an error")
+	assert.StringContains(t, w.Body.String(), expBody, "DownloadHAProxyConfig() returned unexpected body")
+}
+
+// ----------------------------------------------
+// GetHAProxyDiff TESTS
+// ----------------------------------------------
+
+// Tests that the GetHAProxyDiff() handler returns the differences between the live and rendered
+// config.
+func Test_GetHAProxyDiff(t *testing.T) {
+	// setup objects and mocks
+	w := httptest.NewRecorder()
+	enc := JSONEncoder{}
+	svc := testHelpers.NewDataSvcMock()
+	svc.SaveBackend(&Backend{Name: "new_backend"})
+	h := testHelpers.NewHAProxyMock()
+	h.template = template.Must(template.New("test").Parse("global\n  daemon\n{{range .Backends}}backend {{.Name}}\n{{end}}"))
+	h.config = "global\n  daemon\nbackend old_backend\n"
+
+	// execute function to test
+	GetHAProxyDiff(w, enc, svc, h)
+
+	// assert return values
+	expBody := `--- haproxy.cfg
++++ rendered
+@@ -1,3 +1,3 @@
+ global
+   daemon
+-backend old_backend
++backend new_backend
+`
+	assert.Equal(t, w.Header().Get("Content-Type"), "text/plain", "GetHAProxyDiff() response has unexpected content type")
+	assert.Equal(t, w.Code, 200, "GetHAProxyDiff() returned unexpected status code")
+	assert.Equal(t, w.Body.String(), expBody, "GetHAProxyDiff() returned unexpected body")
+}
+
+// Tests that the GetHAProxyDiff() handler returns an empty body when the live config is current.
+func Test_GetHAProxyDiff_NoChanges(t *testing.T) {
+	// setup objects and mocks
+	w := httptest.NewRecorder()
+	enc := JSONEncoder{}
+	svc := testHelpers.NewDataSvcMock()
+	svc.SaveBackend(&Backend{Name: "new_backend"})
+	h := testHelpers.NewHAProxyMock()
+	h.template = template.Must(template.New("test").Parse("{{range .Backends}}backend {{.Name}}\n{{end}}"))
+	h.config = "backend new_backend\n"
+
+	// execute function to test
+	GetHAProxyDiff(w, enc, svc, h)
+
+	// assert return values
+	assert.Equal(t, w.Code, 200, "GetHAProxyDiff() returned unexpected status code")
+	assert.Equal(t, w.Body.String(), "", "GetHAProxyDiff() returned unexpected body")
+}
+
+// Tests that the GetHAProxyDiff() handler ignores the header comment of the config file.
+func Test_GetHAProxyDiff_Header(t *testing.T) {
+	w := httptest.NewRecorder()
+	enc := JSONEncoder{}
+	svc := testHelpers.NewDataSvcMock()
+	svc.SaveBackend(&Backend{Name: "new_backend"})
+	h := testHelpers.NewHAProxyMock()
+	h.template = template.Must(template.New("test").Parse("{{range .Backends}}backend {{.Name}}\n{{end}}"))
+	h.config = configHeaderPrefix + " dev at 2015-06-12T14:25:01Z\nbackend new_backend\n"
+
+	GetHAProxyDiff(w, enc, svc, h)
+
+	assert.Equal(t, w.Code, 200, "GetHAProxyDiff() returned unexpected status code")
+	assert.Equal(t, w.Body.String(), "", "GetHAProxyDiff() returned unexpected body")
+}
+
+func Test_GetHAProxyDiff_ErrorReadingConfig(t *testing.T) {
+	// setup objects and mocks
+	w := httptest.NewRecorder()
+	enc := JSONEncoder{}
+	h := testHelpers.NewHAProxyMock()
+	h.getConfigAction = func() (string, error) { return "", errors.New("error") }
+
+	// execute function to test
+	GetHAProxyDiff(w, enc, testHelpers.NewDataSvcMock(), h)
+
+	// assert return values
+	expCode := 500
+	expBody := fmt.Sprintf(`"code":%d`, expCode)
+	assert.Equal(t, w.Code, expCode, "GetHAProxyDiff() returned unexpected status code")
+	assert.StringContains(t, w.Body.String(), expBody, "GetHAProxyDiff() returned unexpected body")
+}
+
+// ----------------------------------------------
+// GetHAProxyHealth TESTS
+// ----------------------------------------------
+
+// Tests the "happy path" for the GetHAProxyHealth() handler.
+func Test_GetHAProxyHealth(t *testing.T) {
+	w := httptest.NewRecorder()
+	GetHAProxyHealth(w, JSONEncoder{}, testHelpers.NewHAProxyMock())
+
+	assert.Equal(t, w.Code, 200, "GetHAProxyHealth() returned unexpected status code")
+	assert.Equal(t, w.Body.String(), `{"status":"running"}`, "GetHAProxyHealth() returned unexpected body")
+}
+
+// Tests that the GetHAProxyHealth() handler reports an HAProxy that isn't running.
+func Test_GetHAProxyHealth_NotRunning(t *testing.T) {
+	w := httptest.NewRecorder()
+	h := testHelpers.NewHAProxyMock()
+	h.isRunningAction = func() (bool, error) { return false, nil }
+	GetHAProxyHealth(w, JSONEncoder{}, h)
+
+	assert.Equal(t, w.Code, 503, "GetHAProxyHealth() returned unexpected status code")
+	assert.Equal(t, w.Body.String(), `{"status":"stopped"}`, "GetHAProxyHealth() returned unexpected body")
+}
+
+// Tests that the GetHAProxyHealth() handler reports a failed liveness check.
+func Test_GetHAProxyHealth_Error(t *testing.T) {
+	w := httptest.NewRecorder()
+	h := testHelpers.NewHAProxyMock()
+	h.isRunningAction = func() (bool, error) { return false, errors.New("no bind address") }
+	GetHAProxyHealth(w, JSONEncoder{}, h)
+
+	assert.Equal(t, w.Code, 503, "GetHAProxyHealth() returned unexpected status code")
+	assert.Equal(t, w.Body.String(), `{"status":"unknown","error":"no bind address"}`, "GetHAProxyHealth() returned unexpected body")
+}
+
+// ----------------------------------------------
+// GetHAProxyStats TESTS
+// ----------------------------------------------
+
+// Tests the "happy path" for the GetHAProxyStats() handler.
+func Test_GetHAProxyStats(t *testing.T) {
+	h := testHelpers.NewHAProxyMock()
+	h.getStatsAction = func() ([]map[string]string, error) {
+		return []map[string]string{{"pxname": "www", "svname": "FRONTEND"}}, nil
+	}
+	status, body, derr := GetHAProxyStats(JSONEncoder{}, h)
+	assert.EnsureNil(t, derr, "GetHAProxyStats() returned an unexpected error: %v", derr)
+	assert.Equal(t, status, 200, "GetHAProxyStats() returned unexpected status code")
+	assert.Equal(t, body, `[{"pxname":"www","svname":"FRONTEND"}]`, "GetHAProxyStats() returned unexpected body")
+}
+
+// Tests that the GetHAProxyStats() handler returns an error if the stats can't be read.
+func Test_GetHAProxyStats_Error(t *testing.T) {
+	h := testHelpers.NewHAProxyMock()
+	h.getStatsAction = func() ([]map[string]string, error) { return nil, errors.New("no socket") }
+	_, _, derr := GetHAProxyStats(JSONEncoder{}, h)
+	assert.EnsureNotNil(t, derr, "GetHAProxyStats() failed to return an error when expected")
+	assert.Equal(t, derr.Type, ErrUnknown, "GetHAProxyStats() returned an unexpected error type")
+}
+
 // ----------------------------------------------
 // ReloadHAProxy TESTS
 // ----------------------------------------------
@@ -67,3 +256,98 @@ func Test_ReloadHAProxy(t *testing.T) {
 	assert.Equal(t, w.Code, 200, "RestartHAProxy() returned unexpected status code")
 	assert.StringContains(t, w.Body.String(), "success", "RestartHAProxy() returned unexpected body")
 }
+
+// ----------------------------------------------
+// GetReloadQueue TESTS
+// ----------------------------------------------
+
+// Tests that the GetReloadQueue() handler reflects the queue depth and completed reloads.
+func Test_GetReloadQueue(t *testing.T) {
+	enc := JSONEncoder{}
+	q, started, release := blockedReloadQueue(10, nil)
+	defer q.Close()
+
+	// queue up a running reload and two pending reloads
+	results := make(chan error, 3)
+	go func() { results <- q.ReloadConfig() }()
+	<-started
+	go func() { results <- q.ReloadConfig() }()
+	go func() { results <- q.ReloadConfig() }()
+	waitForDepth(t, q, 2)
+
+	w := httptest.NewRecorder()
+	GetReloadQueue(w, enc, q)
+	status := &ReloadQueueStatus{}
+	enc.Decode(w.Body.Bytes(), status)
+	assert.Equal(t, w.Code, 200, "GetReloadQueue() returned unexpected status code")
+	assert.Equal(t, status.Depth, 2, "GetReloadQueue() returned unexpected depth")
+	assert.NotNil(t, status.Running, "GetReloadQueue() did not return the running reload")
+
+	// complete the reloads
+	release()
+	for i := 0; i < 3; i++ {
+		<-results
+	}
+
+	w = httptest.NewRecorder()
+	GetReloadQueue(w, enc, q)
+	expBody := `{"depth":0,"running":null,"completed":3,"failed":0,"byChange":{}}`
+	assert.Equal(t, w.Code, 200, "GetReloadQueue() returned unexpected status code")
+	assert.Equal(t, w.Body.String(), expBody, "GetReloadQueue() returned unexpected body")
+}
+
+// ----------------------------------------------
+// GetHAProxyTemplate TESTS
+// ----------------------------------------------
+
+// Tests the "happy path" for the GetHAProxyTemplate() handler.
+func Test_GetHAProxyTemplate(t *testing.T) {
+	w := httptest.NewRecorder()
+	svc := testHelpers.NewDataSvcMock()
+	svc.Template = testTemplate
+
+	code, body, derr := GetHAProxyTemplate(w, svc)
+	assert.EnsureNil(t, derr, "GetHAProxyTemplate() returned an unexpected error: %v", derr)
+	assert.Equal(t, w.Header().Get("Content-Type"), "text/plain", "GetHAProxyTemplate() response has unexpected content type")
+	assert.Equal(t, code, 200, "GetHAProxyTemplate() returned unexpected status code")
+	assert.Equal(t, body, testTemplate, "GetHAProxyTemplate() returned unexpected body")
+}
+
+// Tests that the GetHAProxyTemplate() handler returns an ErrNotFound error if no template is stored.
+func Test_GetHAProxyTemplate_NotStored(t *testing.T) {
+	w := httptest.NewRecorder()
+	svc := testHelpers.NewDataSvcMock()
+
+	_, _, derr := GetHAProxyTemplate(w, svc)
+	assert.EnsureNotNil(t, derr, "GetHAProxyTemplate() failed to return an expected error")
+	assert.Equal(t, derr.Type, ErrNotFound, "GetHAProxyTemplate() returned an unexpected error type")
+}
+
+// ----------------------------------------------
+// PutHAProxyTemplate TESTS
+// ----------------------------------------------
+
+// Tests the "happy path" for the PutHAProxyTemplate() handler.
+func Test_PutHAProxyTemplate(t *testing.T) {
+	r := httptest.NewRequest("PUT", "/haproxy/template", strings.NewReader(testTemplate))
+	svc := testHelpers.NewDataSvcMock()
+
+	code, _, derr := PutHAProxyTemplate(r, svc)
+	assert.EnsureNil(t, derr, "PutHAProxyTemplate() returned an unexpected error: %v", derr)
+	assert.Equal(t, code, 204, "PutHAProxyTemplate() returned unexpected status code")
+	assert.Equal(t, svc.Template, testTemplate, "PutHAProxyTemplate() failed to store the template")
+}
+
+// Tests that the PutHAProxyTemplate() handler returns an ErrBadData error for an empty or invalid
+// template.
+func Test_PutHAProxyTemplate_Invalid(t *testing.T) {
+	for _, text := range []string{"", "  \n", "{{range .Backends}"} {
+		r := httptest.NewRequest("PUT", "/haproxy/template", strings.NewReader(text))
+		svc := testHelpers.NewDataSvcMock()
+
+		_, _, derr := PutHAProxyTemplate(r, svc)
+		assert.EnsureNotNil(t, derr, "PutHAProxyTemplate() failed to return an expected error for %q", text)
+		assert.Equal(t, derr.Type, ErrBadData, "PutHAProxyTemplate() returned an unexpected error type for %q", text)
+		assert.Equal(t, svc.Template, "", "PutHAProxyTemplate() stored an invalid template")
+	}
+}
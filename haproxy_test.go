@@ -1,10 +1,17 @@
 package main
 
 import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"text/template"
+	"time"
 )
 
 const (
@@ -20,13 +27,18 @@ const (
     bind {{.Bind}}{{end}}{{if .Mode}}
     mode {{.Mode}}{{end}}{{if .DefaultBackend}}
     default_backend {{.DefaultBackend}}{{end}}{{if .Option}}
-    option {{.Option}}{{end}}
+    option {{.Option}}{{end}}{{if .MaxConn}}
+    maxconn {{.MaxConn}}{{end}}{{range .HTTPRequests}}
+    http-request {{.}}{{end}}{{range $i, $r := .RoutingRules}}
+    acl rule_{{$i}} {{$r.Condition}}
+    use_backend {{$r.Backend}} if rule_{{$i}}{{end}}
 {{end}}
-{{range .Backends}}
+{{range .Backends}}{{$resolvers := .Resolvers}}
   backend {{.Name}}{{if .Mode}}
     mode {{.Mode}}{{end}}{{if .Balance}}
-    balance {{.Balance}}{{end}}{{range .Members}}
-    server {{.Name}} {{.Host}}:{{.Port}} check inter 2000{{end}}
+    balance {{.Balance}}{{end}}{{if .HealthCheck}}
+    option httpchk {{.HealthCheck}}{{end}}{{range .Members}}
+    server {{.Name}} {{.Address}} check inter 2000{{if .Rise}} rise {{.Rise}}{{end}}{{if .Fall}} fall {{.Fall}}{{end}}{{if .Disabled}} disabled{{end}}{{if $resolvers}} resolvers {{$resolvers}}{{end}}{{end}}
 {{end}}
 `
 )
@@ -38,14 +50,14 @@ const (
 // Tests the "happy path" for the NewHAProxy() function.
 func Test_NewHAProxy(t *testing.T) {
 	tmpl, _ := template.New("test").Parse(testTemplate)
-	h := NewHAProxy(testConfigPath, tmpl, "")
+	h := NewHAProxy(&Config{HAConfigPath: testConfigPath}, tmpl)
 	assert.EnsureNotNil(t, h, "NewHAProxy() returned a nil value")
 	assert.Equal(t, reflect.TypeOf(h), reflect.TypeOf(&haProxyImpl{}), "NewHAProxy() returned an unexpected object type")
 }
 
 // Tests that the NewHAProxy() function assigns a default template if none is passed it.
 func Test_NewHAProxy_NilTemplate(t *testing.T) {
-	h := NewHAProxy(testConfigPath, nil, "")
+	h := NewHAProxy(&Config{HAConfigPath: testConfigPath}, nil)
 	assert.EnsureNotNil(t, h, "NewHAProxy() returned a nil value")
 	assert.Equal(t, reflect.TypeOf(h), reflect.TypeOf(&haProxyImpl{}), "NewHAProxy() returned an unexpected object type")
 
@@ -76,6 +88,27 @@ func Test_haProxyImpl_SetTemplate(t *testing.T) {
 	assert.Equal(t, h.template, tmpl, "haProxyImpl.SetTemplate() did not assign the template to the expected value")
 }
 
+// Tests that the template can be replaced while configs are being rendered; run with -race to detect
+// unguarded access to the template.
+func Test_haProxyImpl_SetTemplate_ConcurrentRender(t *testing.T) {
+	h := &haProxyImpl{template: template.Must(template.New("test").Parse("first"))}
+	second := template.Must(template.New("test").Parse("second"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			h.SetTemplate(second)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		c, err := h.RenderConfig(Frontends{}, Backends{})
+		assert.EnsureNil(t, err, "haProxyImpl.RenderConfig() returned an unexpected error: %v", err)
+		assert.True(t, c == "first" || c == "second", "haProxyImpl.RenderConfig() rendered an unexpected config: %s", c)
+	}
+	<-done
+}
+
 // ----------------------------------------------
 // haProxyImpl.GetConfig TESTS
 // ----------------------------------------------
@@ -117,6 +150,18 @@ func Test_haProxyImpl_GetFrontends(t *testing.T) {
 	assert.Equal(t, f[0], expFrontend, "haProxyImpl.GetFrontends() returned unexpected object")
 }
 
+// Tests that haProxyImpl.GetFrontends() returns a ConfigParseError identifying a malformed maxconn line.
+func Test_haProxyImpl_GetFrontends_Malformed(t *testing.T) {
+	h := &haProxyImpl{configPath: "test-fixtures/haproxy-malformed.cfg"}
+	f, err := h.GetFrontends()
+	assert.Nil(t, f, "haProxyImpl.GetFrontends() returned frontends from a malformed config")
+
+	perr, ok := err.(*ConfigParseError)
+	assert.EnsureTrue(t, ok, "haProxyImpl.GetFrontends() returned an unexpected error: %v", err)
+	assert.Equal(t, perr.Line, 11, "haProxyImpl.GetFrontends() returned an error with an unexpected line number")
+	assert.Equal(t, perr.Text, "maxconn lots", "haProxyImpl.GetFrontends() returned an error with unexpected text")
+}
+
 // ----------------------------------------------
 // haProxyImpl.GetBackends TESTS
 // ----------------------------------------------
@@ -151,6 +196,20 @@ func Test_haProxyImpl_GetBackends(t *testing.T) {
 	assert.Equal(t, b[1], expBackend, "haProxyImpl.GetBackends() returned unexpected object")
 }
 
+// Tests that haProxyImpl.GetBackends() returns a ConfigParseError identifying a malformed server line.
+func Test_haProxyImpl_GetBackends_Malformed(t *testing.T) {
+	h := &haProxyImpl{configPath: "test-fixtures/haproxy-malformed.cfg"}
+	b, err := h.GetBackends()
+	assert.Nil(t, b, "haProxyImpl.GetBackends() returned backends from a malformed config")
+
+	perr, ok := err.(*ConfigParseError)
+	assert.EnsureTrue(t, ok, "haProxyImpl.GetBackends() returned an unexpected error: %v", err)
+	assert.Equal(t, perr.Line, 19, "haProxyImpl.GetBackends() returned an error with an unexpected line number")
+	assert.Equal(t, perr.Text, "server app1_node2 10.1.1.20 check inter 2000", "haProxyImpl.GetBackends() returned an error with unexpected text")
+	assert.Equal(t, err.Error(), "haproxy config file is invalid - could not read members for backend app-1 at line 19: server app1_node2 10.1.1.20 check inter 2000",
+		"haProxyImpl.GetBackends() returned an unexpected error message")
+}
+
 // ----------------------------------------------
 // haProxyImpl.WriteConfig TESTS
 // ----------------------------------------------
@@ -198,3 +257,730 @@ func Test_haProxyImpl_WriteConfig(t *testing.T) {
 	assert.Equal(t, f[0], frontends[0], "haProxyImpl.GetFrontends() returned unexpected object")
 	assert.Equal(t, b[0], backends[0], "haProxyImpl.GetBackends() returned unexpected object")
 }
+
+// Tests that a frontend maxconn is rendered when set and parsed back unchanged, and omitted when not.
+func Test_haProxyImpl_WriteConfig_MaxConn(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	h := &haProxyImpl{
+		configPath: testFile,
+		template:   tmpl,
+	}
+	frontends := Frontends{
+		&Frontend{Name: "test-app", Bind: "*:80", Mode: "http", MaxConn: 2000},
+		&Frontend{Name: "test-app-2", Bind: "*:81", Mode: "http"},
+	}
+	err := h.WriteConfig(frontends, Backends{})
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() returned an unexpected error: %v", err)
+
+	config, _ := h.GetConfig()
+	assert.True(t, strings.Contains(config, "mode http\n    maxconn 2000\n"), "haProxyImpl.WriteConfig() did not render the maxconn:\n%s", config)
+	assert.Equal(t, strings.Count(config, "    maxconn "), 1, "haProxyImpl.WriteConfig() rendered an unexpected maxconn:\n%s", config)
+
+	f, err := h.GetFrontends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetFrontends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(f), 2, "haProxyImpl.GetFrontends() returned unexptected number of objects")
+	assert.Equal(t, f[0], frontends[0], "haProxyImpl.GetFrontends() returned unexpected object")
+	assert.Equal(t, f[1], frontends[1], "haProxyImpl.GetFrontends() returned unexpected object")
+}
+
+// Tests that frontend routing rules are rendered as acl and use_backend pairs and parsed back
+// unchanged.
+func Test_haProxyImpl_WriteConfig_RoutingRules(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	h := &haProxyImpl{
+		configPath: testFile,
+		template:   tmpl,
+	}
+	frontends := Frontends{
+		&Frontend{Name: "test-app", Bind: "*:80", Mode: "http", RoutingRules: []Rule{
+			{Condition: "path_beg /api", Backend: "api"},
+			{Condition: "hdr_dom(host) -i example.com", Backend: "web"},
+		}},
+	}
+	err := h.WriteConfig(frontends, Backends{})
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() returned an unexpected error: %v", err)
+
+	config, _ := h.GetConfig()
+	expRules := "    acl rule_0 path_beg /api\n    use_backend api if rule_0\n" +
+		"    acl rule_1 hdr_dom(host) -i example.com\n    use_backend web if rule_1\n"
+	assert.StringContains(t, config, expRules, "haProxyImpl.WriteConfig() did not render the routing rules")
+
+	f, err := h.GetFrontends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetFrontends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(f), 1, "haProxyImpl.GetFrontends() returned unexptected number of objects")
+	assert.Equal(t, f[0], frontends[0], "haProxyImpl.GetFrontends() returned unexpected object")
+}
+
+// Tests that the haProxyImpl.WriteConfig() function renders the http-request rules of a frontend in
+// order, and that haProxyImpl.GetFrontends() reads them back in the same order.
+func Test_haProxyImpl_WriteConfig_HTTPRequests(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	h := &haProxyImpl{
+		configPath: testFile,
+		template:   tmpl,
+	}
+	frontends := Frontends{
+		&Frontend{Name: "test-app", Bind: "*:80", Mode: "http", HTTPRequests: []string{
+			"redirect scheme https unless { ssl_fc }",
+			"set-header X-Forwarded-Proto https",
+			"del-header X-Debug",
+			"add-header X-Order 1",
+		}},
+	}
+	err := h.WriteConfig(frontends, Backends{})
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() returned an unexpected error: %v", err)
+
+	config, _ := h.GetConfig()
+	expRules := "    http-request redirect scheme https unless { ssl_fc }\n" +
+		"    http-request set-header X-Forwarded-Proto https\n" +
+		"    http-request del-header X-Debug\n" +
+		"    http-request add-header X-Order 1\n"
+	assert.StringContains(t, config, expRules, "haProxyImpl.WriteConfig() did not render the http-request rules in order")
+
+	f, err := h.GetFrontends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetFrontends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(f), 1, "haProxyImpl.GetFrontends() returned unexptected number of objects")
+	assert.Equal(t, f[0], frontends[0], "haProxyImpl.GetFrontends() returned unexpected object")
+}
+
+// Tests that the haProxyImpl.WriteConfig() function renders backend members sorted by name.
+func Test_haProxyImpl_WriteConfig_SortedMembers(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	h := &haProxyImpl{
+		configPath: testFile,
+		template:   tmpl,
+	}
+	backends := Backends{
+		&Backend{
+			Name: "test-app-1",
+			Mode: "http",
+			Members: BackendMembers{
+				BackendMember{Name: "node_c", Host: "10.2.2.30", Port: 8080},
+				BackendMember{Name: "node_a", Host: "10.2.2.10", Port: 8080},
+				BackendMember{Name: "node_b", Host: "10.2.2.20", Port: 8080},
+			},
+		},
+	}
+	err := h.WriteConfig(Frontends{}, backends.ToHAProxyBackends())
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() returned an unexpected error: %v", err)
+
+	config, _ := h.GetConfig()
+	a := strings.Index(config, "server node_a")
+	b := strings.Index(config, "server node_b")
+	c := strings.Index(config, "server node_c")
+	assert.True(t, a >= 0 && a < b && b < c, "haProxyImpl.WriteConfig() did not render members sorted by name:\n%s", config)
+
+	// the original backend members must not be reordered
+	assert.Equal(t, backends[0].Members[0].Name, "node_c", "Backends.ToHAProxyBackends() reordered the original members")
+}
+
+// Tests that IPv6 member hosts are bracketed when written and parsed back unchanged.
+func Test_haProxyImpl_WriteConfig_IPv6Members(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	h := &haProxyImpl{
+		configPath: testFile,
+		template:   tmpl,
+	}
+	backends := Backends{
+		&Backend{
+			Name: "test-app-1",
+			Mode: "http",
+			Members: BackendMembers{
+				BackendMember{Name: "node_a", Host: "::1", Port: 8080},
+				BackendMember{Name: "node_b", Host: "2001:db8::10", Port: 8081},
+				BackendMember{Name: "node_c", Host: "10.2.2.30", Port: 8082},
+			},
+		},
+	}
+	err := h.WriteConfig(Frontends{}, backends)
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() returned an unexpected error: %v", err)
+
+	config, _ := h.GetConfig()
+	assert.True(t, strings.Contains(config, "server node_a [::1]:8080 "), "haProxyImpl.WriteConfig() did not bracket the IPv6 host:\n%s", config)
+	assert.True(t, strings.Contains(config, "server node_b [2001:db8::10]:8081 "), "haProxyImpl.WriteConfig() did not bracket the IPv6 host:\n%s", config)
+	assert.True(t, strings.Contains(config, "server node_c 10.2.2.30:8082 "), "haProxyImpl.WriteConfig() rendered an unexpected IPv4 address:\n%s", config)
+
+	b, err := h.GetBackends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetBackends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(b), 1, "haProxyImpl.GetBackends() returned unexptected number of objects")
+	assert.Equal(t, b[0], backends[0], "haProxyImpl.GetBackends() returned unexpected object")
+}
+
+// Tests that member rise and fall thresholds are rendered on the server lines of the members that
+// have them, and that members with and without them are parsed back unchanged.
+func Test_haProxyImpl_WriteConfig_RiseFall(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	h := &haProxyImpl{
+		configPath: testFile,
+		template:   tmpl,
+	}
+	backends := Backends{
+		&Backend{
+			Name: "test-app-1",
+			Mode: "http",
+			Members: BackendMembers{
+				BackendMember{Name: "node_a", Host: "10.2.2.10", Port: 8080, Rise: 3, Fall: 2},
+				BackendMember{Name: "node_b", Host: "10.2.2.20", Port: 8080, Fall: 5, Disabled: true},
+				BackendMember{Name: "node_c", Host: "10.2.2.30", Port: 8080},
+			},
+		},
+	}
+	err := h.WriteConfig(Frontends{}, backends)
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() returned an unexpected error: %v", err)
+
+	config, _ := h.GetConfig()
+	assert.StringContains(t, config, "server node_a 10.2.2.10:8080 check inter 2000 rise 3 fall 2\n", "haProxyImpl.WriteConfig() did not render the rise and fall")
+	assert.StringContains(t, config, "server node_b 10.2.2.20:8080 check inter 2000 fall 5 disabled\n", "haProxyImpl.WriteConfig() did not render the fall")
+	assert.StringContains(t, config, "server node_c 10.2.2.30:8080 check inter 2000\n", "haProxyImpl.WriteConfig() rendered unexpected server options")
+
+	b, err := h.GetBackends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetBackends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(b), 1, "haProxyImpl.GetBackends() returned unexptected number of objects")
+	assert.Equal(t, b[0], backends[0], "haProxyImpl.GetBackends() returned unexpected object")
+}
+
+// Tests that haProxyImpl.GetBackends() reads rise and fall from server lines with other check
+// parameters in any order, and rejects a threshold that isn't a number.
+func Test_haProxyImpl_GetBackends_RiseFall(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+	h := &haProxyImpl{configPath: testFile}
+
+	config := "backend app\n" +
+		"    server node_a 10.2.2.10:8080 check fall 4 inter 1000  weight 10 rise 2\n" +
+		"    server node_b 10.2.2.20:8080 check port 9000\n"
+	ioutil.WriteFile(testFile, []byte(config), 0644)
+	b, err := h.GetBackends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetBackends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(b), 1, "haProxyImpl.GetBackends() returned unexptected number of objects")
+	assert.Equal(t, b[0].Members, BackendMembers{
+		BackendMember{Name: "node_a", Host: "10.2.2.10", Port: 8080, Rise: 2, Fall: 4},
+		BackendMember{Name: "node_b", Host: "10.2.2.20", Port: 8080},
+	}, "haProxyImpl.GetBackends() returned unexpected members")
+
+	ioutil.WriteFile(testFile, []byte("backend app\n    server node_a 10.2.2.10:8080 check rise often\n"), 0644)
+	_, err = h.GetBackends()
+	perr, ok := err.(*ConfigParseError)
+	assert.EnsureTrue(t, ok, "haProxyImpl.GetBackends() returned an unexpected error: %v", err)
+	assert.Equal(t, perr.Line, 2, "haProxyImpl.GetBackends() returned an error with an unexpected line number")
+}
+
+// Tests that haProxyImpl.GetBackends() reads server lines with few or no check parameters, and
+// rejects a server line without an address.
+func Test_haProxyImpl_GetBackends_ShortServerLines(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+	h := &haProxyImpl{configPath: testFile}
+
+	config := "backend app\n" +
+		"    server node_a 10.2.2.10:8080\n" +
+		"    server node_b 10.2.2.20:8080 check\n"
+	ioutil.WriteFile(testFile, []byte(config), 0644)
+	b, err := h.GetBackends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetBackends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(b), 1, "haProxyImpl.GetBackends() returned unexptected number of objects")
+	assert.Equal(t, b[0].Members, BackendMembers{
+		BackendMember{Name: "node_a", Host: "10.2.2.10", Port: 8080},
+		BackendMember{Name: "node_b", Host: "10.2.2.20", Port: 8080},
+	}, "haProxyImpl.GetBackends() returned unexpected members")
+
+	ioutil.WriteFile(testFile, []byte("backend app\n    server node_a\n"), 0644)
+	_, err = h.GetBackends()
+	perr, ok := err.(*ConfigParseError)
+	assert.EnsureTrue(t, ok, "haProxyImpl.GetBackends() returned an unexpected error: %v", err)
+	assert.Equal(t, perr.Line, 2, "haProxyImpl.GetBackends() returned an error with an unexpected line number")
+}
+
+// Tests that backend resolvers and disabled members are rendered on each server line and parsed
+// back unchanged.
+func Test_haProxyImpl_WriteConfig_Resolvers(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	h := &haProxyImpl{
+		configPath: testFile,
+		template:   tmpl,
+	}
+	backends := Backends{
+		&Backend{
+			Name:      "test-app-1",
+			Mode:      "http",
+			Resolvers: "mydns",
+			Members: BackendMembers{
+				BackendMember{Name: "node_a", Host: "app-a.service.consul", Port: 8080},
+				BackendMember{Name: "node_b", Host: "app-b.service.consul", Port: 8080},
+			},
+		},
+		&Backend{
+			Name: "test-app-2",
+			Mode: "http",
+			Members: BackendMembers{
+				BackendMember{Name: "node_c", Host: "10.2.2.30", Port: 8080, Disabled: true},
+			},
+		},
+	}
+	err := h.WriteConfig(Frontends{}, backends)
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() returned an unexpected error: %v", err)
+
+	config, _ := h.GetConfig()
+	assert.True(t, strings.Contains(config, "server node_a app-a.service.consul:8080 check inter 2000 resolvers mydns\n"), "haProxyImpl.WriteConfig() did not render the resolvers:\n%s", config)
+	assert.True(t, strings.Contains(config, "server node_c 10.2.2.30:8080 check inter 2000 disabled\n"), "haProxyImpl.WriteConfig() rendered unexpected server options:\n%s", config)
+
+	b, err := h.GetBackends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetBackends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(b), 2, "haProxyImpl.GetBackends() returned unexptected number of objects")
+	assert.Equal(t, b[0], backends[0], "haProxyImpl.GetBackends() returned unexpected object")
+	assert.Equal(t, b[1], backends[1], "haProxyImpl.GetBackends() returned unexpected object")
+}
+
+// Tests that a backend health check is rendered as an httpchk option and parsed back unchanged.
+func Test_haProxyImpl_WriteConfig_HealthCheck(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	h := &haProxyImpl{
+		configPath: testFile,
+		template:   tmpl,
+	}
+	backends := Backends{
+		&Backend{
+			Name:        "test-app-1",
+			Mode:        "http",
+			HealthCheck: "GET /health",
+			Members: BackendMembers{
+				BackendMember{Name: "node_a", Host: "10.2.2.10", Port: 8080},
+			},
+		},
+		&Backend{
+			Name: "test-app-2",
+			Mode: "tcp",
+			Members: BackendMembers{
+				BackendMember{Name: "node_b", Host: "10.2.2.20", Port: 8080},
+			},
+		},
+	}
+	err := h.WriteConfig(Frontends{}, backends)
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() returned an unexpected error: %v", err)
+
+	config, _ := h.GetConfig()
+	assert.True(t, strings.Contains(config, "mode http\n    option httpchk GET /health\n"), "haProxyImpl.WriteConfig() did not render the health check:\n%s", config)
+	assert.Equal(t, strings.Count(config, "option httpchk"), 1, "haProxyImpl.WriteConfig() rendered an unexpected health check:\n%s", config)
+
+	b, err := h.GetBackends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetBackends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(b), 2, "haProxyImpl.GetBackends() returned unexptected number of objects")
+	assert.Equal(t, b[0], backends[0], "haProxyImpl.GetBackends() returned unexpected object")
+	assert.Equal(t, b[1], backends[1], "haProxyImpl.GetBackends() returned unexpected object")
+}
+
+// Tests that an error executing the config template names the entity being rendered along with the
+// template error, and that the config file is left unchanged.
+func Test_haProxyImpl_WriteConfig_TemplateError(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+
+	tmpl, _ := template.New("test").Parse("{{range .Frontends}}frontend {{.Name}}\n{{end}}{{range .Backends}}backend {{.Name}} {{.Foo}}\n{{end}}")
+	h := &haProxyImpl{
+		configPath: testFile,
+		template:   tmpl,
+	}
+	frontends := Frontends{&Frontend{Name: "test-fe"}}
+	backends := Backends{&Backend{Name: "test-app-1"}, &Backend{Name: "test-app-2"}}
+
+	err := h.WriteConfig(frontends, backends)
+	assert.EnsureNotNil(t, err, "haProxyImpl.WriteConfig() failed to return an expected error")
+	assert.StringContains(t, err.Error(), "error rendering the HAProxy config template for backend 'test-app-1'", "haProxyImpl.WriteConfig() returned an error without the entity")
+	assert.StringContains(t, err.Error(), "can't evaluate field Foo", "haProxyImpl.WriteConfig() returned an error without the template error")
+	_, err = os.Stat(testFile)
+	assert.True(t, os.IsNotExist(err), "haProxyImpl.WriteConfig() wrote the config file despite the template error")
+
+	_, err = h.RenderConfig(frontends, Backends{})
+	assert.Nil(t, err, "haProxyImpl.RenderConfig() returned an unexpected error: %v", err)
+}
+
+// Tests that the default template renders a defaults section for each mode with configured
+// defaults, followed by the frontends and backends of that mode, and that the frontends and backends
+// of other modes follow the global defaults.
+func Test_haProxyImpl_RenderConfig_ModeDefaults(t *testing.T) {
+	h := NewHAProxy(&Config{ModeDefaults: map[string][]string{
+		"tcp":  {"timeout client 1h", "timeout server 1h"},
+		"http": {"timeout client 30s", "option httplog"},
+	}}, nil)
+	frontends := Frontends{
+		&Frontend{Name: "web", Mode: "http"},
+		&Frontend{Name: "db", Mode: "tcp"},
+		&Frontend{Name: "probe", Mode: "health"},
+	}
+	backends := Backends{&Backend{Name: "db-1", Mode: "tcp"}, &Backend{Name: "web-1", Mode: "http"}}
+
+	config, err := h.RenderConfig(frontends, backends)
+	assert.EnsureNil(t, err, "haProxyImpl.RenderConfig() returned an unexpected error: %v", err)
+
+	httpDefaults := "  defaults\n    mode http\n    timeout client 30s\n    option httplog\n"
+	tcpDefaults := "  defaults\n    mode tcp\n    timeout client 1h\n    timeout server 1h\n"
+	assert.StringContains(t, config, httpDefaults, "haProxyImpl.RenderConfig() did not render the http defaults")
+	assert.StringContains(t, config, tcpDefaults, "haProxyImpl.RenderConfig() did not render the tcp defaults")
+
+	// each proxy follows the defaults of its mode, and those of other modes follow the global defaults
+	order := []string{"timeout connect 5000ms", "frontend probe", httpDefaults, "frontend web", "backend web-1", tcpDefaults, "frontend db", "backend db-1"}
+	last := -1
+	for _, s := range order {
+		i := strings.Index(config, s)
+		assert.EnsureTrue(t, i > last, "haProxyImpl.RenderConfig() rendered %q out of order:\n%s", s, config)
+		last = i
+	}
+}
+
+// ----------------------------------------------
+// haProxyImpl.ReloadConfig TESTS
+// ----------------------------------------------
+
+// starts a fake HAProxy socket server that sends each command it receives on the returned channel
+func startFakeHAProxySocket(t *testing.T, response string) (string, chan string, func()) {
+	dir, err := ioutil.TempDir("", "conduit_test_sock")
+	assert.EnsureNil(t, err, "Error creating temp dir for socket: %v", err)
+	sockPath := filepath.Join(dir, "haproxy.sock")
+
+	l, err := net.Listen("unix", sockPath)
+	assert.EnsureNil(t, err, "Error listening on fake HAProxy socket: %v", err)
+
+	cmds := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			cmds <- strings.TrimSpace(line)
+			conn.Write([]byte(response))
+			conn.Close()
+		}
+	}()
+
+	return sockPath, cmds, func() {
+		l.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// Tests that the haProxyImpl.IsRunning() function checks the HAProxy socket when one is configured.
+func Test_haProxyImpl_IsRunning_Socket(t *testing.T) {
+	sockPath, _, stop := startFakeHAProxySocket(t, "\n")
+	defer stop()
+
+	h := &haProxyImpl{socketPath: sockPath}
+	running, err := h.IsRunning()
+	assert.EnsureNil(t, err, "haProxyImpl.IsRunning() returned an unexpected error: %v", err)
+	assert.True(t, running, "haProxyImpl.IsRunning() failed to detect a running HAProxy")
+
+	h.socketPath = "test-fixtures/missing.sock"
+	running, err = h.IsRunning()
+	assert.EnsureNil(t, err, "haProxyImpl.IsRunning() returned an unexpected error: %v", err)
+	assert.False(t, running, "haProxyImpl.IsRunning() reported a missing socket as running")
+}
+
+// Tests that the haProxyImpl.IsRunning() function connects to the frontend bind addresses when no
+// socket is configured.
+func Test_haProxyImpl_IsRunning_Bind(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.EnsureNil(t, err, "net.Listen() returned an unexpected error: %v", err)
+	defer l.Close()
+
+	f, err := ioutil.TempFile("", "conduit_test_cfg")
+	assert.EnsureNil(t, err, "ioutil.TempFile() returned an unexpected error: %v", err)
+	defer os.Remove(f.Name())
+	fmt.Fprintf(f, "frontend app\n  bind %s\n", l.Addr().String())
+	f.Close()
+
+	h := &haProxyImpl{configPath: f.Name()}
+	running, err := h.IsRunning()
+	assert.EnsureNil(t, err, "haProxyImpl.IsRunning() returned an unexpected error: %v", err)
+	assert.True(t, running, "haProxyImpl.IsRunning() failed to detect a running HAProxy")
+
+	l.Close()
+	running, err = h.IsRunning()
+	assert.EnsureNil(t, err, "haProxyImpl.IsRunning() returned an unexpected error: %v", err)
+	assert.False(t, running, "haProxyImpl.IsRunning() reported a closed bind address as running")
+}
+
+// Tests that the haProxyImpl.IsRunning() function returns an error when there's nothing to check.
+func Test_haProxyImpl_IsRunning_NoBind(t *testing.T) {
+	f, err := ioutil.TempFile("", "conduit_test_cfg")
+	assert.EnsureNil(t, err, "ioutil.TempFile() returned an unexpected error: %v", err)
+	defer os.Remove(f.Name())
+	fmt.Fprint(f, "global\n  daemon\n")
+	f.Close()
+
+	h := &haProxyImpl{configPath: f.Name()}
+	_, err = h.IsRunning()
+	assert.NotNil(t, err, "haProxyImpl.IsRunning() failed to return an error without a socket or bind address")
+}
+
+// Tests that the haProxyImpl.GetStats() function issues "show stat" over the stats socket and parses
+// the CSV response.
+func Test_haProxyImpl_GetStats(t *testing.T) {
+	response := "# pxname,svname,scur,status,\n" +
+		"www,FRONTEND,3,OPEN,\n" +
+		"app,app_1,1,UP,\n" +
+		"app,BACKEND,1,UP,\n\n"
+	sockPath, cmds, stop := startFakeHAProxySocket(t, response)
+	defer stop()
+
+	h := &haProxyImpl{socketPath: "test-fixtures/missing.sock", statsPath: sockPath}
+	stats, err := h.GetStats()
+	assert.EnsureNil(t, err, "haProxyImpl.GetStats() returned an unexpected error: %v", err)
+	assert.Equal(t, <-cmds, "show stat", "haProxyImpl.GetStats() sent an unexpected command")
+
+	expected := []map[string]string{
+		{"pxname": "www", "svname": "FRONTEND", "scur": "3", "status": "OPEN"},
+		{"pxname": "app", "svname": "app_1", "scur": "1", "status": "UP"},
+		{"pxname": "app", "svname": "BACKEND", "scur": "1", "status": "UP"},
+	}
+	assert.Equal(t, stats, expected, "haProxyImpl.GetStats() returned unexpected stats")
+	assert.Equal(t, JSONEncoder{}.Encode(stats),
+		`[{"pxname":"www","scur":"3","status":"OPEN","svname":"FRONTEND"},`+
+			`{"pxname":"app","scur":"1","status":"UP","svname":"app_1"},`+
+			`{"pxname":"app","scur":"1","status":"UP","svname":"BACKEND"}]`,
+		"haProxyImpl.GetStats() returned stats with unexpected JSON")
+}
+
+// Tests that the haProxyImpl.GetStats() function falls back to the master/admin socket, and
+// returns an error when no socket is configured.
+func Test_haProxyImpl_GetStats_Socket(t *testing.T) {
+	sockPath, cmds, stop := startFakeHAProxySocket(t, "# pxname,svname,\nwww,FRONTEND,\n")
+	defer stop()
+
+	h := &haProxyImpl{socketPath: sockPath}
+	stats, err := h.GetStats()
+	assert.EnsureNil(t, err, "haProxyImpl.GetStats() returned an unexpected error: %v", err)
+	assert.Equal(t, <-cmds, "show stat", "haProxyImpl.GetStats() sent an unexpected command")
+	assert.Equal(t, len(stats), 1, "haProxyImpl.GetStats() returned an unexpected number of rows")
+
+	h.socketPath = ""
+	_, err = h.GetStats()
+	assert.NotNil(t, err, "haProxyImpl.GetStats() failed to return an error without a socket")
+}
+
+// Tests that the dialAddress() function resolves bind addresses to connectable addresses.
+func Test_dialAddress(t *testing.T) {
+	testCases := map[string]string{
+		"*:80":          "127.0.0.1:80",
+		":80":           "127.0.0.1:80",
+		"80":            "127.0.0.1:80",
+		"10.0.0.1:8000": "10.0.0.1:8000",
+		" *:8000-8010":  "127.0.0.1:8000",
+		":::443":        "[::]:443",
+	}
+	for bind, exp := range testCases {
+		assert.Equal(t, dialAddress(bind), exp, "dialAddress() returned an unexpected address for '%s'", bind)
+	}
+}
+
+// Tests that the config header comment is written at the top of the config file when enabled, and
+// that the config is still parsed correctly.
+func Test_haProxyImpl_WriteConfig_Header(t *testing.T) {
+	testFile := "test-fixtures/test.cfg"
+	defer os.Remove(testFile)
+
+	tmpl, _ := template.New("test").Parse(testTemplate)
+	h := &haProxyImpl{
+		configPath: testFile,
+		template:   tmpl,
+		header:     true,
+	}
+	frontends := Frontends{&Frontend{Name: "test-app", Bind: "*:80", Mode: "http"}}
+	backends := Backends{
+		&Backend{
+			Name:    "test-app-1",
+			Mode:    "http",
+			Members: BackendMembers{BackendMember{Name: "node_a", Host: "10.2.2.10", Port: 8080}},
+		},
+	}
+	before := time.Now().UTC().Truncate(time.Second)
+	err := h.WriteConfig(frontends, backends)
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() returned an unexpected error: %v", err)
+
+	config, _ := h.GetConfig()
+	header := strings.SplitN(config, "\n", 2)[0]
+	prefix := configHeaderPrefix + " " + version + " at "
+	assert.EnsureTrue(t, strings.HasPrefix(header, prefix), "haProxyImpl.WriteConfig() did not write the header:\n%s", config)
+	at, err := time.Parse(time.RFC3339, strings.TrimPrefix(header, prefix))
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() wrote an invalid header time: %v", err)
+	assert.False(t, at.Before(before), "haProxyImpl.WriteConfig() wrote an unexpected header time: %v", at)
+
+	rendered, _ := h.RenderConfig(frontends, backends)
+	assert.Equal(t, stripConfigHeader(config), rendered, "stripConfigHeader() did not remove only the header")
+
+	f, err := h.GetFrontends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetFrontends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(f), 1, "haProxyImpl.GetFrontends() returned unexptected number of objects")
+	assert.Equal(t, f[0], frontends[0], "haProxyImpl.GetFrontends() returned unexpected object")
+	b, err := h.GetBackends()
+	assert.EnsureNil(t, err, "haProxyImpl.GetBackends() returned an unexpected error: %v", err)
+	assert.EnsureEqual(t, len(b), 1, "haProxyImpl.GetBackends() returned unexptected number of objects")
+	assert.Equal(t, b[0], backends[0], "haProxyImpl.GetBackends() returned unexpected object")
+}
+
+// Tests that the haProxyImpl.WriteConfig() function keeps only the configured number of backups.
+func Test_haProxyImpl_WriteConfig_Backups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conduit_test_backups")
+	assert.EnsureNil(t, err, "ioutil.TempDir() returned an unexpected error: %v", err)
+	defer os.RemoveAll(dir)
+
+	tmpl, _ := template.New("test").Parse(`{{range .Backends}}backend {{.Name}}{{end}}`)
+	h := &haProxyImpl{
+		configPath:  filepath.Join(dir, "haproxy.cfg"),
+		template:    tmpl,
+		backupCount: 3,
+	}
+	for i := 1; i <= 5; i++ {
+		err := h.WriteConfig(Frontends{}, Backends{&Backend{Name: fmt.Sprintf("app%d", i)}})
+		assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() returned an unexpected error: %v", err)
+	}
+
+	// the first write had nothing to back up, and the oldest backup has been rotated out
+	files, err := ioutil.ReadDir(filepath.Join(dir, "backups"))
+	assert.EnsureNil(t, err, "haProxyImpl.WriteConfig() failed to create the backups directory: %v", err)
+	assert.EnsureEqual(t, len(files), 3, "haProxyImpl.WriteConfig() kept an unexpected number of backups")
+	for i, f := range files {
+		c, _ := ioutil.ReadFile(filepath.Join(dir, "backups", f.Name()))
+		assert.Equal(t, string(c), fmt.Sprintf("backend app%d", i+2), "haProxyImpl.WriteConfig() kept an unexpected backup %s", f.Name())
+	}
+	c, _ := ioutil.ReadFile(h.configPath)
+	assert.Equal(t, string(c), "backend app5", "haProxyImpl.WriteConfig() wrote an unexpected config")
+}
+
+// Tests that the haProxyImpl.ReloadConfig() function executes the reload command by default.
+func Test_haProxyImpl_ReloadConfig_Command(t *testing.T) {
+	h := &haProxyImpl{
+		reloadCmd:      "true",
+		reloadStrategy: reloadStrategyCommand,
+	}
+	err := h.ReloadConfig()
+	assert.Nil(t, err, "haProxyImpl.ReloadConfig() returned an unexpected error: %v", err)
+
+	h.reloadCmd = "false"
+	err = h.ReloadConfig()
+	assert.NotNil(t, err, "haProxyImpl.ReloadConfig() failed to return an error for a failing command")
+}
+
+// Tests that the haProxyImpl.ReloadConfig() function executes the reload argv without a shell, and
+// the reload command with one.
+func Test_haProxyImpl_ReloadConfig_Argv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conduit reload argv")
+	assert.EnsureNil(t, err, "ioutil.TempDir() returned an unexpected error: %v", err)
+	defer os.RemoveAll(dir)
+
+	// an argument with spaces and shell metacharacters is passed as-is
+	marker := filepath.Join(dir, "reloaded; $(false)")
+	h := &haProxyImpl{
+		reloadCmd:      "false",
+		reloadArgv:     []string{"touch", marker},
+		reloadStrategy: reloadStrategyCommand,
+	}
+	err = h.ReloadConfig()
+	assert.EnsureNil(t, err, "haProxyImpl.ReloadConfig() returned an unexpected error: %v", err)
+	_, err = os.Stat(marker)
+	assert.Nil(t, err, "haProxyImpl.ReloadConfig() failed to execute the reload argv")
+
+	// the shell command needs the argument quoted
+	shellMarker := filepath.Join(dir, "shell reloaded")
+	h = &haProxyImpl{
+		reloadCmd:      fmt.Sprintf("touch '%s'", shellMarker),
+		reloadStrategy: reloadStrategyCommand,
+	}
+	err = h.ReloadConfig()
+	assert.EnsureNil(t, err, "haProxyImpl.ReloadConfig() returned an unexpected error: %v", err)
+	_, err = os.Stat(shellMarker)
+	assert.Nil(t, err, "haProxyImpl.ReloadConfig() failed to execute the reload command")
+
+	h.reloadArgv = []string{"false"}
+	err = h.ReloadConfig()
+	assert.NotNil(t, err, "haProxyImpl.ReloadConfig() failed to return an error for a failing argv")
+}
+
+// Tests that the haProxyImpl.ReloadConfig() function sends a reload command over the socket.
+func Test_haProxyImpl_ReloadConfig_Socket(t *testing.T) {
+	sockPath, cmds, stop := startFakeHAProxySocket(t, "\n")
+	defer stop()
+
+	h := &haProxyImpl{
+		reloadCmd:      "false",
+		reloadStrategy: reloadStrategySocket,
+		socketPath:     sockPath,
+	}
+	err := h.ReloadConfig()
+	assert.EnsureNil(t, err, "haProxyImpl.ReloadConfig() returned an unexpected error: %v", err)
+
+	select {
+	case cmd := <-cmds:
+		assert.Equal(t, cmd, "reload", "haProxyImpl.ReloadConfig() sent an unexpected socket command")
+	case <-time.After(time.Second):
+		t.Fatal("haProxyImpl.ReloadConfig() did not send a command to the socket")
+	}
+}
+
+// Tests that the haProxyImpl.ReloadConfig() function returns an error when the socket is unavailable.
+func Test_haProxyImpl_ReloadConfig_SocketMissing(t *testing.T) {
+	h := &haProxyImpl{
+		reloadStrategy: reloadStrategySocket,
+		socketPath:     "test-fixtures/missing.sock",
+	}
+	err := h.ReloadConfig()
+	assert.NotNil(t, err, "haProxyImpl.ReloadConfig() failed to return an error for a missing socket")
+}
+
+// Tests that the haProxyImpl.ReloadConfigUsing() function overrides the configured reload strategy.
+func Test_haProxyImpl_ReloadConfigUsing(t *testing.T) {
+	sockPath, cmds, stop := startFakeHAProxySocket(t, "\n")
+	defer stop()
+
+	h := &haProxyImpl{
+		reloadCmd:      "false",
+		reloadStrategy: reloadStrategyCommand,
+		socketPath:     sockPath,
+	}
+	err := h.ReloadConfigUsing(reloadStrategySocket)
+	assert.EnsureNil(t, err, "haProxyImpl.ReloadConfigUsing() returned an unexpected error: %v", err)
+	select {
+	case cmd := <-cmds:
+		assert.Equal(t, cmd, "reload", "haProxyImpl.ReloadConfigUsing() sent an unexpected socket command")
+	case <-time.After(time.Second):
+		t.Fatal("haProxyImpl.ReloadConfigUsing() did not send a command to the socket")
+	}
+
+	// an empty strategy uses the configured command strategy
+	err = h.ReloadConfigUsing("")
+	assert.NotNil(t, err, "haProxyImpl.ReloadConfigUsing() did not execute the configured reload command")
+}
+
+// Tests that the backendBlock() function returns only the section of the named backend.
+func Test_backendBlock(t *testing.T) {
+	config := "global\n  daemon\nbackend one\nmode http\nserver s1 10.0.0.1:80\nbackend two\nserver s2 10.0.0.2:80\n"
+	assert.Equal(t, backendBlock(config, "one"), "backend one\nmode http\nserver s1 10.0.0.1:80\n",
+		"backendBlock() returned an unexpected block")
+	assert.Equal(t, backendBlock(config, "two"), "backend two\nserver s2 10.0.0.2:80\n",
+		"backendBlock() returned an unexpected block")
+	assert.Equal(t, backendBlock(config, "three"), "", "backendBlock() returned a block for a missing backend")
+}
@@ -5,21 +5,106 @@ import (
 	"fmt"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 	ldbutil "github.com/syndtr/goleveldb/leveldb/util"
 )
 
+// the reserved key of the stored HAProxy config template, which is outside of the frontend and
+// backend key ranges
+const templateKey = "haproxy/template"
+
 type levelDBDatastore struct {
-	db *leveldb.DB
+	// the database, when the datastore has no guard; otherwise the guard holds the shared handle
+	db        *leveldb.DB
+	namespace string
+	guard     *dbGuard
+
+	// the snapshot that reads are made from, or nil to read the current state of the database; a
+	// datastore with a snapshot is read-only
+	snap *leveldb.Snapshot
+}
+
+// ldbReader is the read access shared by the database and its snapshots.
+type ldbReader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	NewIterator(slice *ldbutil.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+// returns the shared database handle for the duration of an operation, which must be followed by a
+// call to release; an error is returned if the database has been closed
+func (ldb *levelDBDatastore) acquire() (*leveldb.DB, *Error) {
+	if ldb.guard == nil {
+		return ldb.db, nil
+	}
+	if !ldb.guard.enter() {
+		return nil, NewErrorf(ErrDB, "the database has been closed")
+	}
+	return ldb.guard.db, nil
+}
+
+// like acquire, for an operation that writes to the database; an error is returned if the
+// datastore is a read-only snapshot
+func (ldb *levelDBDatastore) acquireWrite() (*leveldb.DB, *Error) {
+	if ldb.snap != nil {
+		return nil, NewErrorf(ErrDB, "the datastore snapshot is read-only")
+	}
+	return ldb.acquire()
+}
+
+// returns what to read from: the snapshot, if the datastore has one, or else the given database
+func (ldb *levelDBDatastore) reader(db *leveldb.DB) ldbReader {
+	if ldb.snap != nil {
+		return ldb.snap
+	}
+	return db
+}
+
+// marks the end of an operation that began with a call to acquire
+func (ldb *levelDBDatastore) release() {
+	if ldb.guard != nil {
+		ldb.guard.exit()
+	}
+}
+
+// returns the full database key for the given key, prefixed with the datastore's namespace
+func (ldb *levelDBDatastore) key(format string, a ...interface{}) []byte {
+	k := fmt.Sprintf(format, a...)
+	if ldb.namespace != "" {
+		k = ldb.namespace + "/" + k
+	}
+	return []byte(k)
 }
 
-// GetAllFrontends returns all the frontends in the database, or nil.
+// returns the key range that contains every key beginning with the given prefix; the vendored
+// ldbutil.BytesPrefix() only limits the range by the first byte of the prefix, which lets
+// iterators run past the prefix into other namespaces
+func keyPrefixRange(prefix []byte) *ldbutil.Range {
+	var limit []byte
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if c := prefix[i]; c < 0xff {
+			limit = make([]byte, i+1)
+			copy(limit, prefix)
+			limit[i] = c + 1
+			break
+		}
+	}
+	return &ldbutil.Range{Start: prefix, Limit: limit}
+}
+
+// GetAllFrontends returns all the frontends in the database, or an empty list if there are none.
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ldb *levelDBDatastore) GetAllFrontends() (Frontends, *Error) {
-	db := ldb.db
+	db, derr := ldb.acquire()
+	if derr != nil {
+		return nil, derr
+	}
+	defer ldb.release()
 	results := Frontends{}
 
-	iter := db.NewIterator(ldbutil.BytesPrefix([]byte("frontend")), nil)
+	iter := ldb.reader(db).NewIterator(keyPrefixRange(ldb.key("frontend/")), nil)
+	defer iter.Release()
 	for iter.Next() {
 		frontend := &Frontend{}
 		if err := json.Unmarshal(iter.Value(), frontend); err != nil {
@@ -28,7 +113,6 @@ func (ldb *levelDBDatastore) GetAllFrontends() (Frontends, *Error) {
 
 		results = append(results, frontend)
 	}
-	iter.Release()
 
 	if err := iter.Error(); err != nil {
 		return nil, NewError(ErrDB, err)
@@ -41,10 +125,13 @@ func (ldb *levelDBDatastore) GetAllFrontends() (Frontends, *Error) {
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ldb *levelDBDatastore) GetFrontend(key string) (*Frontend, *Error) {
-	db := ldb.db
+	db, derr := ldb.acquire()
+	if derr != nil {
+		return nil, derr
+	}
+	defer ldb.release()
 	result := &Frontend{}
-	id := fmt.Sprintf("frontend/%s", key)
-	resultBytes, err := db.Get([]byte(id), nil)
+	resultBytes, err := ldb.reader(db).Get(ldb.key("frontend/%s", key), nil)
 
 	if err != nil {
 		// If an entity isn't found in the database, its reported as an error
@@ -62,11 +149,37 @@ func (ldb *levelDBDatastore) GetFrontend(key string) (*Frontend, *Error) {
 	return result, nil
 }
 
+// GetRaw returns the stored form of the frontend or backend of the given proxy type ("frontend" or
+// "backend") that has the specified id, or nil.
+// Potential error types:
+//   ErrDB: error reading/writing to the database
+func (ldb *levelDBDatastore) GetRaw(proxyType, key string) (*RawRecord, *Error) {
+	db, derr := ldb.acquire()
+	if derr != nil {
+		return nil, derr
+	}
+	defer ldb.release()
+	id := fmt.Sprintf("%s/%s", proxyType, key)
+	k := ldb.key("%s", id)
+	value, err := ldb.reader(db).Get(k, nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, nil
+		}
+		return nil, NewError(ErrDB, err)
+	}
+	return &RawRecord{ID: id, ProxyType: proxyType, Key: string(k), Value: value}, nil
+}
+
 // SaveFrontend upserts a frontend and returns an error if the operation failed.
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ldb *levelDBDatastore) SaveFrontend(f *Frontend) *Error {
-	db := ldb.db
+	db, derr := ldb.acquireWrite()
+	if derr != nil {
+		return derr
+	}
+	defer ldb.release()
 
 	f.ProxyType = "frontend"
 	f.ID = fmt.Sprintf("%s/%s", f.ProxyType, f.Name)
@@ -76,7 +189,7 @@ func (ldb *levelDBDatastore) SaveFrontend(f *Frontend) *Error {
 		return NewError(ErrDB, err)
 	}
 
-	if err := db.Put([]byte(f.ID), aBytes, nil); err != nil {
+	if err := db.Put(ldb.key("%s", f.ID), aBytes, nil); err != nil {
 		return NewError(ErrDB, err)
 	}
 
@@ -92,25 +205,31 @@ func (ldb *levelDBDatastore) SaveFrontend(f *Frontend) *Error {
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ldb *levelDBDatastore) DeleteFrontend(key string) *Error {
-	db := ldb.db
-	fullkey := fmt.Sprintf("frontend/%s", key)
-	err := db.Delete([]byte(fullkey), nil)
-
-	if err != nil {
+	db, derr := ldb.acquireWrite()
+	if derr != nil {
+		return derr
+	}
+	defer ldb.release()
+	if err := db.Delete(ldb.key("frontend/%s", key), nil); err != nil {
 		return NewError(ErrDB, err)
 	}
 
 	return nil
 }
 
-// GetAllBackends returns all the backends in the database, or nil.
+// GetAllBackends returns all the backends in the database, or an empty list if there are none.
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ldb *levelDBDatastore) GetAllBackends() (Backends, *Error) {
-	db := ldb.db
+	db, derr := ldb.acquire()
+	if derr != nil {
+		return nil, derr
+	}
+	defer ldb.release()
 	results := Backends{}
 
-	iter := db.NewIterator(ldbutil.BytesPrefix([]byte("backend")), nil)
+	iter := ldb.reader(db).NewIterator(keyPrefixRange(ldb.key("backend/")), nil)
+	defer iter.Release()
 	for iter.Next() {
 		backend := &Backend{}
 		if err := json.Unmarshal(iter.Value(), backend); err != nil {
@@ -119,7 +238,6 @@ func (ldb *levelDBDatastore) GetAllBackends() (Backends, *Error) {
 
 		results = append(results, backend)
 	}
-	iter.Release()
 
 	if err := iter.Error(); err != nil {
 		return nil, NewError(ErrDB, err)
@@ -132,11 +250,13 @@ func (ldb *levelDBDatastore) GetAllBackends() (Backends, *Error) {
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ldb *levelDBDatastore) GetBackend(key string) (*Backend, *Error) {
-	db := ldb.db
+	db, derr := ldb.acquire()
+	if derr != nil {
+		return nil, derr
+	}
+	defer ldb.release()
 	result := &Backend{}
-	id := fmt.Sprintf("backend/%s", key)
-
-	resultBytes, err := db.Get([]byte(id), nil)
+	resultBytes, err := ldb.reader(db).Get(ldb.key("backend/%s", key), nil)
 	if err != nil {
 		// If an entity isn't found in the database, its reported as an error
 		// from LevelDB, but this isn't an error to Conduit
@@ -157,7 +277,11 @@ func (ldb *levelDBDatastore) GetBackend(key string) (*Backend, *Error) {
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ldb *levelDBDatastore) SaveBackend(b *Backend) *Error {
-	db := ldb.db
+	db, derr := ldb.acquireWrite()
+	if derr != nil {
+		return derr
+	}
+	defer ldb.release()
 
 	b.ProxyType = "backend"
 	b.ID = fmt.Sprintf("%s/%s", b.ProxyType, b.Name)
@@ -167,7 +291,7 @@ func (ldb *levelDBDatastore) SaveBackend(b *Backend) *Error {
 		return NewError(ErrDB, err)
 	}
 
-	if err := db.Put([]byte(b.ID), aBytes, nil); err != nil {
+	if err := db.Put(ldb.key("%s", b.ID), aBytes, nil); err != nil {
 		return NewError(ErrDB, err)
 	}
 
@@ -183,12 +307,74 @@ func (ldb *levelDBDatastore) SaveBackend(b *Backend) *Error {
 // Potential error types:
 //   ErrDB: error reading/writing to the database
 func (ldb *levelDBDatastore) DeleteBackend(key string) *Error {
-	db := ldb.db
-	id := fmt.Sprintf("backend/%s", key)
-
-	if err := db.Delete([]byte(id), nil); err != nil {
+	db, derr := ldb.acquireWrite()
+	if derr != nil {
+		return derr
+	}
+	defer ldb.release()
+	if err := db.Delete(ldb.key("backend/%s", key), nil); err != nil {
 		return NewError(ErrDB, err)
 	}
 
 	return nil
 }
+
+// Snapshot returns a read-only view of the datastore as it is now, which is unaffected by later
+// writes, and a function that releases the snapshot once it's no longer needed.
+// Potential error types:
+//   ErrDB: error reading/writing to the database
+func (ldb *levelDBDatastore) Snapshot() (Datastore, func(), *Error) {
+	if ldb.snap != nil {
+		return ldb, func() {}, nil
+	}
+	db, derr := ldb.acquire()
+	if derr != nil {
+		return nil, nil, derr
+	}
+	defer ldb.release()
+	snap, err := db.GetSnapshot()
+	if err != nil {
+		return nil, nil, NewError(ErrDB, err)
+	}
+	return &levelDBDatastore{db: ldb.db, namespace: ldb.namespace, guard: ldb.guard, snap: snap}, snap.Release, nil
+}
+
+// GetTemplate returns the stored HAProxy config template, or an empty string if none is stored.
+// Potential error types:
+//   ErrDB: error reading/writing to the database
+func (ldb *levelDBDatastore) GetTemplate() (string, *Error) {
+	db, derr := ldb.acquire()
+	if derr != nil {
+		return "", derr
+	}
+	defer ldb.release()
+	text, err := ldb.reader(db).Get(ldb.key(templateKey), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return "", nil
+		}
+		return "", NewError(ErrDB, err)
+	}
+	return string(text), nil
+}
+
+// SaveTemplate stores the HAProxy config template; an empty template removes the stored one.
+// Potential error types:
+//   ErrDB: error reading/writing to the database
+func (ldb *levelDBDatastore) SaveTemplate(text string) *Error {
+	db, derr := ldb.acquireWrite()
+	if derr != nil {
+		return derr
+	}
+	defer ldb.release()
+	var err error
+	if text == "" {
+		err = db.Delete(ldb.key(templateKey), nil)
+	} else {
+		err = db.Put(ldb.key(templateKey), []byte(text), nil)
+	}
+	if err != nil {
+		return NewError(ErrDB, err)
+	}
+	return nil
+}
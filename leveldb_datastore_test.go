@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"testing"
 )
@@ -73,6 +74,23 @@ func Test_levelDBFrontend_GetAll(t *testing.T) {
 	testCase.execute(t)
 }
 
+// Tests that the levelDBFrontend.GetAll() function returns an empty list, not nil, when there are
+// no frontends.
+func Test_levelDBFrontend_GetAll_Empty(t *testing.T) {
+	testAction := func(db Datastore) {
+		frontends, derr := db.GetAllFrontends()
+		assert.EnsureNil(t, derr, "levelDBFrontend.GetAll() returned an unexpected error: %v", derr)
+		assert.Equal(t, JSONEncoder{}.Encode(frontends), "[]", "levelDBFrontend.GetAll() returned an unexpected value")
+	}
+
+	testCase := levelDBTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+	}
+	testCase.execute(t)
+}
+
 // ----------------------------------------------
 // levelDBFrontend.Get TESTS
 // ----------------------------------------------
@@ -245,6 +263,23 @@ func Test_levelDBBackend_GetAll(t *testing.T) {
 	testCase.execute(t)
 }
 
+// Tests that the levelDBBackend.GetAll() function returns an empty list, not nil, when there are
+// no backends.
+func Test_levelDBBackend_GetAll_Empty(t *testing.T) {
+	testAction := func(db Datastore) {
+		backends, derr := db.GetAllBackends()
+		assert.EnsureNil(t, derr, "levelDBBackend.GetAll() returned an unexpected error: %v", derr)
+		assert.Equal(t, JSONEncoder{}.Encode(backends), "[]", "levelDBBackend.GetAll() returned an unexpected value")
+	}
+
+	testCase := levelDBTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+	}
+	testCase.execute(t)
+}
+
 // ----------------------------------------------
 // levelDBBackend.Get TESTS
 // ----------------------------------------------
@@ -380,3 +415,167 @@ func Test_levelDBBackend_Delete_NonExistentBackend(t *testing.T) {
 	}
 	testCase.execute(t)
 }
+
+// ----------------------------------------------
+// levelDBDatastore.GetRaw TESTS
+// ----------------------------------------------
+
+// Tests that the levelDBDatastore.GetRaw() function returns the stored form of a record along with
+// its ID and ProxyType.
+func Test_levelDBDatastore_GetRaw(t *testing.T) {
+	b := ldbBTData.OneBackend()
+
+	setup := func(db Datastore) {
+		derr := db.SaveBackend(b)
+		assert.EnsureNil(t, derr, "levelDBBackend.Save() returned an unexpected error: %v", derr)
+	}
+
+	testAction := func(db Datastore) {
+		rec, derr := db.GetRaw("backend", b.Name)
+		assert.EnsureNil(t, derr, "levelDBDatastore.GetRaw() returned an unexpected error: %v", derr)
+		assert.EnsureNotNil(t, rec, "levelDBDatastore.GetRaw() failed to return the stored record")
+		assert.Equal(t, rec.ID, "backend/"+b.Name, "levelDBDatastore.GetRaw() returned an unexpected ID")
+		assert.Equal(t, rec.ProxyType, "backend", "levelDBDatastore.GetRaw() returned an unexpected ProxyType")
+		assert.Equal(t, rec.Key, "backend/"+b.Name, "levelDBDatastore.GetRaw() returned an unexpected key")
+
+		stored := &Backend{}
+		err := json.Unmarshal(rec.Value, stored)
+		assert.EnsureNil(t, err, "levelDBDatastore.GetRaw() returned an invalid value: %v", err)
+		assert.Equal(t, stored.Name, b.Name, "levelDBDatastore.GetRaw() returned an unexpected value")
+
+		rec, derr = db.GetRaw("frontend", b.Name)
+		assert.EnsureNil(t, derr, "levelDBDatastore.GetRaw() returned an unexpected error: %v", derr)
+		assert.Nil(t, rec, "levelDBDatastore.GetRaw() returned a record of the wrong proxy type")
+	}
+
+	testCase := levelDBTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}
+	testCase.execute(t)
+}
+
+// ----------------------------------------------
+// levelDBDatastore.SaveTemplate TESTS
+// ----------------------------------------------
+
+// Tests that the HAProxy config template is stored and retrieved, and that saving an empty
+// template removes it.
+func Test_levelDBDatastore_SaveTemplate(t *testing.T) {
+	testAction := func(db Datastore) {
+		text, derr := db.GetTemplate()
+		assert.EnsureNil(t, derr, "levelDBDatastore.GetTemplate() returned an unexpected error: %v", derr)
+		assert.Equal(t, text, "", "levelDBDatastore.GetTemplate() returned a template when none is stored")
+
+		derr = db.SaveTemplate(testTemplate)
+		assert.EnsureNil(t, derr, "levelDBDatastore.SaveTemplate() returned an unexpected error: %v", derr)
+		text, derr = db.GetTemplate()
+		assert.EnsureNil(t, derr, "levelDBDatastore.GetTemplate() returned an unexpected error: %v", derr)
+		assert.Equal(t, text, testTemplate, "levelDBDatastore.GetTemplate() returned an unexpected template")
+
+		derr = db.SaveTemplate("")
+		assert.EnsureNil(t, derr, "levelDBDatastore.SaveTemplate() returned an unexpected error: %v", derr)
+		text, derr = db.GetTemplate()
+		assert.EnsureNil(t, derr, "levelDBDatastore.GetTemplate() returned an unexpected error: %v", derr)
+		assert.Equal(t, text, "", "levelDBDatastore.SaveTemplate() failed to remove the template")
+	}
+
+	testCase := levelDBTestCase{
+		Setup:    nil,
+		Action:   testAction,
+		Teardown: nil,
+	}
+	testCase.execute(t)
+}
+
+// Tests that a levelDBDatastore snapshot isn't affected by later writes, and is read-only.
+func Test_levelDBDatastore_Snapshot(t *testing.T) {
+	f1 := ldbFTData.OneFrontend()
+	f2 := ldbFTData.OneFrontend()
+	f2.Name = "other"
+
+	setup := func(db Datastore) {
+		db.SaveFrontend(f1)
+	}
+
+	testAction := func(db Datastore) {
+		snap, release, derr := db.(*levelDBDatastore).Snapshot()
+		assert.EnsureNil(t, derr, "levelDBDatastore.Snapshot() returned an unexpected error: %v", derr)
+		defer release()
+
+		assert.EnsureNil(t, db.SaveFrontend(f2), "levelDBDatastore.SaveFrontend() returned an unexpected error")
+		assert.EnsureNil(t, db.DeleteFrontend(f1.Name), "levelDBDatastore.DeleteFrontend() returned an unexpected error")
+
+		all, derr := snap.GetAllFrontends()
+		assert.EnsureNil(t, derr, "levelDBDatastore.GetAllFrontends() returned an unexpected error: %v", derr)
+		assert.EnsureEqual(t, len(all), 1, "levelDBDatastore.Snapshot() returned a view affected by later writes")
+		assert.Equal(t, all[0].Name, f1.Name, "levelDBDatastore.Snapshot() returned an unexpected frontend")
+		f, _ := snap.GetFrontend(f2.Name)
+		assert.Nil(t, f, "levelDBDatastore.Snapshot() returned a frontend saved after the snapshot")
+
+		derr = snap.SaveFrontend(f2)
+		assert.EnsureNotNil(t, derr, "levelDBDatastore.SaveFrontend() wrote to a snapshot")
+		assert.Equal(t, derr.Type, ErrDB, "levelDBDatastore.SaveFrontend() returned an unexpected error type")
+	}
+
+	testCase := levelDBTestCase{
+		Setup:    setup,
+		Action:   testAction,
+		Teardown: nil,
+	}
+	testCase.execute(t)
+}
+
+// ----------------------------------------------
+// levelDBDatastore namespace TESTS
+// ----------------------------------------------
+
+// Tests that datastores with different namespaces sharing one database don't see each other's records.
+func Test_levelDBDatastore_Namespaces(t *testing.T) {
+	dbPath := testHelpers.DBPath(t)
+	defer os.Remove(dbPath)
+	leveldb := testHelpers.LevelDB(t, dbPath)
+	defer leveldb.Close()
+
+	dbA := &levelDBDatastore{db: leveldb, namespace: "tenant-a"}
+	dbB := &levelDBDatastore{db: leveldb, namespace: "tenant-b"}
+	dbDefault := &levelDBDatastore{db: leveldb}
+
+	derr := dbA.SaveFrontend(ldbFTData.OneFrontend())
+	assert.EnsureNil(t, derr, "levelDBFrontend.Save() returned an unexpected error: %v", derr)
+	derr = dbA.SaveBackend(ldbBTData.OneBackend())
+	assert.EnsureNil(t, derr, "levelDBBackend.Save() returned an unexpected error: %v", derr)
+	derr = dbB.SaveFrontend(ldbFTData.OtherFrontend())
+	assert.EnsureNil(t, derr, "levelDBFrontend.Save() returned an unexpected error: %v", derr)
+
+	for _, testCase := range []struct {
+		Name      string
+		DB        Datastore
+		Frontends int
+		Backends  int
+	}{
+		{Name: "tenant-a", DB: dbA, Frontends: 1, Backends: 1},
+		{Name: "tenant-b", DB: dbB, Frontends: 1, Backends: 0},
+		{Name: "default", DB: dbDefault, Frontends: 0, Backends: 0},
+	} {
+		frontends, derr := testCase.DB.GetAllFrontends()
+		assert.EnsureNil(t, derr, "levelDBFrontend.GetAll() returned an unexpected error: %v", derr)
+		assert.Equal(t, len(frontends), testCase.Frontends, "levelDBFrontend.GetAll() returned an unexpected number of values for namespace %s", testCase.Name)
+
+		backends, derr := testCase.DB.GetAllBackends()
+		assert.EnsureNil(t, derr, "levelDBBackend.GetAll() returned an unexpected error: %v", derr)
+		assert.Equal(t, len(backends), testCase.Backends, "levelDBBackend.GetAll() returned an unexpected number of values for namespace %s", testCase.Name)
+	}
+
+	f, derr := dbB.GetFrontend(ldbFTData.OneFrontend().Name)
+	assert.EnsureNil(t, derr, "levelDBFrontend.Get() returned an unexpected error: %v", derr)
+	assert.Nil(t, f, "levelDBFrontend.Get() returned a frontend from another namespace")
+
+	// deleting from one namespace should not affect another
+	derr = dbB.DeleteFrontend(ldbFTData.OneFrontend().Name)
+	assert.EnsureNil(t, derr, "levelDBFrontend.Delete() returned an unexpected error: %v", derr)
+	f, derr = dbA.GetFrontend(ldbFTData.OneFrontend().Name)
+	assert.EnsureNil(t, derr, "levelDBFrontend.Get() returned an unexpected error: %v", derr)
+	assert.NotNil(t, f, "levelDBFrontend.Delete() removed a frontend from another namespace")
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// patchOperation is a single operation of a JSON Patch (RFC 6902) document.
+type patchOperation struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	From string `json:"from"`
+
+	// the raw value of the operation, which is nil if the value is missing rather than null
+	Value json.RawMessage `json:"value"`
+}
+
+// applyPatch applies the given JSON Patch (RFC 6902) document to the given JSON document and returns
+// the patched document; the operations are applied in order, and the first one that fails stops the
+// patch with an error.
+func applyPatch(doc, patch []byte) ([]byte, error) {
+	ops := []patchOperation{}
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, errors.New("the patch is not a valid JSON Patch document - must be an array of operations")
+	}
+	var v interface{}
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		var err error
+		if v, err = op.apply(v); err != nil {
+			return nil, fmt.Errorf("patch operation %d is invalid - %v", i, err)
+		}
+	}
+	return json.Marshal(v)
+}
+
+// applies the operation to the given document, returning the patched document
+func (op patchOperation) apply(doc interface{}) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("a '%s' operation requires a value", op.Op)
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+		switch op.Op {
+		case "add":
+			return patchAdd(doc, path, value)
+		case "replace":
+			if _, err := patchGet(doc, path); err != nil {
+				return nil, err
+			}
+			return patchSet(doc, path, value)
+		default:
+			current, err := patchGet(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("the value at '%s' does not match the tested value", op.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		return patchRemove(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := patchGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return nil, fmt.Errorf("'%s' can't be moved into one of its children", op.From)
+			}
+			if doc, err = patchRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			// copy the value, so that later operations on either location don't affect the other
+			b, _ := json.Marshal(value)
+			json.Unmarshal(b, &value)
+		}
+		return patchAdd(doc, path, value)
+	default:
+		return nil, fmt.Errorf("the op '%s' is not supported - must be add, remove, replace, move, copy, or test", op.Op)
+	}
+}
+
+// parses the given JSON Pointer (RFC 6901) into its reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("the path '%s' is invalid - must be empty or begin with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// returns the index of the array element referenced by the given token; when adding, the index may
+// be the array's length, which "-" refers to as well
+func patchIndex(token string, length int, adding bool) (int, error) {
+	if token == "-" && adding {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("'%s' is not a valid array index", token)
+	}
+	if i > length || (i == length && !adding) {
+		return 0, fmt.Errorf("the array index %d is out of range", i)
+	}
+	return i, nil
+}
+
+// returns the value at the given path of the document
+func patchGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch c := doc.(type) {
+		case map[string]interface{}:
+			v, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("the path '/%s' does not exist", strings.Join(path, "/"))
+			}
+			doc = v
+		case []interface{}:
+			i, err := patchIndex(token, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, fmt.Errorf("the path '/%s' does not exist", strings.Join(path, "/"))
+		}
+	}
+	return doc, nil
+}
+
+// returns the document with the container holding the last token of the given path replaced by the
+// result of fn, which is passed the container and that token
+func patchUpdate(doc interface{}, path []string, fn func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	parent, err := patchGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := fn(parent, path[len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 1 {
+		return updated, nil
+	}
+	return patchSet(doc, path[:len(path)-1], updated)
+}
+
+// returns the document with the existing value at the given path replaced by the given value
+func patchSet(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return patchUpdate(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			i, err := patchIndex(token, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			c[i] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("the path '/%s' does not exist", strings.Join(path, "/"))
+		}
+	})
+}
+
+// returns the document with the given value added at the given path; an existing object member is
+// replaced, and an array element is inserted before the one at the given index
+func patchAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return patchUpdate(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			i, err := patchIndex(token, len(c), true)
+			if err != nil {
+				return nil, err
+			}
+			if i == len(c) {
+				return append(c, value), nil
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("the path '/%s' does not exist", strings.Join(path, "/"))
+		}
+	})
+}
+
+// returns the document with the value at the given path removed
+func patchRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("the whole document can't be removed")
+	}
+	return patchUpdate(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			if _, ok := c[token]; !ok {
+				return nil, fmt.Errorf("the path '/%s' does not exist", strings.Join(path, "/"))
+			}
+			delete(c, token)
+			return c, nil
+		case []interface{}:
+			i, err := patchIndex(token, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("the path '/%s' does not exist", strings.Join(path, "/"))
+		}
+	})
+}
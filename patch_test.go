@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_applyPatch(t *testing.T) {
+	doc := `{"a":1,"b":[1,2,3],"c":{"d":"x"}}`
+	tests := []struct {
+		patch    string
+		expected string
+	}{
+		{`[{"op":"add","path":"/e","value":true}]`, `{"a":1,"b":[1,2,3],"c":{"d":"x"},"e":true}`},
+		{`[{"op":"add","path":"/b/1","value":9}]`, `{"a":1,"b":[1,9,2,3],"c":{"d":"x"}}`},
+		{`[{"op":"add","path":"/b/-","value":4}]`, `{"a":1,"b":[1,2,3,4],"c":{"d":"x"}}`},
+		{`[{"op":"remove","path":"/b/0"}]`, `{"a":1,"b":[2,3],"c":{"d":"x"}}`},
+		{`[{"op":"replace","path":"/c/d","value":null}]`, `{"a":1,"b":[1,2,3],"c":{"d":null}}`},
+		{`[{"op":"move","from":"/a","path":"/c/a"}]`, `{"b":[1,2,3],"c":{"a":1,"d":"x"}}`},
+		{`[{"op":"copy","from":"/c","path":"/f"}]`, `{"a":1,"b":[1,2,3],"c":{"d":"x"},"f":{"d":"x"}}`},
+		{`[{"op":"test","path":"/b","value":[1,2,3]},{"op":"remove","path":"/b"}]`, `{"a":1,"c":{"d":"x"}}`},
+	}
+
+	for _, test := range tests {
+		patched, err := applyPatch([]byte(doc), []byte(test.patch))
+		assert.EnsureNil(t, err, "applyPatch() returned an unexpected error for %s: %v", test.patch, err)
+		assert.Equal(t, string(patched), test.expected, "applyPatch() returned an unexpected document for %s", test.patch)
+	}
+}
+
+func Test_applyPatch_Invalid(t *testing.T) {
+	doc := `{"a":1,"b":[1,2,3]}`
+	tests := []string{
+		`{"op":"remove","path":"/a"}`,
+		`[{"op":"bogus","path":"/a"}]`,
+		`[{"op":"add","path":"a","value":1}]`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"replace","path":"/z","value":1}]`,
+		`[{"op":"remove","path":"/b/3"}]`,
+		`[{"op":"add","path":"/b/01","value":1}]`,
+		`[{"op":"test","path":"/a","value":2}]`,
+		`[{"op":"move","from":"/b","path":"/b/0"}]`,
+	}
+
+	for _, patch := range tests {
+		_, err := applyPatch([]byte(doc), []byte(patch))
+		assert.NotNil(t, err, "applyPatch() failed to return an error for %s", patch)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const defaultReloadQueueSize = 100
+
+// ReloadQueue is an HAProxy whose config reloads are executed one at a time by a single worker, so
+// that bursts of changes don't reload HAProxy concurrently. Callers of ReloadConfig block until
+// their reload has completed.
+type ReloadQueue struct {
+	HAProxy
+	jobs chan *reloadJob
+	done chan struct{}
+
+	mu        sync.Mutex
+	closed    bool
+	depth     int
+	running   *reloadJob
+	nextID    int
+	completed int
+	failed    int
+	byChange  map[string]int
+}
+
+// ReloadQueueStatus represents the serializable state of a ReloadQueue.
+type ReloadQueueStatus struct {
+	Depth     int            `json:"depth"`
+	Running   *ReloadStatus  `json:"running"`
+	Completed int            `json:"completed"`
+	Failed    int            `json:"failed"`
+	ByChange  map[string]int `json:"byChange"`
+}
+
+// ReloadStatus represents the serializable state of a single queued reload.
+type ReloadStatus struct {
+	ID         int       `json:"id"`
+	EnqueuedAt time.Time `json:"enqueuedAt"`
+	StartedAt  time.Time `json:"startedAt"`
+	Change     string    `json:"change,omitempty"`
+}
+
+type reloadJob struct {
+	status   ReloadStatus
+	strategy string
+	result   chan error
+}
+
+// NewReloadQueue returns a new ReloadQueue that reloads the given HAProxy and holds up to size
+// pending reloads; if size is zero, a default size is used.
+func NewReloadQueue(ha HAProxy, size int) *ReloadQueue {
+	if size <= 0 {
+		size = defaultReloadQueueSize
+	}
+	q := &ReloadQueue{
+		HAProxy:  ha,
+		jobs:     make(chan *reloadJob, size),
+		done:     make(chan struct{}),
+		byChange: make(map[string]int),
+	}
+	go q.work()
+	return q
+}
+
+// ReloadConfig queues a reload of the HAProxy config file and waits for it to complete.
+func (q *ReloadQueue) ReloadConfig() error {
+	return q.ReloadConfigUsing("")
+}
+
+// ReloadConfigUsing queues a reload of the HAProxy config file using the given reload strategy and
+// waits for it to complete.
+func (q *ReloadQueue) ReloadConfigUsing(strategy string) error {
+	return q.ReloadConfigFor(strategy, 0)
+}
+
+// ReloadConfigFor queues a reload of the HAProxy config file caused by the given type of change,
+// using the given reload strategy, and waits for it to complete; the reload is counted under its
+// change type in the queue's status.
+func (q *ReloadQueue) ReloadConfigFor(strategy string, change changeType) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return errors.New("the reload queue has been closed")
+	}
+	q.nextID++
+	job := &reloadJob{
+		status:   ReloadStatus{ID: q.nextID, EnqueuedAt: time.Now(), Change: change.String()},
+		strategy: strategy,
+		result:   make(chan error, 1),
+	}
+	select {
+	case q.jobs <- job:
+		q.depth++
+	default:
+		q.mu.Unlock()
+		return errors.New("the reload queue is full")
+	}
+	q.mu.Unlock()
+
+	return <-job.result
+}
+
+// Status returns the current state of the queue.
+func (q *ReloadQueue) Status() ReloadQueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	s := ReloadQueueStatus{
+		Depth:     q.depth,
+		Completed: q.completed,
+		Failed:    q.failed,
+		ByChange:  make(map[string]int, len(q.byChange)),
+	}
+	for c, n := range q.byChange {
+		s.ByChange[c] = n
+	}
+	if q.running != nil {
+		running := q.running.status
+		s.Running = &running
+	}
+	return s
+}
+
+// Close stops the queue from accepting new reloads and waits for the pending reloads to complete.
+func (q *ReloadQueue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+	<-q.done
+}
+
+// executes the queued reloads one at a time until the queue is closed and drained
+func (q *ReloadQueue) work() {
+	defer close(q.done)
+	for job := range q.jobs {
+		q.mu.Lock()
+		q.depth--
+		job.status.StartedAt = time.Now()
+		q.running = job
+		q.mu.Unlock()
+
+		err := q.HAProxy.ReloadConfigUsing(job.strategy)
+
+		q.mu.Lock()
+		q.running = nil
+		if err != nil {
+			q.failed++
+		} else {
+			q.completed++
+		}
+		if job.status.Change != "" {
+			q.byChange[job.status.Change]++
+		}
+		q.mu.Unlock()
+
+		job.result <- err
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// returns a ReloadQueue whose reloads block until released; each reload that starts is announced
+// on the returned channel
+func blockedReloadQueue(size int, reloadErr error) (*ReloadQueue, chan struct{}, func()) {
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	ha := testHelpers.NewHAProxyMock()
+	ha.reloadConfigAction = func() error {
+		started <- struct{}{}
+		<-release
+		return reloadErr
+	}
+	return NewReloadQueue(ha, size), started, func() { close(release) }
+}
+
+// waits for the queue to reach the given depth, failing the test if it doesn't
+func waitForDepth(t *testing.T, q *ReloadQueue, depth int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Status().Depth != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("ReloadQueue depth is %d, expected %d", q.Status().Depth, depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// ----------------------------------------------
+// ReloadQueue TESTS
+// ----------------------------------------------
+
+// Tests the "happy path" for the ReloadQueue.ReloadConfig() function.
+func Test_ReloadQueue_ReloadConfig(t *testing.T) {
+	q, started, release := blockedReloadQueue(10, nil)
+	defer q.Close()
+
+	results := make(chan error, 3)
+	go func() { results <- q.ReloadConfig() }()
+	<-started
+	go func() { results <- q.ReloadConfig() }()
+	go func() { results <- q.ReloadConfig() }()
+	waitForDepth(t, q, 2)
+
+	s := q.Status()
+	assert.EnsureNotNil(t, s.Running, "ReloadQueue.Status() did not report the running reload")
+	assert.Equal(t, s.Running.ID, 1, "ReloadQueue.Status() reported an unexpected running reload")
+	assert.Equal(t, s.Completed, 0, "ReloadQueue.Status() reported unexpected completed reloads")
+
+	release()
+	for i := 0; i < 3; i++ {
+		err := <-results
+		assert.Nil(t, err, "ReloadQueue.ReloadConfig() returned an unexpected error: %v", err)
+	}
+
+	s = q.Status()
+	assert.Equal(t, s.Depth, 0, "ReloadQueue.Status() reported unexpected depth")
+	assert.Nil(t, s.Running, "ReloadQueue.Status() reported a running reload after completion")
+	assert.Equal(t, s.Completed, 3, "ReloadQueue.Status() reported unexpected completed reloads")
+	assert.Equal(t, s.Failed, 0, "ReloadQueue.Status() reported unexpected failed reloads")
+}
+
+// Tests that the ReloadQueue.ReloadConfigFor() function counts reloads by the type of change that
+// caused them.
+func Test_ReloadQueue_ReloadConfigFor(t *testing.T) {
+	q := NewReloadQueue(testHelpers.NewHAProxyMock(), 0)
+	defer q.Close()
+
+	for _, c := range []changeType{changeFrontend, changeBackend, changeBackend, changeAll} {
+		err := q.ReloadConfigFor("", c)
+		assert.EnsureNil(t, err, "ReloadQueue.ReloadConfigFor() returned an unexpected error: %v", err)
+	}
+	err := q.ReloadConfig()
+	assert.EnsureNil(t, err, "ReloadQueue.ReloadConfig() returned an unexpected error: %v", err)
+
+	s := q.Status()
+	assert.Equal(t, s.Completed, 5, "ReloadQueue.Status() reported unexpected completed reloads")
+	assert.Equal(t, s.ByChange, map[string]int{"frontend": 1, "backend": 2, "all": 1},
+		"ReloadQueue.Status() reported unexpected reloads by change")
+}
+
+// Tests that the ReloadQueue.ReloadConfig() function returns the error of a failed reload.
+func Test_ReloadQueue_ReloadConfig_Error(t *testing.T) {
+	ha := testHelpers.NewHAProxyMock()
+	ha.reloadConfigAction = func() error { return errors.New("test") }
+	q := NewReloadQueue(ha, 0)
+	defer q.Close()
+
+	err := q.ReloadConfig()
+	assert.NotNil(t, err, "ReloadQueue.ReloadConfig() failed to return an expected error")
+	assert.Equal(t, q.Status().Failed, 1, "ReloadQueue.Status() reported unexpected failed reloads")
+}
+
+// Tests that the ReloadQueue.ReloadConfig() function rejects reloads when the queue is full.
+func Test_ReloadQueue_ReloadConfig_Full(t *testing.T) {
+	q, started, release := blockedReloadQueue(1, nil)
+	defer q.Close()
+	defer release()
+
+	go q.ReloadConfig()
+	<-started
+	go q.ReloadConfig()
+	waitForDepth(t, q, 1)
+
+	err := q.ReloadConfig()
+	assert.NotNil(t, err, "ReloadQueue.ReloadConfig() failed to reject a reload on a full queue")
+}
+
+// Tests that the ReloadQueue.Close() function drains the pending reloads.
+func Test_ReloadQueue_Close(t *testing.T) {
+	q, started, release := blockedReloadQueue(10, nil)
+
+	results := make(chan error, 2)
+	go func() { results <- q.ReloadConfig() }()
+	<-started
+	go func() { results <- q.ReloadConfig() }()
+	waitForDepth(t, q, 1)
+
+	closed := make(chan struct{})
+	go func() {
+		q.Close()
+		close(closed)
+	}()
+	release()
+	<-closed
+
+	assert.Equal(t, q.Status().Completed, 2, "ReloadQueue.Close() did not drain the pending reloads")
+	assert.Nil(t, <-results, "ReloadQueue.ReloadConfig() returned an unexpected error")
+	assert.Nil(t, <-results, "ReloadQueue.ReloadConfig() returned an unexpected error")
+
+	err := q.ReloadConfig()
+	assert.NotNil(t, err, "ReloadQueue.ReloadConfig() accepted a reload after the queue was closed")
+}
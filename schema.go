@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+const jsonSchemaVersion = "http://json-schema.org/draft-04/schema#"
+
+// JSONSchema is a JSON Schema document describing a serializable type.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Maximum              *int                   `json:"maximum,omitempty"`
+}
+
+// schemaRules describes the rules that ValidateBackend and ValidateFrontend apply to single fields,
+// keyed by the name of the struct and of the field; an empty enum value stands for the default.
+var schemaRules = map[string]JSONSchema{
+	"Backend.Mode":           {Enum: []string{"", modeHTTP, modeTCP, modeHealth}},
+	"Backend.ReloadStrategy": {Enum: []string{"", reloadStrategyCommand, reloadStrategySocket}},
+	"Backend.DefaultPort":    {Minimum: schemaBound(0), Maximum: schemaBound(65535)},
+	"BackendMember.Rise":     {Minimum: schemaBound(0)},
+	"BackendMember.Fall":     {Minimum: schemaBound(0)},
+	"Frontend.Mode":          {Enum: []string{"", modeHTTP, modeTCP, modeHealth}},
+	"Frontend.MaxConn":       {Minimum: schemaBound(0)},
+}
+
+// returns a pointer to the given minimum or maximum
+func schemaBound(i int) *int {
+	return &i
+}
+
+// NewJSONSchema returns the JSON Schema of the given struct, with property names in the given JSON
+// field style and the given properties marked as required. The allowed values and ranges of the
+// fields listed in schemaRules are included.
+func NewJSONSchema(v interface{}, fieldStyle string, required ...string) *JSONSchema {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s := schemaOf(t, fieldStyle)
+	s.Schema = jsonSchemaVersion
+	s.Title = t.Name()
+	for _, name := range required {
+		if fieldStyle == fieldStyleSnake {
+			name = camelToSnake(name)
+		}
+		s.Required = append(s.Required, name)
+	}
+	return s
+}
+
+// returns the schema of the given type; struct properties are named by their JSON tags
+func schemaOf(t reflect.Type, fieldStyle string) *JSONSchema {
+	if t == reflect.TypeOf(time.Time{}) {
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), fieldStyle)
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaOf(t.Elem(), fieldStyle)}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), fieldStyle)}
+	case reflect.Struct:
+		s := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" || f.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if fieldStyle == fieldStyleSnake {
+				name = camelToSnake(name)
+			}
+			p := schemaOf(f.Type, fieldStyle)
+			if r, ok := schemaRules[t.Name()+"."+f.Name]; ok {
+				p.Enum, p.Minimum, p.Maximum = r.Enum, r.Minimum, r.Maximum
+			}
+			s.Properties[name] = p
+		}
+		return s
+	}
+	return &JSONSchema{}
+}
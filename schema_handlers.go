@@ -0,0 +1,11 @@
+package main
+
+import (
+	"net/http"
+)
+
+// GetSchema returns the given JSON Schema document
+func GetSchema(w http.ResponseWriter, s *JSONSchema) {
+	// the schema is already in the configured field style, and its keywords must not be rewritten
+	util{}.writeResponse(w, http.StatusOK, JSONEncoder{}.Encode(s))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// ----------------------------------------------
+// GetSchema TESTS
+// ----------------------------------------------
+
+// Tests that the GetSchema() handler describes the backend fields and marks the name as required.
+func Test_GetSchema_Backend(t *testing.T) {
+	w := httptest.NewRecorder()
+	GetSchema(w, NewJSONSchema(Backend{}, "", "name"))
+
+	s := &JSONSchema{}
+	err := json.Unmarshal(w.Body.Bytes(), s)
+	assert.EnsureNil(t, err, "GetSchema() returned an invalid schema: %v", err)
+	assert.Equal(t, w.Code, 200, "GetSchema() returned unexpected status code")
+	assert.Equal(t, s.Schema, jsonSchemaVersion, "GetSchema() returned unexpected schema version")
+	assert.Equal(t, s.Title, "Backend", "GetSchema() returned unexpected title")
+	assert.Equal(t, s.Required, []string{"name"}, "GetSchema() returned unexpected required fields")
+
+	assert.EnsureNotNil(t, s.Properties["name"], "GetSchema() did not describe the name field")
+	assert.Equal(t, s.Properties["name"].Type, "string", "GetSchema() returned unexpected type for name")
+	assert.Nil(t, s.Properties["ID"], "GetSchema() described an unserialized field")
+
+	members := s.Properties["members"]
+	assert.EnsureNotNil(t, members, "GetSchema() did not describe the members field")
+	assert.Equal(t, members.Type, "array", "GetSchema() returned unexpected type for members")
+	assert.Equal(t, members.Items.Properties["port"].Type, "integer", "GetSchema() returned unexpected type for member port")
+	assert.Equal(t, members.Items.Properties["lastKnown"].Format, "date-time", "GetSchema() returned unexpected format for member lastKnown")
+	assert.Equal(t, s.Properties["meta"].AdditionalProperties.Type, "string", "GetSchema() returned unexpected type for meta values")
+}
+
+// Tests that the GetSchema() handler describes the allowed values and ranges of the backend fields
+// that are validated.
+func Test_GetSchema_BackendRules(t *testing.T) {
+	w := httptest.NewRecorder()
+	GetSchema(w, NewJSONSchema(Backend{}, "", "name"))
+
+	s := &JSONSchema{}
+	err := json.Unmarshal(w.Body.Bytes(), s)
+	assert.EnsureNil(t, err, "GetSchema() returned an invalid schema: %v", err)
+	assert.Equal(t, s.Properties["mode"].Enum, []string{"", "http", "tcp", "health"}, "GetSchema() returned unexpected values for mode")
+	assert.Equal(t, s.Properties["reloadStrategy"].Enum, []string{"", "command", "socket"}, "GetSchema() returned unexpected values for reloadStrategy")
+	assert.Nil(t, s.Properties["name"].Enum, "GetSchema() restricted the values of name")
+
+	port := s.Properties["defaultPort"]
+	assert.EnsureNotNil(t, port.Minimum, "GetSchema() did not describe the minimum defaultPort")
+	assert.EnsureNotNil(t, port.Maximum, "GetSchema() did not describe the maximum defaultPort")
+	assert.Equal(t, *port.Minimum, 0, "GetSchema() returned unexpected minimum for defaultPort")
+	assert.Equal(t, *port.Maximum, 65535, "GetSchema() returned unexpected maximum for defaultPort")
+
+	member := s.Properties["members"].Items
+	for _, name := range []string{"rise", "fall"} {
+		assert.EnsureNotNil(t, member.Properties[name].Minimum, "GetSchema() did not describe the minimum member %s", name)
+		assert.Equal(t, *member.Properties[name].Minimum, 0, "GetSchema() returned unexpected minimum for member %s", name)
+		assert.Nil(t, member.Properties[name].Maximum, "GetSchema() returned unexpected maximum for member %s", name)
+	}
+}
+
+// Tests that the GetSchema() handler describes the allowed values and ranges of the frontend fields
+// that are validated, with the properties named in the snake_case field style.
+func Test_GetSchema_FrontendRules(t *testing.T) {
+	w := httptest.NewRecorder()
+	GetSchema(w, NewJSONSchema(Frontend{}, fieldStyleSnake, "name"))
+
+	s := &JSONSchema{}
+	err := json.Unmarshal(w.Body.Bytes(), s)
+	assert.EnsureNil(t, err, "GetSchema() returned an invalid schema: %v", err)
+	assert.Equal(t, s.Properties["mode"].Enum, []string{"", "http", "tcp", "health"}, "GetSchema() returned unexpected values for mode")
+	assert.EnsureNotNil(t, s.Properties["maxconn"].Minimum, "GetSchema() did not describe the minimum maxconn")
+	assert.Equal(t, *s.Properties["maxconn"].Minimum, 0, "GetSchema() returned unexpected minimum for maxconn")
+}
+
+// Tests that the GetSchema() handler describes the frontend fields and marks the name as required.
+func Test_GetSchema_Frontend(t *testing.T) {
+	w := httptest.NewRecorder()
+	GetSchema(w, NewJSONSchema(Frontend{}, "", "name"))
+
+	s := &JSONSchema{}
+	err := json.Unmarshal(w.Body.Bytes(), s)
+	assert.EnsureNil(t, err, "GetSchema() returned an invalid schema: %v", err)
+	assert.Equal(t, s.Title, "Frontend", "GetSchema() returned unexpected title")
+	assert.Equal(t, s.Required, []string{"name"}, "GetSchema() returned unexpected required fields")
+	assert.EnsureNotNil(t, s.Properties["defaultBackend"], "GetSchema() did not describe the defaultBackend field")
+	assert.Equal(t, s.Properties["rules"].Items.Type, "string", "GetSchema() returned unexpected type for rules")
+}
+
+// Tests that the GetSchema() handler names the properties in the snake_case field style.
+func Test_GetSchema_SnakeCase(t *testing.T) {
+	w := httptest.NewRecorder()
+	GetSchema(w, NewJSONSchema(Frontend{}, fieldStyleSnake, "name", "defaultBackend"))
+
+	s := &JSONSchema{}
+	err := json.Unmarshal(w.Body.Bytes(), s)
+	assert.EnsureNil(t, err, "GetSchema() returned an invalid schema: %v", err)
+	assert.NotNil(t, s.Properties["default_backend"], "GetSchema() did not rename the defaultBackend field")
+	assert.Equal(t, s.Required, []string{"name", "default_backend"}, "GetSchema() returned unexpected required fields")
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"log"
+	"os"
 	"testing"
 	"text/template"
 	"time"
@@ -49,6 +54,11 @@ func (TestHelpers) NewDBManagerMock() *DBManagerMock {
 	return &DBManagerMock{}
 }
 
+// NewServerMock returns a mock Server instance.
+func (TestHelpers) NewServerMock() *ServerMock {
+	return &ServerMock{}
+}
+
 // DBPath retrieves a DBPath for data layer testing.
 func (TestHelpers) DBPath(t *testing.T) string {
 	dbPath, err := ioutil.TempDir("", "conduit_test_db")
@@ -56,6 +66,15 @@ func (TestHelpers) DBPath(t *testing.T) string {
 	return dbPath
 }
 
+// CaptureLog returns everything written to the standard logger while executing fn.
+func (TestHelpers) CaptureLog(fn func()) string {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	fn()
+	return buf.String()
+}
+
 // DBPath retrieves a LevelDB instance for data layer testing.
 func (TestHelpers) LevelDB(t *testing.T, dbPath string) *leveldb.DB {
 	db, err := openLevelDBFromFile(leveldb.OpenFile, leveldb.RecoverFile, dbPath, nil)
@@ -192,13 +211,14 @@ func (b BackendTestData) OtherBackendMultiMembers() *Backend {
 type DatastoreMock struct {
 	Backends  Backends
 	Frontends Frontends
+	Template  string
+	SaveError *Error
 }
 
 func (db *DatastoreMock) GetAllBackends() (Backends, *Error) {
 	b := make(Backends, len(db.Backends))
 	for i, x := range db.Backends {
-		val := *x
-		b[i] = &val
+		b[i] = x.Copy()
 	}
 	return b, nil
 }
@@ -212,18 +232,19 @@ func (db *DatastoreMock) GetBackend(key string) (*Backend, *Error) {
 	if val == nil {
 		return nil, nil
 	}
-	b := *val
-	return &b, nil
+	return val.Copy(), nil
 }
 func (db *DatastoreMock) SaveBackend(b *Backend) *Error {
+	if db.SaveError != nil {
+		return db.SaveError
+	}
 	for _, x := range db.Backends {
 		if x.Name == b.Name {
-			*x = *b
+			*x = *b.Copy()
 			return nil
 		}
 	}
-	val := &Backend{}
-	*val = *b
+	val := b.Copy()
 	db.Backends = append(db.Backends, val)
 	return nil
 }
@@ -244,8 +265,7 @@ func (db *DatastoreMock) DeleteBackend(key string) *Error {
 func (db *DatastoreMock) GetAllFrontends() (Frontends, *Error) {
 	f := make(Frontends, len(db.Frontends))
 	for i, x := range db.Frontends {
-		val := *x
-		f[i] = &val
+		f[i] = x.Copy()
 	}
 	return f, nil
 }
@@ -260,18 +280,16 @@ func (db *DatastoreMock) GetFrontend(key string) (*Frontend, *Error) {
 	if val == nil {
 		return nil, nil
 	}
-	f := *val
-	return &f, nil
+	return val.Copy(), nil
 }
 func (db *DatastoreMock) SaveFrontend(f *Frontend) *Error {
 	for _, x := range db.Frontends {
 		if x.Name == f.Name {
-			*x = *f
+			*x = *f.Copy()
 			return nil
 		}
 	}
-	val := &Frontend{}
-	*val = *f
+	val := f.Copy()
 	db.Frontends = append(db.Frontends, val)
 	return nil
 }
@@ -289,17 +307,60 @@ func (db *DatastoreMock) DeleteFrontend(key string) *Error {
 	return nil
 }
 
+func (db *DatastoreMock) GetRaw(proxyType, key string) (*RawRecord, *Error) {
+	var v interface{}
+	switch proxyType {
+	case "backend":
+		b, _ := db.GetBackend(key)
+		if b != nil {
+			v = b
+		}
+	case "frontend":
+		f, _ := db.GetFrontend(key)
+		if f != nil {
+			v = f
+		}
+	}
+	return rawRecordOf(proxyType, key, v), nil
+}
+
+func (db *DatastoreMock) GetTemplate() (string, *Error) {
+	return db.Template, nil
+}
+
+func (db *DatastoreMock) SaveTemplate(text string) *Error {
+	if db.SaveError != nil {
+		return db.SaveError
+	}
+	db.Template = text
+	return nil
+}
+
+// returns the stored form of the given frontend or backend, or nil if v is nil
+func rawRecordOf(proxyType, key string, v interface{}) *RawRecord {
+	if v == nil {
+		return nil
+	}
+	value, _ := json.Marshal(v)
+	id := fmt.Sprintf("%s/%s", proxyType, key)
+	return &RawRecord{ID: id, ProxyType: proxyType, Key: id, Value: value}
+}
+
 // ----------------------------------------------
 // DataSvcMock
 // ----------------------------------------------
 
 type DataSvcMock struct {
-	Frontends   Frontends
-	Backends    Backends
-	GetAllError *Error
-	GetError    *Error
-	SaveError   *Error
-	DeleteError *Error
+	Frontends     Frontends
+	Backends      Backends
+	Template      string
+	LimitWarning  string
+	MemberWarning string
+	SnapshotError *Error
+	GetAllError   *Error
+	GetError      *Error
+	SaveError     *Error
+	DeleteError   *Error
 }
 
 func (svc *DataSvcMock) GetAllFrontends() (Frontends, *Error) {
@@ -308,8 +369,7 @@ func (svc *DataSvcMock) GetAllFrontends() (Frontends, *Error) {
 	}
 	b := make(Frontends, len(svc.Frontends))
 	for i, x := range svc.Frontends {
-		val := *x
-		b[i] = &val
+		b[i] = x.Copy()
 	}
 	return b, nil
 }
@@ -326,8 +386,7 @@ func (svc *DataSvcMock) GetFrontend(key string) (*Frontend, *Error) {
 	if val == nil {
 		return nil, nil
 	}
-	b := *val
-	return &b, nil
+	return val.Copy(), nil
 }
 func (svc *DataSvcMock) SaveFrontend(b *Frontend) *Error {
 	if svc.SaveError != nil {
@@ -335,12 +394,11 @@ func (svc *DataSvcMock) SaveFrontend(b *Frontend) *Error {
 	}
 	for _, x := range svc.Frontends {
 		if x.Name == b.Name {
-			*x = *b
+			*x = *b.Copy()
 			return nil
 		}
 	}
-	val := &Frontend{}
-	*val = *b
+	val := b.Copy()
 	svc.Frontends = append(svc.Frontends, val)
 	return nil
 }
@@ -367,8 +425,7 @@ func (svc *DataSvcMock) GetAllBackends() (Backends, *Error) {
 	}
 	b := make(Backends, len(svc.Backends))
 	for i, x := range svc.Backends {
-		val := *x
-		b[i] = &val
+		b[i] = x.Copy()
 	}
 	return b, nil
 }
@@ -385,8 +442,7 @@ func (svc *DataSvcMock) GetBackend(key string) (*Backend, *Error) {
 	if val == nil {
 		return nil, nil
 	}
-	b := *val
-	return &b, nil
+	return val.Copy(), nil
 }
 func (svc *DataSvcMock) SaveBackend(b *Backend) *Error {
 	if svc.SaveError != nil {
@@ -394,15 +450,36 @@ func (svc *DataSvcMock) SaveBackend(b *Backend) *Error {
 	}
 	for _, x := range svc.Backends {
 		if x.Name == b.Name {
-			*x = *b
+			*x = *b.Copy()
 			return nil
 		}
 	}
-	val := &Backend{}
-	*val = *b
+	val := b.Copy()
 	svc.Backends = append(svc.Backends, val)
 	return nil
 }
+func (svc *DataSvcMock) CloneBackend(source, name, version string) (*Backend, *Error) {
+	if svc.SaveError != nil {
+		return nil, svc.SaveError
+	}
+	src, _ := svc.GetBackend(source)
+	if src == nil {
+		return nil, NewErrorf(ErrNotFound, "the backend to clone does not exist")
+	}
+	if existing, _ := svc.GetBackend(name); existing != nil {
+		return nil, NewErrorf(ErrConflict, "a backend with the name %s already exists", name)
+	}
+	b := src.Copy()
+	b.Name = name
+	if version != "" {
+		b.Version = version
+		for i := range b.Members {
+			b.Members[i].Version = version
+		}
+	}
+	svc.Backends = append(svc.Backends, b.Copy())
+	return b, nil
+}
 func (svc *DataSvcMock) DeleteBackend(key string) *Error {
 	if svc.DeleteError != nil {
 		return svc.DeleteError
@@ -419,6 +496,177 @@ func (svc *DataSvcMock) DeleteBackend(key string) *Error {
 	}
 	return nil
 }
+func (svc *DataSvcMock) DeleteBackendCascade(key string) *Error {
+	if derr := svc.DeleteBackend(key); derr != nil {
+		return derr
+	}
+	for _, x := range svc.Frontends {
+		if x.DefaultBackend == key {
+			x.DefaultBackend = ""
+		}
+	}
+	return nil
+}
+func (svc *DataSvcMock) SwapBackendMembers(name string, members BackendMembers) (*Backend, *Error) {
+	if svc.SaveError != nil {
+		return nil, svc.SaveError
+	}
+	for _, x := range svc.Backends {
+		if x.Name == name {
+			x.Members = members.Copy()
+			return x.Copy(), nil
+		}
+	}
+	return nil, NewErrorf(ErrNotFound, "the backend does not exist")
+}
+func (svc *DataSvcMock) HeartbeatMembers(beats []MemberHeartbeat) ([]MemberHeartbeat, *Error) {
+	if svc.SaveError != nil {
+		return nil, svc.SaveError
+	}
+	notFound := []MemberHeartbeat{}
+	for _, hb := range beats {
+		found := false
+		for _, x := range svc.Backends {
+			if x.Name != hb.Backend {
+				continue
+			}
+			for i := range x.Members {
+				if x.Members[i].Name == hb.Member {
+					x.Members[i].LastKnown = time.Now()
+					found = true
+				}
+			}
+		}
+		if !found {
+			notFound = append(notFound, hb)
+		}
+	}
+	return notFound, nil
+}
+func (svc *DataSvcMock) SaveBackendMemberMeta(name, member string, meta map[string]string) *Error {
+	if svc.SaveError != nil {
+		return svc.SaveError
+	}
+	for _, x := range svc.Backends {
+		if x.Name != name {
+			continue
+		}
+		for i := range x.Members {
+			if x.Members[i].Name == member {
+				x.Members[i].Meta = meta
+				return nil
+			}
+		}
+	}
+	return NewErrorf(ErrNotFound, "the backend member does not exist")
+}
+func (svc *DataSvcMock) SetBackendMemberVersions(name, version string, backendToo bool) (*Backend, *Error) {
+	if svc.SaveError != nil {
+		return nil, svc.SaveError
+	}
+	for _, x := range svc.Backends {
+		if x.Name != name {
+			continue
+		}
+		for i := range x.Members {
+			x.Members[i].Version = version
+		}
+		if backendToo {
+			x.Version = version
+		}
+		return x.Copy(), nil
+	}
+	return nil, NewErrorf(ErrNotFound, "the backend does not exist")
+}
+func (svc *DataSvcMock) BackendLimitWarning() string {
+	return svc.LimitWarning
+}
+func (svc *DataSvcMock) SingleMemberWarning(b *Backend) string {
+	if len(b.Members) != 1 {
+		return ""
+	}
+	return svc.MemberWarning
+}
+func (svc *DataSvcMock) GetRaw(proxyType, key string) (*RawRecord, *Error) {
+	if svc.GetError != nil {
+		return nil, svc.GetError
+	}
+	var v interface{}
+	switch proxyType {
+	case "backend":
+		b, _ := svc.GetBackend(key)
+		if b != nil {
+			v = b
+		}
+	case "frontend":
+		f, _ := svc.GetFrontend(key)
+		if f != nil {
+			v = f
+		}
+	}
+	return rawRecordOf(proxyType, key, v), nil
+}
+func (svc *DataSvcMock) GetTemplate() (string, *Error) {
+	if svc.GetError != nil {
+		return "", svc.GetError
+	}
+	return svc.Template, nil
+}
+func (svc *DataSvcMock) SaveTemplate(text string) *Error {
+	if svc.SaveError != nil {
+		return svc.SaveError
+	}
+	if _, err := template.New("test").Parse(text); err != nil {
+		return NewErrorf(ErrBadData, "the template is invalid: %v", err)
+	}
+	svc.Template = text
+	return nil
+}
+func (svc *DataSvcMock) SweepExpired(now time.Time) (int, *Error) {
+	if svc.DeleteError != nil {
+		return 0, svc.DeleteError
+	}
+	count := 0
+	frontends := Frontends{}
+	for _, f := range svc.Frontends {
+		if f.Expired(now) {
+			count++
+			continue
+		}
+		frontends = append(frontends, f)
+	}
+	backends := Backends{}
+	for _, b := range svc.Backends {
+		if b.Expired(now) {
+			count++
+			continue
+		}
+		backends = append(backends, b)
+	}
+	svc.Frontends, svc.Backends = frontends, backends
+	return count, nil
+}
+func (svc *DataSvcMock) Sync() *Error {
+	return nil
+}
+func (svc *DataSvcMock) Drain() {
+}
+func (svc *DataSvcMock) WithTransaction(fn func(tx DataSvc) error) *Error {
+	if err := fn(svc); err != nil {
+		if derr, ok := err.(*Error); ok {
+			return derr
+		}
+		return NewError(ErrUnknown, err)
+	}
+	return nil
+}
+
+func (svc *DataSvcMock) Snapshot() (DataSvc, func(), *Error) {
+	if svc.SnapshotError != nil {
+		return nil, nil, svc.SnapshotError
+	}
+	return svc, func() {}, nil
+}
 
 // ----------------------------------------------
 // HAProxyMock
@@ -432,6 +680,10 @@ type HAProxyMock struct {
 	getBackendsAction  func() (Backends, error)
 	writeConfigAction  func(frontends Frontends, backends Backends) error
 	reloadConfigAction func() error
+	reloadStrategies   []string
+	reloadChanges      []changeType
+	isRunningAction    func() (bool, error)
+	getStatsAction     func() ([]map[string]string, error)
 }
 
 func (h *HAProxyMock) Template() *template.Template {
@@ -463,6 +715,15 @@ func (h *HAProxyMock) GetBackends() (Backends, error) {
 	return Backends{}, nil
 }
 
+func (h *HAProxyMock) RenderConfig(frontends Frontends, backends Backends) (string, error) {
+	if h.template == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	err := h.template.Execute(&buf, newTemplateData(frontends, backends, nil))
+	return buf.String(), err
+}
+
 func (h *HAProxyMock) WriteConfig(frontends Frontends, backends Backends) error {
 	if h.writeConfigAction != nil {
 		return h.writeConfigAction(frontends, backends)
@@ -471,6 +732,30 @@ func (h *HAProxyMock) WriteConfig(frontends Frontends, backends Backends) error
 }
 
 func (h *HAProxyMock) ReloadConfig() error {
+	return h.ReloadConfigUsing("")
+}
+
+func (h *HAProxyMock) IsRunning() (bool, error) {
+	if h.isRunningAction != nil {
+		return h.isRunningAction()
+	}
+	return true, nil
+}
+
+func (h *HAProxyMock) GetStats() ([]map[string]string, error) {
+	if h.getStatsAction != nil {
+		return h.getStatsAction()
+	}
+	return []map[string]string{}, nil
+}
+
+func (h *HAProxyMock) ReloadConfigFor(strategy string, change changeType) error {
+	h.reloadChanges = append(h.reloadChanges, change)
+	return h.ReloadConfigUsing(strategy)
+}
+
+func (h *HAProxyMock) ReloadConfigUsing(strategy string) error {
+	h.reloadStrategies = append(h.reloadStrategies, strategy)
 	if h.reloadConfigAction != nil {
 		return h.reloadConfigAction()
 	}
@@ -505,7 +790,12 @@ func (e *EncoderMock) Decode(b []byte, i interface{}) error {
 // DBManagerMock
 // ----------------------------------------------
 
-type DBManagerMock struct{}
+type DBManagerMock struct {
+	CompactError error
+	Compactions  int
+	RecoverError error
+	Recoveries   int
+}
 
 func (m *DBManagerMock) Close() error {
 	return nil
@@ -513,3 +803,36 @@ func (m *DBManagerMock) Close() error {
 func (m *DBManagerMock) NewDatastore() Datastore {
 	return nil
 }
+func (m *DBManagerMock) Compact() error {
+	if m.CompactError != nil {
+		return m.CompactError
+	}
+	m.Compactions++
+	return nil
+}
+func (m *DBManagerMock) Recover() error {
+	if m.RecoverError != nil {
+		return m.RecoverError
+	}
+	m.Recoveries++
+	return nil
+}
+
+// ----------------------------------------------
+// ServerMock
+// ----------------------------------------------
+
+type ServerMock struct {
+	Stops      int
+	stopAction func()
+}
+
+func (s *ServerMock) Run(config *Config, dbMgr DBManager, tmpl *template.Template) {}
+func (s *ServerMock) Stop() {
+	s.Stops++
+	if s.stopAction != nil {
+		s.stopAction()
+	}
+}
+func (s *ServerMock) SignalShutdown() {}
+func (s *ServerMock) SignalRestart()  {}
@@ -1,6 +1,15 @@
 package main
 
-import "net/http"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type util struct{}
 
@@ -16,7 +25,216 @@ func (u util) conflict(w http.ResponseWriter, enc Encoder, err string) {
 	u.writeResponse(w, http.StatusConflict, enc.Encode(NewErrorResponse(http.StatusConflict, err)))
 }
 
+// writes the given error as an ErrorResponse with the status code of its type
+func (u util) writeError(w http.ResponseWriter, enc Encoder, err *Error) {
+	code := statusOf(err)
+	u.writeResponse(w, code, enc.Encode(NewErrorResponse(code, err.Error())))
+}
+
+// writes the given error as an ErrorResponse with the status code of its type, unless it's a server
+// error, which is panicked with instead so that the recovery middleware logs it and responds
+func (u util) writeClientError(w http.ResponseWriter, enc Encoder, err *Error) {
+	if statusOf(err) >= http.StatusInternalServerError {
+		panic(err)
+	}
+	u.writeError(w, enc, err)
+}
+
 func (util) writeResponse(w http.ResponseWriter, code int, body string) {
 	w.WriteHeader(code)
 	w.Write([]byte(body))
 }
+
+// ListEnvelope wraps the results of a list request with pagination metadata.
+type ListEnvelope struct {
+	Data   []interface{} `json:"data"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// listOptions holds the query parameters that control how a list response is written.
+type listOptions struct {
+	Envelope bool
+	Limit    int
+	Offset   int
+	Fields   []string
+
+	// only the records modified after this time are listed, unless it's zero
+	ModifiedSince time.Time
+}
+
+// parses the list query parameters (envelope, limit, offset, fields, modifiedSince) from the given
+// request
+func (util) parseListOptions(r *http.Request) (listOptions, error) {
+	opts := listOptions{}
+	q := r.URL.Query()
+
+	if v := q.Get("envelope"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("the envelope value '%s' is invalid - must be true or false", v)
+		}
+		opts.Envelope = b
+	}
+	if v := q.Get("limit"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			return opts, fmt.Errorf("the limit value '%s' is invalid - must be a non-negative integer", v)
+		}
+		opts.Limit = i
+	}
+	if v := q.Get("offset"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			return opts, fmt.Errorf("the offset value '%s' is invalid - must be a non-negative integer", v)
+		}
+		opts.Offset = i
+	}
+	if v := q.Get("modifiedSince"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return opts, fmt.Errorf("the modifiedSince value '%s' is invalid - must be an RFC3339 time", v)
+		}
+		opts.ModifiedSince = t
+	}
+	opts.Fields = util{}.parseFields(r)
+	return opts, nil
+}
+
+// reads the body of the given request; an entirely empty body is rejected with an ErrBadData error,
+// so that a client that accidentally sends nothing doesn't save a zero-value object, whereas an empty
+// JSON object ({}) is returned to be decoded as usual
+func (util) readBody(r *http.Request) ([]byte, *Error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, NewError(ErrUnknown, err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, NewErrorf(ErrBadData, "the request body is empty")
+	}
+	return body, nil
+}
+
+// parses the raw query parameter, returning true if the stored form of a record should be returned
+func (util) parseRaw(r *http.Request) (bool, error) {
+	switch v := r.URL.Query().Get("raw"); v {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("the raw value '%s' is invalid - must be 'true' or 'false'", v)
+	}
+}
+
+// writes the stored form of the frontend or backend of the given proxy type; its keys are never
+// rewritten, so that it's returned exactly as stored
+func (u util) writeRaw(w http.ResponseWriter, enc Encoder, svc DataSvc, proxyType, name string) {
+	status, body, err := u.raw(svc, proxyType, name)
+	if err != nil {
+		u.writeError(w, enc, err)
+		return
+	}
+	u.writeResponse(w, status, body)
+}
+
+// returns the response containing the stored form of the frontend or backend of the given proxy
+// type
+func (util) raw(svc DataSvc, proxyType, name string) (int, string, *Error) {
+	rec, err := svc.GetRaw(proxyType, name)
+	if err != nil {
+		return 0, "", err
+	}
+	if rec == nil {
+		return 0, "", NewErrorf(ErrNotFound, "the %s with name %s does not exist", proxyType, name)
+	}
+	return http.StatusOK, JSONEncoder{}.Encode(rec), nil
+}
+
+// parses the comma-separated fields query parameter, which selects the fields of the response
+// objects to return; nil is returned if all fields should be returned
+func (util) parseFields(r *http.Request) []string {
+	var fields []string
+	for _, f := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// projects the given object onto the given fields by encoding it and keeping only the matching
+// keys, in the order the fields were given; fields the object doesn't have are ignored
+func (util) project(enc Encoder, v interface{}, fields []string) interface{} {
+	if len(fields) == 0 {
+		return v
+	}
+	obj := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(enc.Encode(v)), &obj); err != nil {
+		// not an object, so there are no fields to select
+		return v
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	written := map[string]bool{}
+	for _, f := range fields {
+		value, ok := obj[f]
+		if !ok || written[f] {
+			continue
+		}
+		if len(written) > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(f)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(value)
+		written[f] = true
+	}
+	buf.WriteByte('}')
+	return json.RawMessage(buf.Bytes())
+}
+
+// writes a list response containing the page of items selected by the given options
+func (u util) writeList(w http.ResponseWriter, enc Encoder, items []interface{}, opts listOptions) {
+	u.writeResponse(w, http.StatusOK, u.list(enc, items, opts))
+}
+
+// returns the body of a list response containing the page of items selected by the given options,
+// wrapped in a ListEnvelope if requested; a limit of zero means that all remaining items are
+// returned
+func (u util) list(enc Encoder, items []interface{}, opts listOptions) string {
+	total := len(items)
+	start := opts.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if opts.Limit > 0 && start+opts.Limit < total {
+		end = start + opts.Limit
+	}
+	page := items[start:end]
+	if len(opts.Fields) > 0 {
+		projected := make([]interface{}, len(page))
+		for i, item := range page {
+			projected[i] = u.project(enc, item, opts.Fields)
+		}
+		page = projected
+	}
+
+	if !opts.Envelope {
+		return enc.EncodeMulti(page...)
+	}
+	if page == nil {
+		// so empty results produce '[]' and not 'null'
+		page = []interface{}{}
+	}
+	return enc.Encode(&ListEnvelope{
+		Data:   page,
+		Total:  total,
+		Limit:  opts.Limit,
+		Offset: opts.Offset,
+	})
+}
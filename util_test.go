@@ -63,3 +63,65 @@ func Test_util_conflict(t *testing.T) {
 	assert.Equal(t, rw.Code, expCode, "conflict() returned unexpected status code")
 	assert.Equal(t, rw.Body.String(), expBody, "conflict() returned unexpected body")
 }
+
+// Tests that the util.writeClientError() function writes client errors and panics with server errors.
+func Test_util_writeClientError(t *testing.T) {
+	u := util{}
+	enc := JSONEncoder{}
+	rw := httptest.NewRecorder()
+
+	u.writeClientError(rw, enc, NewErrorf(ErrDrift, "drifted!"))
+	assert.Equal(t, rw.Code, http.StatusConflict, "writeClientError() returned unexpected status code")
+	assert.Equal(t, rw.Body.String(), `{"code":409,"message":"drifted!"}`, "writeClientError() returned unexpected body")
+
+	f := func() { u.writeClientError(httptest.NewRecorder(), enc, NewErrorf(ErrDB, "failed!")) }
+	assert.Panic(t, f, "writeClientError() failed to panic with a server error")
+}
+
+// Tests that the util.writeList() function pages results and only wraps them when requested.
+func Test_util_writeList(t *testing.T) {
+	u := util{}
+	enc := JSONEncoder{}
+	items := []interface{}{"a", "b", "c"}
+
+	testCases := []struct {
+		Opts    listOptions
+		ExpBody string
+	}{
+		{Opts: listOptions{}, ExpBody: `["a","b","c"]`},
+		{Opts: listOptions{Limit: 2}, ExpBody: `["a","b"]`},
+		{Opts: listOptions{Offset: 1}, ExpBody: `["b","c"]`},
+		{Opts: listOptions{Offset: 5}, ExpBody: `[]`},
+		{Opts: listOptions{Envelope: true}, ExpBody: `{"data":["a","b","c"],"total":3,"limit":0,"offset":0}`},
+		{Opts: listOptions{Envelope: true, Limit: 1, Offset: 2}, ExpBody: `{"data":["c"],"total":3,"limit":1,"offset":2}`},
+	}
+
+	for _, testCase := range testCases {
+		rw := httptest.NewRecorder()
+		u.writeList(rw, enc, items, testCase.Opts)
+		assert.Equal(t, rw.Code, http.StatusOK, "writeList() returned unexpected status code")
+		assert.Equal(t, rw.Body.String(), testCase.ExpBody, "writeList() returned unexpected body for options %+v", testCase.Opts)
+	}
+}
+
+// Tests that the util.project() function selects the requested fields in the configured style.
+func Test_util_project(t *testing.T) {
+	u := util{}
+	f := &Frontend{Name: "web", Bind: "*:80", DefaultBackend: "app"}
+
+	testCases := []struct {
+		Enc     Encoder
+		Fields  []string
+		ExpBody string
+	}{
+		{Enc: JSONEncoder{}, Fields: []string{"defaultBackend", "name"}, ExpBody: `{"defaultBackend":"app","name":"web"}`},
+		{Enc: JSONEncoder{}, Fields: []string{"name", "name", "unknown"}, ExpBody: `{"name":"web"}`},
+		{Enc: JSONEncoder{FieldStyle: fieldStyleSnake}, Fields: []string{"default_backend"}, ExpBody: `{"default_backend":"app"}`},
+		{Enc: JSONEncoder{}, Fields: []string{"unknown"}, ExpBody: `{}`},
+	}
+
+	for _, testCase := range testCases {
+		body := testCase.Enc.Encode(u.project(testCase.Enc, f, testCase.Fields))
+		assert.Equal(t, body, testCase.ExpBody, "project() returned unexpected body for fields %v", testCase.Fields)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// FieldError is a validation error of a single field of a frontend or backend.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error returns the message of the error.
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// returns a new FieldError for the given field with a message formatted according to a format
+// specifier
+func fieldErrorf(field, format string, a ...interface{}) *FieldError {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, a...)}
+}
+
+// ValidationError is the set of field errors that make a frontend or backend invalid.
+type ValidationError []error
+
+// Error returns the messages of the field errors, separated by semicolons.
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validationResult is the serializable result of validating a proposed frontend or backend.
+type validationResult struct {
+	Errors []error `json:"errors"`
+}
+
+// returns the status code and body of the response to a validation request, given the errors found
+func validationResponse(enc Encoder, errs []error) (int, string) {
+	if len(errs) == 0 {
+		return http.StatusOK, enc.Encode(&validationResult{Errors: []error{}})
+	}
+	return http.StatusBadRequest, enc.Encode(&validationResult{Errors: errs})
+}
+
+// ValidateBackend returns a FieldError for each invalid field of the given backend, or nil if the
+// backend is valid.
+func ValidateBackend(b *Backend) []error {
+	errs := []error{}
+	if b.Name == "" {
+		errs = append(errs, fieldErrorf("name", "name is required"))
+	}
+	switch b.ReloadStrategy {
+	case "", reloadStrategyCommand, reloadStrategySocket:
+	default:
+		errs = append(errs, fieldErrorf("reloadStrategy", "reloadStrategy '%s' is invalid - must be '%s' or '%s'",
+			b.ReloadStrategy, reloadStrategyCommand, reloadStrategySocket))
+	}
+	if b.HealthCheck != "" {
+		switch {
+		case b.Mode != "http":
+			errs = append(errs, fieldErrorf("healthCheck", "healthCheck is only supported by backends with mode 'http'"))
+		case strings.ContainsAny(b.HealthCheck, "\r\n"):
+			errs = append(errs, fieldErrorf("healthCheck", "healthCheck must be a single line"))
+		}
+	}
+	if b.DefaultPort < 0 || b.DefaultPort > 65535 {
+		errs = append(errs, fieldErrorf("defaultPort", "defaultPort %d is invalid - must be between 1 and 65535", b.DefaultPort))
+	}
+	for _, m := range b.Members {
+		if m.Rise < 0 || m.Fall < 0 {
+			errs = append(errs, fieldErrorf("members", "member %s is invalid - rise and fall must not be negative", m.Name))
+		}
+	}
+	if err := checkMemberAddresses(withDefaultPort(b.Members, b.DefaultPort)); err != nil {
+		errs = append(errs, fieldErrorf("members", "%v", err))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateFrontend returns a FieldError for each invalid field of the given frontend, or nil if the
+// frontend is valid.
+func ValidateFrontend(f *Frontend) []error {
+	errs := []error{}
+	if f.Name == "" {
+		errs = append(errs, fieldErrorf("name", "name is required"))
+	}
+	if _, err := normalizeBind(f.Bind); err != nil {
+		errs = append(errs, fieldErrorf("bind", "%v", err))
+	}
+	if f.MaxConn < 0 {
+		errs = append(errs, fieldErrorf("maxconn", "maxconn %d is invalid - must not be negative", f.MaxConn))
+	}
+	for i, r := range f.HTTPRequests {
+		if strings.TrimSpace(r) == "" || strings.ContainsAny(r, "\r\n") {
+			errs = append(errs, fieldErrorf("httpRequests", "http-request rule %d is invalid - must be a single non-empty line", i))
+		}
+	}
+	for i, r := range f.RoutingRules {
+		switch {
+		case strings.TrimSpace(r.Condition) == "" || strings.ContainsAny(r.Condition, "\r\n"):
+			errs = append(errs, fieldErrorf("routingRules", "routing rule %d is invalid - must have a single-line condition", i))
+		case r.Backend == "":
+			errs = append(errs, fieldErrorf("routingRules", "routing rule %d is invalid - must have a backend", i))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validates the given comma-separated list of bind addresses and normalizes each into host:port
+// form; a bare port (e.g. "80") is normalized to listen on all addresses (e.g. "*:80"), and the
+// same address may not be bound more than once
+func normalizeBind(bind string) (string, error) {
+	if bind == "" {
+		return "", nil
+	}
+
+	seen := make(map[string]bool)
+	addrs := strings.Split(bind, ",")
+	for i, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		host, port := "*", addr
+		if j := strings.LastIndex(addr, ":"); j >= 0 {
+			host, port = addr[:j], addr[j+1:]
+		}
+		if strings.ContainsAny(host, " \t") || !isValidPortRange(port) {
+			return "", fmt.Errorf("bind address '%s' is invalid - must be in host:port form", addr)
+		}
+		addrs[i] = host + ":" + port
+
+		// an empty host and "*" both listen on all addresses
+		key := addrs[i]
+		if host == "" {
+			key = "*:" + port
+		}
+		if seen[key] {
+			return "", fmt.Errorf("bind address '%s' is specified more than once", addrs[i])
+		}
+		seen[key] = true
+	}
+	return strings.Join(addrs, ","), nil
+}
+
+// ensures that no two of the given members have the same host and port
+func checkMemberAddresses(members BackendMembers) error {
+	seen := make(map[string]string)
+	for _, m := range members {
+		addr := strings.ToLower(m.Address())
+		if name, ok := seen[addr]; ok {
+			return fmt.Errorf("members '%s' and '%s' have the same address '%s'", name, m.Name, m.Address())
+		}
+		seen[addr] = m.Name
+	}
+	return nil
+}
+
+// returns a copy of the given members in which the members without a port have the given default
+// port; if the default is zero, the members are returned unchanged
+func withDefaultPort(members BackendMembers, port int) BackendMembers {
+	if port == 0 {
+		return members
+	}
+	defaulted := make(BackendMembers, len(members))
+	for i, m := range members {
+		if m.Port == 0 {
+			m.Port = port
+		}
+		defaulted[i] = m
+	}
+	return defaulted
+}
+
+// determines if the given value is a valid port or port range (e.g. "80" or "8000-8010")
+func isValidPortRange(s string) bool {
+	ports := strings.SplitN(s, "-", 2)
+	for _, p := range ports {
+		i, err := strconv.Atoi(p)
+		if err != nil || i < 1 || i > 65535 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// asserts that the given errors consist of a single FieldError for the given field
+func assertFieldError(t *testing.T, errs []error, field, name string) {
+	if !assert.Equal(t, len(errs), 1, "%s returned unexpected errors: %v", name, errs) {
+		return
+	}
+	err, ok := errs[0].(*FieldError)
+	if !assert.True(t, ok, "%s returned an error that is not a FieldError: %v", name, errs[0]) {
+		return
+	}
+	assert.Equal(t, err.Field, field, "%s returned an error for an unexpected field", name)
+}
+
+// ----------------------------------------------
+// ValidateBackend TESTS
+// ----------------------------------------------
+
+// Tests that the ValidateBackend() function accepts a valid backend.
+func Test_ValidateBackend(t *testing.T) {
+	b := &Backend{
+		Name:           "test-app",
+		ReloadStrategy: reloadStrategySocket,
+		Members: BackendMembers{
+			{Name: "m1", Host: "10.0.0.1", Port: 8080},
+			{Name: "m2", Host: "10.0.0.2", Port: 8080},
+		},
+	}
+	errs := ValidateBackend(b)
+	assert.Nil(t, errs, "ValidateBackend() returned unexpected errors: %v", errs)
+}
+
+// Tests that the ValidateBackend() function requires a name.
+func Test_ValidateBackend_Name(t *testing.T) {
+	errs := ValidateBackend(&Backend{})
+	assertFieldError(t, errs, "name", "ValidateBackend()")
+}
+
+// Tests that the ValidateBackend() function rejects an unknown reload strategy.
+func Test_ValidateBackend_ReloadStrategy(t *testing.T) {
+	errs := ValidateBackend(&Backend{Name: "test-app", ReloadStrategy: "restart"})
+	assertFieldError(t, errs, "reloadStrategy", "ValidateBackend()")
+}
+
+// Tests that the ValidateBackend() function rejects members with the same address.
+func Test_ValidateBackend_Members(t *testing.T) {
+	b := &Backend{
+		Name: "test-app",
+		Members: BackendMembers{
+			{Name: "m1", Host: "10.0.0.1", Port: 8080},
+			{Name: "m2", Host: "10.0.0.1", Port: 8080},
+		},
+	}
+	errs := ValidateBackend(b)
+	assertFieldError(t, errs, "members", "ValidateBackend()")
+}
+
+// Tests that the ValidateBackend() function rejects negative member rise and fall thresholds.
+func Test_ValidateBackend_RiseFall(t *testing.T) {
+	for _, m := range []BackendMember{{Name: "m1", Rise: -1}, {Name: "m1", Fall: -1}} {
+		errs := ValidateBackend(&Backend{Name: "test-app", Members: BackendMembers{m}})
+		assertFieldError(t, errs, "members", fmt.Sprintf("ValidateBackend(%d, %d)", m.Rise, m.Fall))
+	}
+
+	errs := ValidateBackend(&Backend{Name: "test-app", Members: BackendMembers{{Name: "m1", Host: "10.0.0.1", Port: 8080, Rise: 3, Fall: 2}}})
+	assert.Nil(t, errs, "ValidateBackend() returned unexpected errors: %v", errs)
+}
+
+// Tests that the ValidateBackend() function rejects a default port out of range, and compares member
+// addresses using the default port.
+func Test_ValidateBackend_DefaultPort(t *testing.T) {
+	for _, port := range []int{-1, 65536} {
+		errs := ValidateBackend(&Backend{Name: "test-app", DefaultPort: port})
+		assertFieldError(t, errs, "defaultPort", fmt.Sprintf("ValidateBackend(%d)", port))
+	}
+
+	b := &Backend{
+		Name:        "test-app",
+		DefaultPort: 8080,
+		Members: BackendMembers{
+			{Name: "m1", Host: "10.0.0.1"},
+			{Name: "m2", Host: "10.0.0.1", Port: 8080},
+		},
+	}
+	errs := ValidateBackend(b)
+	assertFieldError(t, errs, "members", "ValidateBackend()")
+}
+
+// Tests that the ValidateBackend() function only accepts a single-line healthCheck on backends with
+// mode http.
+func Test_ValidateBackend_HealthCheck(t *testing.T) {
+	errs := ValidateBackend(&Backend{Name: "test-app", Mode: "http", HealthCheck: "GET /health"})
+	assert.Nil(t, errs, "ValidateBackend() returned unexpected errors: %v", errs)
+
+	for _, b := range []*Backend{
+		{Name: "test-app", Mode: "tcp", HealthCheck: "GET /health"},
+		{Name: "test-app", HealthCheck: "GET /health"},
+		{Name: "test-app", Mode: "http", HealthCheck: "GET /health\n    option forwardfor"},
+	} {
+		errs := ValidateBackend(b)
+		assertFieldError(t, errs, "healthCheck", fmt.Sprintf("ValidateBackend(%q, %q)", b.Mode, b.HealthCheck))
+	}
+}
+
+// Tests that the ValidateBackend() function reports every invalid field.
+func Test_ValidateBackend_Multiple(t *testing.T) {
+	errs := ValidateBackend(&Backend{ReloadStrategy: "restart"})
+	assert.Equal(t, len(errs), 2, "ValidateBackend() returned unexpected errors: %v", errs)
+}
+
+// ----------------------------------------------
+// ValidateFrontend TESTS
+// ----------------------------------------------
+
+// Tests that the ValidateFrontend() function accepts a valid frontend.
+func Test_ValidateFrontend(t *testing.T) {
+	errs := ValidateFrontend(&Frontend{Name: "test-fe", Bind: "*:80,10.0.0.1:8000-8010"})
+	assert.Nil(t, errs, "ValidateFrontend() returned unexpected errors: %v", errs)
+}
+
+// Tests that the ValidateFrontend() function requires a name.
+func Test_ValidateFrontend_Name(t *testing.T) {
+	errs := ValidateFrontend(&Frontend{Bind: "*:80"})
+	assertFieldError(t, errs, "name", "ValidateFrontend()")
+}
+
+// Tests that the ValidateFrontend() function rejects an invalid bind address.
+func Test_ValidateFrontend_Bind(t *testing.T) {
+	for _, bind := range []string{"*:http", "*:0", "*:80,*:80", "a host:80"} {
+		errs := ValidateFrontend(&Frontend{Name: "test-fe", Bind: bind})
+		assertFieldError(t, errs, "bind", "ValidateFrontend("+bind+")")
+	}
+}
+
+// Tests that the ValidateFrontend() function rejects a negative maxconn.
+func Test_ValidateFrontend_MaxConn(t *testing.T) {
+	errs := ValidateFrontend(&Frontend{Name: "test-fe", Bind: "*:80", MaxConn: -1})
+	assertFieldError(t, errs, "maxconn", "ValidateFrontend()")
+
+	errs = ValidateFrontend(&Frontend{Name: "test-fe", Bind: "*:80", MaxConn: 2000})
+	assert.Nil(t, errs, "ValidateFrontend() returned unexpected errors: %v", errs)
+}
+
+// Tests that the ValidateFrontend() function requires each routing rule to have a single-line
+// condition and a backend.
+func Test_ValidateFrontend_RoutingRules(t *testing.T) {
+	for _, r := range []Rule{{Backend: "api"}, {Condition: "path_beg /api\nbind *:81", Backend: "api"}, {Condition: "path_beg /api"}} {
+		errs := ValidateFrontend(&Frontend{Name: "test-fe", Bind: "*:80", RoutingRules: []Rule{r}})
+		assertFieldError(t, errs, "routingRules", fmt.Sprintf("ValidateFrontend(%+v)", r))
+	}
+
+	errs := ValidateFrontend(&Frontend{Name: "test-fe", Bind: "*:80", RoutingRules: []Rule{{Condition: "path_beg /api", Backend: "api"}}})
+	assert.Nil(t, errs, "ValidateFrontend() returned unexpected errors: %v", errs)
+}
+
+// Tests that ValidateFrontend() rejects empty and multi-line http-request rules.
+func Test_ValidateFrontend_HTTPRequests(t *testing.T) {
+	for _, r := range []string{"", " ", "set-header X-A a\nbind *:81"} {
+		errs := ValidateFrontend(&Frontend{Name: "test-fe", Bind: "*:80", HTTPRequests: []string{r}})
+		assertFieldError(t, errs, "httpRequests", fmt.Sprintf("ValidateFrontend(%q)", r))
+	}
+
+	errs := ValidateFrontend(&Frontend{Name: "test-fe", Bind: "*:80", HTTPRequests: []string{"redirect scheme https unless { ssl_fc }"}})
+	assert.Nil(t, errs, "ValidateFrontend() returned unexpected errors: %v", errs)
+}
+
+// ----------------------------------------------
+// ValidationError TESTS
+// ----------------------------------------------
+
+// Tests that the ValidationError.Error() function joins the messages of its errors.
+func Test_ValidationError_Error(t *testing.T) {
+	err := ValidationError{fieldErrorf("name", "name is required"), errors.New("test")}
+	assert.Equal(t, err.Error(), "name is required; test", "ValidationError.Error() returned an unexpected message")
+}